@@ -0,0 +1,9 @@
+-- 0038_signal_rule_orders.sql
+-- Stores the order and guard settings the CLI syncs alongside each signal rule:
+-- bracket legs, data-quality guards, and the rule's content hash. Rules sized
+-- by notional, percent or risk have no fixed order_qty and are not stored yet;
+-- the sync endpoint reports them as skipped.
+
+ALTER TABLE signal_rules ADD COLUMN order_bracket_json TEXT;   -- take-profit / stop-loss legs
+ALTER TABLE signal_rules ADD COLUMN data_quality_json TEXT;    -- required KPIs, staleness and jump guards
+ALTER TABLE signal_rules ADD COLUMN version_hash TEXT;         -- content hash of the synced revision
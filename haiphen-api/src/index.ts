@@ -380,6 +380,11 @@ function onboardingLinks(env: Env) {
   };
 }
 
+// KPI names accepted by the metrics query endpoints. Ingest stores names as
+// the pipeline reports them, including '&' and ',' (e.g. "Unrealized P&L");
+// the CLI rule validator accepts the same set in expressions.
+const METRIC_KPI_RE = /^[\w\s.:/%()+,&-]{1,100}$/;
+
 function parseDateParam(s: string | null): string | null {
   if (!s) return null;
   if (!/^\d{4}-\d{2}-\d{2}$/.test(s)) return null;
@@ -692,7 +697,7 @@ async function route(req: Request, env: Env): Promise<Response> {
 
     const kpi = String(url.searchParams.get("kpi") ?? "").trim();
     if (!kpi) return err("invalid_request", "Missing kpi", requestId, 400, corsHeaders(req, env));
-    if (!/^[\w\s.:/%()+-]{1,100}$/.test(kpi)) return err("invalid_request", "Invalid kpi format", requestId, 400, corsHeaders(req, env));
+    if (!METRIC_KPI_RE.test(kpi)) return err("invalid_request", "Invalid kpi format", requestId, 400, corsHeaders(req, env));
 
     const dateParam = parseDateParam(url.searchParams.get("date"));
     const date = dateParam ?? (await latestDate(env));
@@ -713,7 +718,7 @@ async function route(req: Request, env: Env): Promise<Response> {
 
     const kpi = String(url.searchParams.get("kpi") ?? "").trim();
     if (!kpi) return err("invalid_request", "Missing kpi", requestId, 400, corsHeaders(req, env));
-    if (!/^[\w\s.:/%()+-]{1,100}$/.test(kpi)) return err("invalid_request", "Invalid kpi format", requestId, 400, corsHeaders(req, env));
+    if (!METRIC_KPI_RE.test(kpi)) return err("invalid_request", "Invalid kpi format", requestId, 400, corsHeaders(req, env));
 
     const sideRaw = String(url.searchParams.get("side") ?? "hi").trim().toLowerCase();
     const side = (sideRaw === "lo" ? "lo" : "hi") as "hi" | "lo";
@@ -3248,7 +3253,7 @@ async function route(req: Request, env: Env): Promise<Response> {
     const rows = await env.DB.prepare(
       `SELECT rule_id, name, status, symbols_json, entry_conditions_json, exit_conditions_json,
               order_side, order_type, order_qty, order_tif, cooldown_seconds, temporal_json,
-              version, created_at, updated_at
+              order_bracket_json, data_quality_json, version, version_hash, created_at, updated_at
        FROM signal_rules WHERE user_id = ? AND status != 'disabled' ORDER BY created_at DESC`
     ).bind(u.user_login).all();
     return okJson({ items: rows.results ?? [] }, requestId, corsHeaders(req, env));
@@ -3391,14 +3396,24 @@ async function route(req: Request, env: Env): Promise<Response> {
     }
 
     let upserted = 0;
+    const skipped: { rule_id: string | null; name: string | null; reason: string }[] = [];
     for (const r of body.rules) {
-      if (!r.rule_id || !r.name || !r.entry_conditions_json || !r.order_side || !r.order_qty) continue;
+      if (!r.rule_id || !r.name || !r.entry_conditions_json || !r.order_side) {
+        skipped.push({ rule_id: r.rule_id || null, name: r.name || null, reason: "missing rule_id, name, entry_conditions_json or order_side" });
+        continue;
+      }
+      if (!r.order_qty) {
+        const reason = r.order_sizing_json ? "dynamically sized rules are not stored yet" : "missing order_qty";
+        skipped.push({ rule_id: r.rule_id, name: r.name, reason });
+        continue;
+      }
 
       await env.DB.prepare(`
         INSERT INTO signal_rules (rule_id, user_id, name, status, symbols_json,
           entry_conditions_json, exit_conditions_json, order_side, order_type,
-          order_qty, order_tif, cooldown_seconds, temporal_json, version)
-        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+          order_qty, order_tif, cooldown_seconds, temporal_json, version,
+          order_bracket_json, data_quality_json, version_hash)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
         ON CONFLICT (rule_id) DO UPDATE SET
           name = excluded.name,
           status = excluded.status,
@@ -3412,18 +3427,22 @@ async function route(req: Request, env: Env): Promise<Response> {
           cooldown_seconds = excluded.cooldown_seconds,
           temporal_json = excluded.temporal_json,
           version = excluded.version,
+          order_bracket_json = excluded.order_bracket_json,
+          data_quality_json = excluded.data_quality_json,
+          version_hash = excluded.version_hash,
           updated_at = strftime('%Y-%m-%dT%H:%M:%fZ','now')
       `).bind(
         r.rule_id, u.user_login, r.name, r.status || "active",
         r.symbols_json || null, r.entry_conditions_json, r.exit_conditions_json || null,
         r.order_side, r.order_type || "market", r.order_qty,
         r.order_tif || "day", r.cooldown_seconds ?? 300,
-        r.temporal_json || null, r.version ?? 1
+        r.temporal_json || null, r.version ?? 1,
+        r.order_bracket_json || null, r.data_quality_json || null, r.version_hash || null
       ).run();
       upserted++;
     }
 
-    return okJson({ ok: true, upserted }, requestId, corsHeaders(req, env));
+    return okJson({ ok: true, upserted, skipped }, requestId, corsHeaders(req, env));
   }
 
   // ---- INTERNAL: prospect digest (top investigations for daily email) ----
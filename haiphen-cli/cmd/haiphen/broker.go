@@ -96,6 +96,20 @@ func safetyConfig(cfg *config.Config) broker.SafetyConfig {
 	}
 }
 
+// dailyPL returns the account's realized plus unrealized P&L for the trading
+// day, measured from the profile's day-start equity baseline.
+func dailyPL(ctx context.Context, cfg *config.Config, b broker.Broker) (float64, error) {
+	path, err := broker.BaselinePath(cfg.Profile)
+	if err != nil {
+		return 0, err
+	}
+	acct, err := b.GetAccount(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return broker.OpenBaseline(path).DailyPL(acct, time.Now())
+}
+
 // brokerOption maps a UI label to a broker registry name.
 type brokerOption struct {
 	Label    string
@@ -247,6 +261,11 @@ func cmdBrokerInit(cfg *config.Config, _ store.Store) *cobra.Command {
 					crypto = "Enabled"
 				}
 				tui.TableRow(os.Stdout, "Crypto", crypto)
+				options := "Disabled"
+				if constraints.OptionsLevel > 0 {
+					options = fmt.Sprintf("Level %d", constraints.OptionsLevel)
+				}
+				tui.TableRow(os.Stdout, "Options", options)
 				tui.TableRow(os.Stdout, "Rate Limit", fmt.Sprintf("%d req/min", constraints.RateLimitRPM))
 			}
 
@@ -376,6 +395,10 @@ func cmdBrokerTrade(cfg *config.Config, _ store.Store) *cobra.Command {
 		limitPrice float64
 		stopPrice  float64
 		tifFlag    string
+		orderClass string
+		takeProfit float64
+		stopLoss   float64
+		stopLimit  float64
 		asJSON     bool
 		skipConfirm bool
 	)
@@ -437,12 +460,35 @@ func cmdBrokerTrade(cfg *config.Config, _ store.Store) *cobra.Command {
 				TIF:        tifFlag,
 			}
 
+			// Attached exit legs. Without --class, both legs make a bracket
+			// and a single leg makes an OTO order.
+			if takeProfit > 0 {
+				req.TakeProfit = &broker.TakeProfit{LimitPrice: takeProfit}
+			}
+			if stopLoss > 0 {
+				req.StopLoss = &broker.StopLoss{StopPrice: stopLoss, LimitPrice: stopLimit}
+			} else if stopLimit > 0 {
+				return fmt.Errorf("--stop-loss-limit requires --stop-loss")
+			}
+			req.OrderClass = strings.ToLower(orderClass)
+			if req.OrderClass == "" {
+				switch {
+				case req.TakeProfit != nil && req.StopLoss != nil:
+					req.OrderClass = broker.OrderClassBracket
+				case req.TakeProfit != nil || req.StopLoss != nil:
+					req.OrderClass = broker.OrderClassOTO
+				}
+			}
+
 			sc := safetyConfig(cfg)
 
 			// Safety checks.
 			if err := broker.ValidateOrderLimits(req, sc); err != nil {
 				return err
 			}
+			if err := broker.ValidateOrderClass(req); err != nil {
+				return err
+			}
 
 			// Connect and check daily loss.
 			b, err := connectBroker(cmd.Context(), cfg)
@@ -452,13 +498,9 @@ func cmdBrokerTrade(cfg *config.Config, _ store.Store) *cobra.Command {
 			defer b.Close()
 
 			if side == "buy" {
-				positions, err := b.GetPositions(cmd.Context())
+				dayPL, err := dailyPL(cmd.Context(), cfg, b)
 				if err == nil {
-					var totalPL float64
-					for _, p := range positions {
-						totalPL += p.UnrealizedPL
-					}
-					if err := broker.ValidateDailyLoss(totalPL, sc); err != nil {
+					if err := broker.ValidateDailyLoss(dayPL, sc); err != nil {
 						return err
 					}
 				}
@@ -469,6 +511,11 @@ func cmdBrokerTrade(cfg *config.Config, _ store.Store) *cobra.Command {
 				tui.InlineDisclaimer(os.Stdout)
 				fmt.Println()
 				tui.TableRow(os.Stdout, "Symbol", req.Symbol)
+				if c, err := broker.ParseOCC(req.Symbol); err == nil {
+					tui.TableRow(os.Stdout, "Contract", fmt.Sprintf("%s %s %s %s ×%d",
+						c.Underlying, c.Expiration.Format("2006-01-02"), strings.ToUpper(c.Type),
+						tui.FormatMoneyPlain(c.Strike), broker.OptionMultiplier))
+				}
 				tui.TableRow(os.Stdout, "Side", strings.ToUpper(req.Side))
 				tui.TableRow(os.Stdout, "Type", strings.ToUpper(req.Type))
 				tui.TableRow(os.Stdout, "Quantity", fmt.Sprintf("%.0f", req.Qty))
@@ -479,9 +526,22 @@ func cmdBrokerTrade(cfg *config.Config, _ store.Store) *cobra.Command {
 					tui.TableRow(os.Stdout, "Stop", tui.FormatMoneyPlain(req.StopPrice))
 				}
 				if req.LimitPrice > 0 {
-					tui.TableRow(os.Stdout, "Est. Value", tui.FormatMoneyPlain(req.Qty*req.LimitPrice))
+					tui.TableRow(os.Stdout, "Est. Value", tui.FormatMoneyPlain(req.Qty*req.LimitPrice*req.Multiplier()))
 				}
 				tui.TableRow(os.Stdout, "TIF", strings.ToUpper(req.TIF))
+				if req.OrderClass != "" {
+					tui.TableRow(os.Stdout, "Class", strings.ToUpper(req.OrderClass))
+				}
+				if req.TakeProfit != nil {
+					tui.TableRow(os.Stdout, "Take Profit", tui.FormatMoneyPlain(req.TakeProfit.LimitPrice))
+				}
+				if req.StopLoss != nil {
+					sl := tui.FormatMoneyPlain(req.StopLoss.StopPrice)
+					if req.StopLoss.LimitPrice > 0 {
+						sl += " (limit " + tui.FormatMoneyPlain(req.StopLoss.LimitPrice) + ")"
+					}
+					tui.TableRow(os.Stdout, "Stop Loss", sl)
+				}
 				fmt.Println()
 
 				ok, err := tui.Confirm("Confirm order?", false)
@@ -520,6 +580,10 @@ func cmdBrokerTrade(cfg *config.Config, _ store.Store) *cobra.Command {
 	cmd.Flags().Float64Var(&limitPrice, "limit-price", 0, "Limit price (required for limit/stop_limit)")
 	cmd.Flags().Float64Var(&stopPrice, "stop-price", 0, "Stop price (required for stop/stop_limit)")
 	cmd.Flags().StringVar(&tifFlag, "tif", "day", "Time in force: day, gtc, ioc, fok")
+	cmd.Flags().StringVar(&orderClass, "class", "", "Order class: simple, bracket, oco, oto (default: inferred from legs)")
+	cmd.Flags().Float64Var(&takeProfit, "take-profit", 0, "Take-profit limit price (attached leg)")
+	cmd.Flags().Float64Var(&stopLoss, "stop-loss", 0, "Stop-loss stop price (attached leg)")
+	cmd.Flags().Float64Var(&stopLimit, "stop-loss-limit", 0, "Stop-loss limit price (makes the stop leg a stop-limit)")
 	cmd.Flags().BoolVar(&asJSON, "json", false, "Output as JSON")
 	cmd.Flags().BoolVar(&skipConfirm, "yes", false, "Skip confirmation prompt")
 	return cmd
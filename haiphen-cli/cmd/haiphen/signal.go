@@ -33,14 +33,24 @@ func cmdSignal(cfg *config.Config, st store.Store) *cobra.Command {
 	cmd.AddCommand(
 		cmdSignalDaemon(cfg, st),
 		cmdSignalStop(cfg),
+		cmdSignalReload(cfg),
 		cmdSignalStatus(cfg),
+		cmdSignalControl(cfg),
+		cmdSignalResume(cfg),
 		cmdSignalAdd(cfg, st),
 		cmdSignalList(cfg),
 		cmdSignalRemove(cfg),
 		cmdSignalEnable(cfg),
 		cmdSignalPause(cfg),
+		cmdSignalHistory(cfg),
+		cmdSignalDiff(cfg),
+		cmdSignalRollback(cfg),
 		cmdSignalTest(cfg, st),
+		cmdSignalBacktest(cfg, st),
+		cmdSignalReplay(cfg),
 		cmdSignalLog(cfg),
+		cmdSignalOutbox(cfg, st),
+		cmdSignalRecordings(cfg),
 		cmdSignalSync(cfg, st),
 		cmdSignalPositions(cfg, st),
 		cmdSignalFilter(cfg),
@@ -52,8 +62,14 @@ func cmdSignal(cfg *config.Config, st store.Store) *cobra.Command {
 
 func cmdSignalDaemon(cfg *config.Config, st store.Store) *cobra.Command {
 	var (
-		foreground bool
-		dryRun     bool
+		foreground  bool
+		dryRun      bool
+		noRecord    bool
+		explain     bool
+		maxAge      time.Duration
+		fresh       bool
+		metricsAddr string
+		breaker     = sig.DefaultBreakerConfig()
 	)
 
 	cmd := &cobra.Command{
@@ -82,6 +98,12 @@ func cmdSignalDaemon(cfg *config.Config, st store.Store) *cobra.Command {
 				return fmt.Errorf("daemon already running (PID %d); stop with: haiphen signal stop", pid)
 			}
 
+			if metricsAddr != "" {
+				if _, err := sig.MetricsListenAddr(metricsAddr); err != nil {
+					return err
+				}
+			}
+
 			if !foreground {
 				// Fork a background process
 				exe, err := os.Executable()
@@ -95,6 +117,26 @@ func cmdSignalDaemon(cfg *config.Config, st store.Store) *cobra.Command {
 				if dryRun {
 					forkArgs = append(forkArgs, "--dry-run")
 				}
+				if noRecord {
+					forkArgs = append(forkArgs, "--no-record")
+				}
+				if explain {
+					forkArgs = append(forkArgs, "--explain")
+				}
+				if maxAge > 0 {
+					forkArgs = append(forkArgs, "--max-snapshot-age", maxAge.String())
+				}
+				if fresh {
+					forkArgs = append(forkArgs, "--fresh")
+				}
+				if metricsAddr != "" {
+					forkArgs = append(forkArgs, "--metrics-addr", metricsAddr)
+				}
+				for _, name := range []string{"breaker-failures", "breaker-blocks", "breaker-window", "breaker-drawdown-pct", "breaker-cancel-orders"} {
+					if f := cmd.Flags().Lookup(name); f.Changed {
+						forkArgs = append(forkArgs, "--"+name+"="+f.Value.String())
+					}
+				}
 
 				proc := exec.Command(exe, forkArgs...)
 				proc.Env = append(os.Environ(), "HAIPHEN_SIGNAL_TOKEN="+token)
@@ -161,6 +203,8 @@ func cmdSignalDaemon(cfg *config.Config, st store.Store) *cobra.Command {
 			ecfg.DaemonID = fmt.Sprintf("cli-%d", os.Getpid())
 			ecfg.Safety = safetyConfig(cfg)
 			ecfg.Safety.ConfirmOrders = false // Non-interactive
+			ecfg.DataQuality.MaxSnapshotAge = maxAge
+			ecfg.Breaker = breaker
 
 			events := make(chan sig.Event, 100)
 
@@ -169,8 +213,27 @@ func cmdSignalDaemon(cfg *config.Config, st store.Store) *cobra.Command {
 			ctx, cancel := context.WithCancel(cmd.Context())
 			defer cancel()
 
-			// Start event logger
-			go sig.EventLogger(ctx, events, cfg.APIOrigin, token)
+			// Events are queued durably and delivered to the API with retry
+			outboxPath, err := sig.OutboxPath(cfg.Profile)
+			if err != nil {
+				return err
+			}
+			outbox, err := sig.OpenOutbox(outboxPath)
+			if err != nil {
+				return fmt.Errorf("open outbox: %w", err)
+			}
+			defer outbox.Close()
+			engine.SetOutbox(outbox)
+
+			var metrics *sig.Metrics
+			if metricsAddr != "" {
+				metrics = sig.NewMetrics()
+				engine.SetMetrics(metrics)
+			}
+
+			// Start event logger and delivery
+			go sig.EventLogger(ctx, events)
+			go sig.DeliverOutbox(ctx, outbox, cfg.APIOrigin, token, metrics)
 
 			// Signal handler
 			sigCh := make(chan os.Signal, 1)
@@ -187,6 +250,10 @@ func cmdSignalDaemon(cfg *config.Config, st store.Store) *cobra.Command {
 				Token:       token,
 				RulesDir:    rulesDir,
 				MaxOrderQty: cfg.BrokerMaxOrderQty,
+				Record:      !noRecord,
+				Explain:     explain,
+				Fresh:       fresh,
+				MetricsAddr: metricsAddr,
 			}
 
 			return sig.RunDaemon(ctx, engine, dcfg)
@@ -195,6 +262,16 @@ func cmdSignalDaemon(cfg *config.Config, st store.Store) *cobra.Command {
 
 	cmd.Flags().BoolVar(&foreground, "foreground", false, "Run in foreground (for debugging)")
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Evaluate rules but never place orders")
+	cmd.Flags().BoolVar(&noRecord, "no-record", false, "Do not journal raw feed messages to disk")
+	cmd.Flags().BoolVar(&explain, "explain", false, "Log every rule's evaluation tree per snapshot (debug)")
+	cmd.Flags().DurationVar(&maxAge, "max-snapshot-age", 0, "Block triggers on snapshots older than this (e.g. 2m; 0 = off)")
+	cmd.Flags().BoolVar(&fresh, "fresh", false, "Discard saved cooldowns, caps and tracked positions and start clean")
+	cmd.Flags().IntVar(&breaker.MaxConsecutiveFailures, "breaker-failures", breaker.MaxConsecutiveFailures, "Halt after this many consecutive order failures (0 = off)")
+	cmd.Flags().IntVar(&breaker.MaxSafetyBlocks, "breaker-blocks", breaker.MaxSafetyBlocks, "Halt after this many safety-blocked orders within --breaker-window (0 = off)")
+	cmd.Flags().DurationVar(&breaker.SafetyBlockWindow, "breaker-window", breaker.SafetyBlockWindow, "Window for --breaker-blocks")
+	cmd.Flags().Float64Var(&breaker.MaxDrawdownPct, "breaker-drawdown-pct", 0, "Halt when equity falls this percent below the day's high (0 = off)")
+	cmd.Flags().BoolVar(&breaker.CancelOpenOrders, "breaker-cancel-orders", false, "Cancel all open orders when the circuit breaker trips")
+	cmd.Flags().StringVar(&metricsAddr, "metrics-addr", "", "Serve Prometheus metrics on this localhost address (e.g. 127.0.0.1:9464)")
 	return cmd
 }
 
@@ -216,20 +293,63 @@ func cmdSignalStop(cfg *config.Config) *cobra.Command {
 	}
 }
 
+// ---- signal reload ----
+
+func cmdSignalReload(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:         "reload",
+		Short:       "Reload rules and the position filter in the running daemon",
+		Long:        "Reload rules and the position filter in the running daemon.\n\nThe daemon also picks up file changes on its own within a few seconds;\nthis forces an immediate reload (SIGHUP).",
+		Annotations: map[string]string{"tier": "free"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := sig.ReloadDaemon(cfg.Profile); err != nil {
+				return err
+			}
+			fmt.Printf("%s Reload requested (see: haiphen signal log)\n", tui.C(tui.Green, "✓"))
+			return nil
+		},
+	}
+}
+
 // ---- signal status ----
 
 func cmdSignalStatus(cfg *config.Config) *cobra.Command {
-	return &cobra.Command{
+	var asJSON bool
+
+	cmd := &cobra.Command{
 		Use:   "status",
 		Short: "Show daemon status",
 		Annotations: map[string]string{"tier": "free"},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			pid, running := sig.IsRunning(cfg.Profile)
 			if !running {
+				if asJSON {
+					fmt.Println(`{"running": false}`)
+					return nil
+				}
 				fmt.Println("Signal daemon is not running")
 				return nil
 			}
 
+			// Live state from the daemon; older daemons have no socket.
+			var status *sig.ControlStatus
+			if client, err := sig.DialControl(cfg.Profile); err == nil {
+				status, err = client.Status(cmd.Context())
+				if err != nil && !asJSON {
+					fmt.Fprintf(os.Stderr, "%s control socket: %v\n", tui.C(tui.Yellow, "!"), err)
+				}
+			}
+
+			if asJSON {
+				out, _ := json.MarshalIndent(map[string]interface{}{
+					"running": true,
+					"pid":     pid,
+					"status":  status,
+				}, "", "  ")
+				fmt.Println(string(out))
+				return nil
+			}
+
 			fmt.Printf("%s Signal daemon running\n", tui.C(tui.Green, "✓"))
 			tui.TableRow(os.Stdout, "PID", fmt.Sprintf("%d", pid))
 			tui.TableRow(os.Stdout, "Profile", cfg.Profile)
@@ -238,24 +358,238 @@ func cmdSignalStatus(cfg *config.Config) *cobra.Command {
 			logPath, _ := sig.LogPath(cfg.Profile)
 			tui.TableRow(os.Stdout, "Log", logPath)
 
-			// Count rules
-			rulesDir, err := sig.SignalsDir(cfg.Profile)
-			if err == nil {
-				rules, _ := sig.LoadRulesFromDir(rulesDir)
-				active := 0
-				for _, r := range rules {
-					if r.Status == "active" {
-						active++
+			if status == nil {
+				// Count rules
+				rulesDir, err := sig.SignalsDir(cfg.Profile)
+				if err == nil {
+					rules, _ := sig.LoadRulesFromDir(rulesDir)
+					active := 0
+					for _, r := range rules {
+						if r.Status == "active" {
+							active++
+						}
 					}
+					tui.TableRow(os.Stdout, "Rules", fmt.Sprintf("%d active / %d total", active, len(rules)))
 				}
-				tui.TableRow(os.Stdout, "Rules", fmt.Sprintf("%d active / %d total", active, len(rules)))
+				return nil
+			}
+
+			printControlStatus(status)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Output as JSON")
+	return cmd
+}
+
+// printControlStatus prints the live engine state reported by the daemon.
+func printControlStatus(status *sig.ControlStatus) {
+	eng := status.Engine
+	tui.TableRow(os.Stdout, "Started", status.StartedAt.Local().Format(time.RFC3339))
+	mode := tui.C(tui.Green, "live")
+	if eng.DryRun {
+		mode = tui.C(tui.Yellow, "dry-run")
+	}
+	if eng.Paused {
+		mode += " " + tui.C(tui.Yellow, "(paused)")
+	}
+	tui.TableRow(os.Stdout, "Mode", mode)
+	if eng.Breaker.Open {
+		tui.TableRow(os.Stdout, "Circuit", tui.C(tui.Red, "OPEN")+" since "+
+			eng.Breaker.OpenedAt.Local().Format("15:04:05")+": "+eng.Breaker.Reason)
+		fmt.Printf("  %s\n", tui.C(tui.Gray, "No orders until resumed: haiphen signal resume"))
+	}
+	tui.TableRow(os.Stdout, "Session orders", fmt.Sprintf("%d / %d", eng.SessionOrders, eng.MaxOrdersPerSession))
+	copies := fmt.Sprintf("%d tracked", len(eng.TrackedPositions))
+	if eng.CopyPremium > 0 {
+		copies += ", " + tui.FormatMoneyPlain(eng.CopyPremium) + " premium"
+	}
+	tui.TableRow(os.Stdout, "Copy positions", copies)
+
+	if len(eng.Rules) == 0 {
+		tui.TableRow(os.Stdout, "Rules", "none loaded")
+		return
+	}
+
+	fmt.Println()
+	fmt.Printf("%-20s %-8s %-8s %-8s %-9s %s\n", "RULE", "STATUS", "SYMBOL", "STATE", "TRIG/HR", "COOLDOWN")
+	fmt.Println(strings.Repeat("-", 80))
+	for _, r := range eng.Rules {
+		name := r.Name
+		if len(name) > 20 {
+			name = name[:18] + ".."
+		}
+		ruleStatus := r.Status
+		if r.Paused {
+			ruleStatus = "paused*"
+		}
+		for _, t := range r.Targets {
+			sym, state, cooldown := t.Symbol, string(t.State), "-"
+			if sym == "" {
+				sym = "-"
+			}
+			if state == "" {
+				state = "-"
+			}
+			if t.CooldownUntil != nil {
+				cooldown = "until " + t.CooldownUntil.Local().Format("15:04:05")
+			}
+			fmt.Printf("%-20s %-8s %-8s %-8s %-9d %s\n", name, ruleStatus, sym, state, t.TriggersLastHour, cooldown)
+		}
+	}
+	fmt.Println(tui.C(tui.Gray, "\n* paused in the running daemon (haiphen signal control resume <rule>)"))
+}
+
+// ---- signal resume ----
+
+func cmdSignalResume(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "resume",
+		Short: "Resume a daemon halted by its circuit breaker (or paused engine-wide)",
+		Long: "Resume a daemon halted by its circuit breaker (or paused engine-wide).\n\n" +
+			"A tripped breaker stops all orders until resumed here; check why with\n" +
+			"haiphen signal status before resuming.",
+		Annotations: map[string]string{"tier": "pro", "audit": "1"},
+		Args:        cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := sig.DialControl(cfg.Profile)
+			if err != nil {
+				return err
+			}
+			before, err := client.Status(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if _, err := client.Resume(cmd.Context(), ""); err != nil {
+				return err
 			}
 
+			switch {
+			case before.Engine.Breaker.Open:
+				fmt.Printf("%s Circuit breaker closed (was: %s)\n", tui.C(tui.Green, "✓"), before.Engine.Breaker.Reason)
+			case before.Engine.Paused:
+				fmt.Printf("%s Engine resumed\n", tui.C(tui.Green, "✓"))
+			default:
+				fmt.Println("Engine was not halted or paused")
+			}
 			return nil
 		},
 	}
 }
 
+// ---- signal control ----
+
+func cmdSignalControl(cfg *config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "control",
+		Aliases: []string{"ctl"},
+		Short:   "Control the running daemon: pause/resume rules, toggle dry-run",
+		Long: "Control the running daemon over its local socket. Changes apply\n" +
+			"immediately and last until changed again (they survive restarts\n" +
+			"unless the daemon is started with --fresh); rule files are not\n" +
+			"modified. To change a rule's saved status use signal pause/enable.",
+	}
+
+	runPause := func(pause bool) func(cmd *cobra.Command, args []string) error {
+		return func(cmd *cobra.Command, args []string) error {
+			client, err := sig.DialControl(cfg.Profile)
+			if err != nil {
+				return err
+			}
+			rule := ""
+			if len(args) == 1 {
+				rule = args[0]
+			}
+			if pause {
+				_, err = client.Pause(cmd.Context(), rule)
+			} else {
+				_, err = client.Resume(cmd.Context(), rule)
+			}
+			if err != nil {
+				return err
+			}
+
+			target := "Engine"
+			if rule != "" {
+				target = fmt.Sprintf("Rule %q", rule)
+			}
+			if pause {
+				fmt.Printf("%s %s paused: no new entries (exits still run)\n", tui.C(tui.Green, "✓"), target)
+			} else {
+				fmt.Printf("%s %s resumed\n", tui.C(tui.Green, "✓"), target)
+			}
+			return nil
+		}
+	}
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:         "pause [rule]",
+			Short:       "Stop new entries for one rule, or the whole engine",
+			Annotations: map[string]string{"tier": "pro", "audit": "1"},
+			Args:        cobra.MaximumNArgs(1),
+			RunE:        runPause(true),
+		},
+		&cobra.Command{
+			Use:         "resume [rule]",
+			Short:       "Resume a paused rule, or the whole engine",
+			Annotations: map[string]string{"tier": "pro", "audit": "1"},
+			Args:        cobra.MaximumNArgs(1),
+			RunE:        runPause(false),
+		},
+		&cobra.Command{
+			Use:         "dry-run <on|off>",
+			Short:       "Toggle dry-run mode in the running daemon",
+			Annotations: map[string]string{"tier": "pro", "audit": "1"},
+			Args:        cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				var on bool
+				switch strings.ToLower(args[0]) {
+				case "on", "true":
+					on = true
+				case "off", "false":
+				default:
+					return fmt.Errorf("expected on or off, got %q", args[0])
+				}
+				client, err := sig.DialControl(cfg.Profile)
+				if err != nil {
+					return err
+				}
+				if _, err := client.SetDryRun(cmd.Context(), on); err != nil {
+					return err
+				}
+				if on {
+					fmt.Printf("%s Dry-run on: rules evaluated but no orders placed\n", tui.C(tui.Green, "✓"))
+				} else {
+					fmt.Printf("%s Dry-run off: %s\n", tui.C(tui.Green, "✓"), tui.C(tui.Yellow, "orders will be placed"))
+				}
+				return nil
+			},
+		},
+		&cobra.Command{
+			Use:         "rules",
+			Short:       "Show the rules loaded in the running daemon (JSON)",
+			Annotations: map[string]string{"tier": "free"},
+			Args:        cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				client, err := sig.DialControl(cfg.Profile)
+				if err != nil {
+					return err
+				}
+				rules, err := client.Rules(cmd.Context())
+				if err != nil {
+					return err
+				}
+				out, _ := json.MarshalIndent(rules, "", "  ")
+				fmt.Println(string(out))
+				return nil
+			},
+		},
+	)
+	return cmd
+}
+
 // ---- signal add ----
 
 func cmdSignalAdd(cfg *config.Config, _ store.Store) *cobra.Command {
@@ -288,11 +622,11 @@ func cmdSignalAdd(cfg *config.Config, _ store.Store) *cobra.Command {
 				return err
 			}
 
-			fmt.Printf("%s Rule %q imported (id=%s)\n", tui.C(tui.Green, "✓"), r.Name, r.RuleID[:8])
+			fmt.Printf("%s Rule %q imported (id=%s, v%d)\n", tui.C(tui.Green, "✓"), r.Name, r.RuleID[:8], r.Version)
 			if len(r.Symbols) > 0 {
 				fmt.Printf("  Symbols: %s\n", strings.Join(r.Symbols, ", "))
 			}
-			fmt.Printf("  Order:   %s %s %.0f (%s)\n", r.Order.Side, r.Order.Type, r.Order.Qty, r.Order.TIF)
+			fmt.Printf("  Order:   %s %s %s (%s)\n", r.Order.Side, r.Order.Type, r.Order.SizeLabel(), r.Order.TIF)
 			fmt.Printf("  Cooldown: %ds\n", r.Cooldown)
 			return nil
 		},
@@ -332,9 +666,9 @@ func cmdSignalList(cfg *config.Config) *cobra.Command {
 				return nil
 			}
 
-			fmt.Printf("%-20s %-8s %-12s %-6s %-8s %-6s %s\n",
-				"NAME", "STATUS", "SYMBOLS", "SIDE", "TYPE", "QTY", "COOLDOWN")
-			fmt.Println(strings.Repeat("-", 75))
+			fmt.Printf("%-20s %-8s %-12s %-6s %-8s %-6s %-9s %s\n",
+				"NAME", "STATUS", "SYMBOLS", "SIDE", "TYPE", "QTY", "COOLDOWN", "WINDOW")
+			fmt.Println(strings.Repeat("-", 100))
 
 			for _, r := range rules {
 				if r.RuleID == "" {
@@ -361,14 +695,15 @@ func cmdSignalList(cfg *config.Config) *cobra.Command {
 					name = name[:18] + ".."
 				}
 
-				fmt.Printf("%-20s %-8s %-12s %-6s %-8s %-6.0f %ds\n",
+				fmt.Printf("%-20s %-8s %-12s %-6s %-8s %-6s %-9s %s\n",
 					name,
 					tui.C(statusColor, r.Status),
 					syms,
 					r.Order.Side,
 					r.Order.Type,
-					r.Order.Qty,
-					r.Cooldown)
+					r.Order.SizeLabel(),
+					fmt.Sprintf("%ds", r.Cooldown),
+					r.Temporal.Summary())
 			}
 
 			fmt.Printf("\n%d rules\n", len(rules))
@@ -465,44 +800,195 @@ func setRuleStatus(cfg *config.Config, name, status string) error {
 	return nil
 }
 
-// ---- signal test ----
+// ---- signal history ----
 
-func cmdSignalTest(cfg *config.Config, st store.Store) *cobra.Command {
-	return &cobra.Command{
-		Use:   "test <name>",
-		Short: "Dry-run a single rule against the latest snapshot",
-		Long:  "Dry-run a single rule against the latest snapshot\n\nRequires: Pro plan or higher\nUpgrade: https://haiphen.io/#pricing",
-		Annotations: map[string]string{"tier": "pro"},
+func cmdSignalHistory(cfg *config.Config) *cobra.Command {
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:         "history <name>",
+		Short:       "List saved versions of a rule",
+		Annotations: map[string]string{"tier": "free"},
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			token, err := requireToken(st)
+			rulesDir, err := sig.SignalsDir(cfg.Profile)
+			if err != nil {
+				return err
+			}
+			live, err := findRule(cfg, args[0])
+			if err != nil {
+				return err
+			}
+			revs, err := sig.RuleHistory(rulesDir, live.Name)
 			if err != nil {
 				return err
 			}
 
+			if asJSON {
+				out, _ := json.MarshalIndent(revs, "", "  ")
+				fmt.Println(string(out))
+				return nil
+			}
+
+			if len(revs) == 0 {
+				fmt.Printf("No saved versions of %q (live: v%d)\n", live.Name, live.Version)
+				return nil
+			}
+
+			fmt.Printf("%-8s %-20s %-14s %-8s %s\n", "VERSION", "SAVED", "AUTHOR", "STATUS", "")
+			fmt.Println(strings.Repeat("-", 60))
+			for _, rev := range revs {
+				saved := "-"
+				if !rev.SavedAt.IsZero() {
+					saved = rev.SavedAt.Local().Format("2006-01-02 15:04:05")
+				}
+				author := rev.Author
+				if author == "" {
+					author = "-"
+				}
+				marker := ""
+				if rev.Version == live.Version {
+					marker = tui.C(tui.Green, "← live")
+				}
+				fmt.Printf("%-8s %-20s %-14s %-8s %s\n",
+					fmt.Sprintf("v%d", rev.Version), saved, author, rev.Rule.Status, marker)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Output as JSON")
+	return cmd
+}
+
+// ---- signal diff ----
+
+func cmdSignalDiff(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:         "diff <name> <from> [to]",
+		Short:       "Show changes between two versions of a rule (to defaults to live)",
+		Annotations: map[string]string{"tier": "free"},
+		Args:        cobra.RangeArgs(2, 3),
+		RunE: func(cmd *cobra.Command, args []string) error {
 			rulesDir, err := sig.SignalsDir(cfg.Profile)
 			if err != nil {
 				return err
 			}
+			live, err := findRule(cfg, args[0])
+			if err != nil {
+				return err
+			}
 
-			rules, err := sig.LoadRulesFromDir(rulesDir)
+			from, err := sig.ParseVersion(args[1])
 			if err != nil {
 				return err
 			}
+			to := live.Version
+			if len(args) == 3 {
+				if to, err = sig.ParseVersion(args[2]); err != nil {
+					return err
+				}
+			}
 
-			var target *sig.Rule
-			for _, r := range rules {
-				if strings.EqualFold(r.Name, args[0]) {
-					target = r
-					break
+			a, err := sig.LoadRuleRevision(rulesDir, live.Name, from)
+			if err != nil {
+				return err
+			}
+			b, err := sig.LoadRuleRevision(rulesDir, live.Name, to)
+			if err != nil {
+				return err
+			}
+			lines, err := sig.DiffRuleRevisions(a, b)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(tui.C(tui.Red, fmt.Sprintf("--- %s v%d", live.Name, from)))
+			fmt.Println(tui.C(tui.Green, fmt.Sprintf("+++ %s v%d", live.Name, to)))
+			for _, l := range lines {
+				switch l.Op {
+				case '-':
+					fmt.Println(tui.C(tui.Red, "-"+l.Text))
+				case '+':
+					fmt.Println(tui.C(tui.Green, "+"+l.Text))
+				default:
+					fmt.Println(" " + l.Text)
 				}
 			}
-			if target == nil {
-				return fmt.Errorf("rule %q not found", args[0])
+			return nil
+		},
+	}
+}
+
+// ---- signal rollback ----
+
+func cmdSignalRollback(cfg *config.Config) *cobra.Command {
+	var to string
+
+	cmd := &cobra.Command{
+		Use:         "rollback <name> --to <version>",
+		Short:       "Restore an earlier version of a rule",
+		Long:        "Restore an earlier version of a rule. The restored content is saved as a\nnew version, so the rollback itself can be undone.\n\nRequires: Pro plan or higher\nUpgrade: https://haiphen.io/#pricing",
+		Annotations: map[string]string{"tier": "pro", "audit": "1"},
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if to == "" {
+				return fmt.Errorf("--to is required (e.g. --to v3)")
+			}
+			version, err := sig.ParseVersion(to)
+			if err != nil {
+				return err
+			}
+			rulesDir, err := sig.SignalsDir(cfg.Profile)
+			if err != nil {
+				return err
+			}
+			live, err := findRule(cfg, args[0])
+			if err != nil {
+				return err
+			}
+
+			rev, err := sig.LoadRuleRevision(rulesDir, live.Name, version)
+			if err != nil {
+				return err
+			}
+			if err := sig.ValidateRule(rev.Rule, cfg.BrokerMaxOrderQty); err != nil {
+				return fmt.Errorf("v%d no longer validates: %w", version, err)
+			}
+
+			r, err := sig.RollbackRule(rulesDir, live.Name, version, "")
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s Rule %q rolled back to v%d (now v%d)\n", tui.C(tui.Green, "✓"), r.Name, version, r.Version)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&to, "to", "", "Version to restore (e.g. v3)")
+	return cmd
+}
+
+// ---- signal test ----
+
+func cmdSignalTest(cfg *config.Config, st store.Store) *cobra.Command {
+	var explain bool
+
+	cmd := &cobra.Command{
+		Use:   "test <name>",
+		Short: "Dry-run a single rule against the latest snapshot",
+		Long:  "Dry-run a single rule against the latest snapshot\n\nRequires: Pro plan or higher\nUpgrade: https://haiphen.io/#pricing",
+		Annotations: map[string]string{"tier": "pro"},
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			token, err := requireToken(st)
+			if err != nil {
+				return err
 			}
 
-			if target.RuleID == "" {
-				target.RuleID = sig.DeterministicID("", target.Name)
+			target, err := findRule(cfg, args[0])
+			if err != nil {
+				return err
 			}
 
 			// Fetch latest snapshot from API
@@ -535,6 +1021,7 @@ func cmdSignalTest(cfg *config.Config, st store.Store) *cobra.Command {
 			}()
 
 			// Evaluate
+			traces := engine.Explain(snap)
 			engine.Evaluate(cmd.Context(), snap)
 			close(events)
 
@@ -542,7 +1029,7 @@ func cmdSignalTest(cfg *config.Config, st store.Store) *cobra.Command {
 			fmt.Printf("Snapshot: %s (%d KPIs)\n", snap.Date, len(snap.KPIs))
 
 			// Show relevant KPIs
-			relevantKPIs := collectKPIs(target)
+			relevantKPIs := target.ReferencedKPIs()
 			if len(relevantKPIs) > 0 {
 				fmt.Println("\nRelevant KPIs:")
 				for _, kpi := range relevantKPIs {
@@ -554,17 +1041,314 @@ func cmdSignalTest(cfg *config.Config, st store.Store) *cobra.Command {
 				}
 			}
 
+			if explain {
+				for _, tr := range traces {
+					label := ""
+					if tr.Symbol != "" {
+						label = " [" + tr.Symbol + "]"
+					}
+					fmt.Printf("\nEntry%s:\n", label)
+					printTrace(tr.Entry, "  ")
+					if tr.Exit != nil {
+						fmt.Printf("\nExit%s:\n", label)
+						printTrace(tr.Exit, "  ")
+					}
+				}
+			}
+
 			fmt.Println()
 			fmt.Println(tui.C(tui.Gray, "Note: crosses_above/crosses_below need two snapshots (prev + current)"))
 			return nil
 		},
 	}
+
+	cmd.Flags().BoolVar(&explain, "explain", false, "Show the evaluation tree: each condition's values and pass/fail")
+	return cmd
 }
 
-// ---- signal log ----
+// printTrace prints an evaluation tree, one condition per line.
+func printTrace(n *sig.TraceNode, indent string) {
+	mark := tui.C(tui.Red, "✗")
+	switch {
+	case n.Skipped:
+		mark = tui.C(tui.Gray, "·")
+	case n.Passed:
+		mark = tui.C(tui.Green, "✓")
+	}
 
-func cmdSignalLog(cfg *config.Config) *cobra.Command {
-	var lines int
+	if n.Kind != "leaf" {
+		fmt.Printf("%s%s %s\n", indent, mark, n.Kind)
+		for _, c := range n.Children {
+			printTrace(c, indent+"  ")
+		}
+		return
+	}
+
+	lhs := n.KPI
+	if n.Expr != "" {
+		lhs = n.Expr
+	}
+	rhs := "?"
+	if n.ValueExpr != "" {
+		rhs = n.ValueExpr
+	} else if n.Threshold != nil {
+		rhs = fmt.Sprintf("%g", *n.Threshold)
+	}
+	line := fmt.Sprintf("%s%s %s %s %s", indent, mark, lhs, n.Operator, rhs)
+
+	var details []string
+	if n.Value != nil {
+		details = append(details, fmt.Sprintf("value %.4f", *n.Value))
+	}
+	if n.ValueExpr != "" && n.Threshold != nil {
+		details = append(details, fmt.Sprintf("threshold %.4f", *n.Threshold))
+	}
+	if n.PrevValue != nil {
+		details = append(details, fmt.Sprintf("prev %.4f vs %.4f", *n.PrevValue, *n.PrevThresh))
+	}
+	if n.Skipped {
+		details = append(details, "not evaluated")
+	}
+	if n.Reason != "" {
+		details = append(details, n.Reason)
+	}
+	if len(details) > 0 {
+		line += tui.C(tui.Gray, "  ("+strings.Join(details, ", ")+")")
+	}
+	fmt.Println(line)
+}
+
+// ---- signal backtest ----
+
+func cmdSignalBacktest(cfg *config.Config, st store.Store) *cobra.Command {
+	var (
+		from     string
+		to       string
+		file     string
+		priceKPI string
+		cash     float64
+		asJSON   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "backtest <name>",
+		Short: "Replay historical snapshots through a rule against a simulated broker",
+		Long:  "Replay historical snapshots through a rule against a simulated broker\n\nRequires: Pro plan or higher\nUpgrade: https://haiphen.io/#pricing",
+		Annotations: map[string]string{"tier": "pro"},
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if priceKPI == "" {
+				return fmt.Errorf("--price-kpi is required (KPI used as the simulated fill price)")
+			}
+			for _, d := range []string{from, to} {
+				if d == "" {
+					continue
+				}
+				if _, err := time.Parse("2006-01-02", d); err != nil {
+					return fmt.Errorf("invalid date %q: use YYYY-MM-DD", d)
+				}
+			}
+
+			target, err := findRule(cfg, args[0])
+			if err != nil {
+				return err
+			}
+			if err := sig.ValidateRule(target, cfg.BrokerMaxOrderQty); err != nil {
+				return fmt.Errorf("validation failed: %w", err)
+			}
+
+			var snaps []*sig.Snapshot
+			if file != "" {
+				snaps, err = sig.LoadSnapshotsFile(file)
+				if err != nil {
+					return fmt.Errorf("load %s: %w", file, err)
+				}
+				snaps = sig.FilterSnapshots(snaps, from, to)
+			} else {
+				token, err := requireToken(st)
+				if err != nil {
+					return err
+				}
+				kpis := target.ReferencedKPIs()
+				kpis = append(kpis, priceKPI)
+				sp := tui.NewSpinner("Fetching snapshot history...")
+				snaps, err = sig.FetchSnapshotHistory(cmd.Context(), cfg.APIOrigin, token, kpis, from, to)
+				if err != nil {
+					sp.Fail("Failed to fetch history")
+					return err
+				}
+				sp.Stop()
+			}
+			if len(snaps) == 0 {
+				return fmt.Errorf("no snapshots found in range")
+			}
+
+			ecfg := sig.DefaultEngineConfig()
+			ecfg.DaemonID = "backtest"
+			ecfg.Safety = safetyConfig(cfg)
+			// A backtest spans many sessions; only the hourly cap applies.
+			ecfg.MaxOrdersPerSession = len(snaps)
+
+			res, err := sig.RunBacktest(cmd.Context(), target, snaps, sig.BacktestConfig{
+				PriceKPI:     priceKPI,
+				StartingCash: cash,
+				Engine:       ecfg,
+			})
+			if err != nil {
+				return err
+			}
+
+			if asJSON {
+				out, _ := json.MarshalIndent(res, "", "  ")
+				fmt.Println(string(out))
+				return nil
+			}
+
+			fmt.Printf("\nRule: %s\n", res.Rule)
+			fmt.Printf("Range: %s → %s (%d snapshots)\n\n", res.From, res.To, res.Snapshots)
+
+			tui.TableRow(os.Stdout, "Entry triggers", fmt.Sprintf("%d", res.Triggers["entry_triggered"]))
+			tui.TableRow(os.Stdout, "Exit triggers", fmt.Sprintf("%d", res.Triggers["exit_triggered"]))
+			tui.TableRow(os.Stdout, "Cooldown blocks", fmt.Sprintf("%d", res.Triggers["cooldown_blocked"]))
+			tui.TableRow(os.Stdout, "Orders failed", fmt.Sprintf("%d", res.Triggers["order_failed"]))
+			tui.TableRow(os.Stdout, "Fills", fmt.Sprintf("%d", len(res.Fills)))
+			tui.TableRow(os.Stdout, "Realized P&L", tui.FormatMoney(res.RealizedPL))
+			tui.TableRow(os.Stdout, "Unrealized P&L", tui.FormatMoney(res.UnrealizedPL))
+			tui.TableRow(os.Stdout, "Total P&L", tui.FormatMoney(res.TotalPL))
+			tui.TableRow(os.Stdout, "Max drawdown", fmt.Sprintf("%s (%.2f%%)", tui.FormatMoneyPlain(res.MaxDrawdown), res.MaxDrawdownPct))
+			tui.TableRow(os.Stdout, "Hit rate", fmt.Sprintf("%.1f%% (%d/%d closed)", res.HitRate*100, res.Wins, res.ClosedTrades))
+
+			if len(res.Fills) > 0 {
+				fmt.Printf("\n%-20s %-6s %-8s %-8s %-10s %s\n", "TIME", "SIDE", "SYMBOL", "QTY", "PRICE", "REALIZED")
+				fmt.Println(strings.Repeat("-", 68))
+				for _, f := range res.Fills {
+					fmt.Printf("%-20s %-6s %-8s %-8.0f %-10.2f %.2f\n",
+						f.Time.UTC().Format("2006-01-02 15:04:05"), f.Side, f.Symbol, f.Qty, f.Price, f.RealizedPL)
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", "", "First date to replay (YYYY-MM-DD; the API serves the latest 100 trading dates)")
+	cmd.Flags().StringVar(&to, "to", "", "Last date to replay (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&file, "file", "", "Replay snapshots from a local JSONL file or feed recording instead of the API")
+	cmd.Flags().StringVar(&priceKPI, "price-kpi", "", "KPI used as the simulated fill price (required)")
+	cmd.Flags().Float64Var(&cash, "cash", 100000, "Starting cash for the simulated account")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Output as JSON")
+	return cmd
+}
+
+// ---- signal replay ----
+
+func cmdSignalReplay(cfg *config.Config) *cobra.Command {
+	var (
+		rulesDir string
+		dryRun   bool
+		asJSON   bool
+		equity   float64
+	)
+
+	cmd := &cobra.Command{
+		Use:   "replay <recording>",
+		Short: "Deterministically replay a recorded daemon session offline",
+		Long:  "Deterministically replay a recorded daemon session offline\n\nRequires: Pro plan or higher\nUpgrade: https://haiphen.io/#pricing",
+		Annotations: map[string]string{"tier": "pro"},
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := sig.ResolveRecording(cfg.Profile, args[0])
+			if err != nil {
+				return err
+			}
+
+			if rulesDir == "" {
+				rulesDir, err = sig.SignalsDir(cfg.Profile)
+				if err != nil {
+					return err
+				}
+			}
+			rules, skipped, err := sig.LoadActiveRules(rulesDir, cfg.BrokerMaxOrderQty)
+			if err != nil {
+				return err
+			}
+			for name, err := range skipped {
+				fmt.Printf("%s skipping invalid rule %q: %v\n", tui.C(tui.Yellow, "!"), name, err)
+			}
+
+			posFilter, err := sig.LoadPositionFilter(cfg.Profile)
+			if err != nil {
+				return err
+			}
+
+			ecfg := sig.DefaultEngineConfig()
+			ecfg.DryRun = dryRun
+			ecfg.DaemonID = "replay"
+			ecfg.Safety = safetyConfig(cfg)
+			ecfg.Safety.ConfirmOrders = false
+
+			res, err := sig.Replay(cmd.Context(), path, sig.ReplayConfig{
+				Rules:          rules,
+				PositionFilter: posFilter,
+				Engine:         ecfg,
+				Equity:         equity,
+			})
+			if err != nil {
+				return err
+			}
+
+			if asJSON {
+				out, _ := json.MarshalIndent(res, "", "  ")
+				fmt.Println(string(out))
+				return nil
+			}
+
+			names := make(map[string]string, len(rules))
+			for _, r := range rules {
+				names[r.RuleID] = r.Name
+			}
+
+			fmt.Printf("\nRecording: %s\n", path)
+			fmt.Printf("Window: %s → %s\n", res.Start.UTC().Format(time.RFC3339), res.End.UTC().Format(time.RFC3339))
+			fmt.Printf("Messages: %d (%d snapshots, %d position batches, %d unparseable)\n",
+				res.Messages, res.Snapshots, res.PositionBatches, res.ParseFailures)
+			fmt.Printf("Rules: %d active\n", len(rules))
+			fmt.Printf("Equity: %s (sizes percent and risk rules)\n\n", tui.FormatMoneyPlain(res.Equity))
+
+			if len(res.Events) == 0 {
+				fmt.Println("No events produced")
+			} else {
+				fmt.Printf("%-20s %-18s %-20s %-8s %-5s %s\n", "TIME", "EVENT", "RULE", "SYMBOL", "SIDE", "QTY")
+				fmt.Println(strings.Repeat("-", 82))
+				for _, ev := range res.Events {
+					rule := names[ev.RuleID]
+					if rule == "" {
+						rule = ev.RuleID
+					}
+					if len(rule) > 20 {
+						rule = rule[:18] + ".."
+					}
+					fmt.Printf("%-20s %-18s %-20s %-8s %-5s %.0f\n",
+						strings.Replace(strings.TrimSuffix(ev.CreatedAt, "Z"), "T", " ", 1),
+						ev.EventType, rule, ev.Symbol, ev.OrderSide, ev.OrderQty)
+				}
+			}
+
+			fmt.Printf("\nDigest: %s\n", tui.C(tui.Gray, res.Digest))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&rulesDir, "rules-dir", "", "Replay against rules in this directory (default: profile rules)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Replay as a --dry-run daemon (no order decisions)")
+	cmd.Flags().Float64Var(&equity, "equity", 100000, "Account equity the replay broker reports to sized rules")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Output as JSON")
+	return cmd
+}
+
+// ---- signal log ----
+
+func cmdSignalLog(cfg *config.Config) *cobra.Command {
+	var lines int
 
 	cmd := &cobra.Command{
 		Use:   "log",
@@ -602,6 +1386,176 @@ func cmdSignalLog(cfg *config.Config) *cobra.Command {
 	return cmd
 }
 
+// ---- signal outbox ----
+
+func cmdSignalOutbox(cfg *config.Config, st store.Store) *cobra.Command {
+	var (
+		flush  bool
+		asJSON bool
+	)
+
+	cmd := &cobra.Command{
+		Use:         "outbox",
+		Short:       "Show (and optionally flush) signal events not yet delivered to the API",
+		Annotations: map[string]string{"tier": "free"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := sig.OutboxPath(cfg.Profile)
+			if err != nil {
+				return err
+			}
+
+			if flush {
+				if pid, running := sig.IsRunning(cfg.Profile); running {
+					return fmt.Errorf("daemon (PID %d) is delivering the outbox; stop it first to flush manually", pid)
+				}
+				token, err := requireToken(st)
+				if err != nil {
+					return err
+				}
+				outbox, err := sig.OpenOutbox(path)
+				if err != nil {
+					return err
+				}
+				defer outbox.Close()
+
+				sp := tui.NewSpinner(fmt.Sprintf("Delivering %d events...", len(outbox.Pending())))
+				n, err := outbox.Flush(cmd.Context(), func(ctx context.Context, ev sig.Event) error {
+					return sig.SendEvent(ctx, cfg.APIOrigin, token, ev)
+				})
+				if err != nil {
+					sp.Fail(fmt.Sprintf("Delivered %d events, %d still pending", n, len(outbox.Pending())))
+					return err
+				}
+				sp.Success(fmt.Sprintf("Delivered %d events", n))
+				return nil
+			}
+
+			pending, err := sig.ReadOutbox(path)
+			if err != nil {
+				return err
+			}
+
+			if asJSON {
+				out, _ := json.MarshalIndent(pending, "", "  ")
+				fmt.Println(string(out))
+				return nil
+			}
+
+			if len(pending) == 0 {
+				fmt.Println("Outbox is empty: all events delivered")
+				return nil
+			}
+
+			fmt.Printf("%-28s %-22s %-18s %-8s %s\n", "EVENT", "TYPE", "RULE", "SYMBOL", "CREATED")
+			fmt.Println(strings.Repeat("-", 100))
+			for _, ev := range pending {
+				ruleID := ev.RuleID
+				if len(ruleID) > 16 {
+					ruleID = ruleID[:16]
+				}
+				fmt.Printf("%-28s %-22s %-18s %-8s %s\n", ev.EventID, ev.EventType, ruleID, ev.Symbol, ev.CreatedAt)
+			}
+			fmt.Printf("\n%d pending events\n", len(pending))
+			fmt.Println(tui.C(tui.Gray, "Deliver now (daemon stopped): haiphen signal outbox --flush"))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&flush, "flush", false, "Deliver pending events to the API now")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Output as JSON")
+	return cmd
+}
+
+// ---- signal recordings ----
+
+func cmdSignalRecordings(cfg *config.Config) *cobra.Command {
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "recordings",
+		Short: "List recorded signal feed journals",
+		Annotations: map[string]string{"tier": "free"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			recs, err := sig.ListRecordings(cfg.Profile)
+			if err != nil {
+				return err
+			}
+
+			if asJSON {
+				out, _ := json.MarshalIndent(recs, "", "  ")
+				fmt.Println(string(out))
+				return nil
+			}
+
+			if len(recs) == 0 {
+				fmt.Println("No feed recordings found")
+				fmt.Println("  Recordings are written while the daemon runs: haiphen signal daemon")
+				return nil
+			}
+
+			fmt.Printf("%-32s %-10s %s\n", "NAME", "SIZE", "LAST WRITE")
+			fmt.Println(strings.Repeat("-", 65))
+			var total int64
+			for _, r := range recs {
+				total += r.Size
+				fmt.Printf("%-32s %-10s %s\n", r.Name, formatBytes(r.Size), r.ModTime.Local().Format("2006-01-02 15:04:05"))
+			}
+
+			dir, _ := sig.RecordingsDir(cfg.Profile)
+			fmt.Printf("\n%d recordings (%s) in %s\n", len(recs), formatBytes(total), tui.C(tui.Gray, dir))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Output as JSON")
+	cmd.AddCommand(cmdSignalRecordingsExport(cfg))
+	return cmd
+}
+
+func cmdSignalRecordingsExport(cfg *config.Config) *cobra.Command {
+	var (
+		out string
+		raw bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export <name>",
+		Short: "Decompress a feed recording to JSONL",
+		Annotations: map[string]string{"tier": "free"},
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := sig.ResolveRecording(cfg.Profile, args[0])
+			if err != nil {
+				return err
+			}
+
+			w := os.Stdout
+			if out != "" && out != "-" {
+				f, err := os.OpenFile(out, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				w = f
+			}
+
+			n, err := sig.ExportRecording(path, w, raw)
+			if err != nil {
+				return err
+			}
+
+			if w != os.Stdout {
+				fmt.Printf("%s Exported %d messages to %s\n", tui.C(tui.Green, "✓"), n, out)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&out, "out", "", "Write to file instead of stdout")
+	cmd.Flags().BoolVar(&raw, "raw", false, "Write only the original feed messages (no receive timestamps)")
+	return cmd
+}
+
 // ---- signal sync ----
 
 func cmdSignalSync(cfg *config.Config, st store.Store) *cobra.Command {
@@ -634,11 +1588,18 @@ func cmdSignalSync(cfg *config.Config, st store.Store) *cobra.Command {
 			}
 
 			sp := tui.NewSpinner("Syncing rules...")
-			n, err := sig.PushRules(cmd.Context(), cfg.APIOrigin, token, rules)
+			n, skipped, err := sig.PushRules(cmd.Context(), cfg.APIOrigin, token, rules)
 			if err != nil {
 				sp.Fail("Sync failed")
 				return err
 			}
+			if len(skipped) > 0 {
+				sp.Fail(fmt.Sprintf("Synced %d of %d rules to D1", n, len(rules)))
+				for _, s := range skipped {
+					fmt.Printf("  %s %s: %s\n", tui.C(tui.Red, "✗"), s.Name, s.Reason)
+				}
+				return fmt.Errorf("%d rules were not synced", len(skipped))
+			}
 			sp.Success(fmt.Sprintf("Synced %d rules to D1", n))
 
 			// Pull recent events
@@ -738,9 +1699,187 @@ func cmdSignalPositions(cfg *config.Config, st store.Store) *cobra.Command {
 	}
 
 	cmd.Flags().BoolVar(&asJSON, "json", false, "Output as JSON")
+	cmd.AddCommand(cmdSignalPositionsReconcile(cfg, st))
 	return cmd
 }
 
+func cmdSignalPositionsReconcile(cfg *config.Config, st store.Store) *cobra.Command {
+	var (
+		enterMissed  bool
+		closeOrphans bool
+		skipConfirm  bool
+		asJSON       bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "reconcile",
+		Short: "Compare copy-trade positions upstream with the broker and fix differences",
+		Long: "Compare copy-trade positions upstream with the broker and fix differences.\n\n" +
+			"Upstream active positions the broker holds are tracked again, so their\n" +
+			"closing events are mirrored. Missed positions (active upstream, not held)\n" +
+			"and orphaned broker positions (held, not active upstream) are listed and,\n" +
+			"with --enter-missed / --close-orphans, entered or closed.\n\n" +
+			"Goes through the running daemon when there is one.",
+		Annotations: map[string]string{"tier": "pro", "audit": "1"},
+		Args:        cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			token, err := requireToken(st)
+			if err != nil {
+				return err
+			}
+
+			sp := tui.NewSpinner("Reconciling copy-trade positions...")
+			upstream, complete, err := sig.FetchActivePositions(cmd.Context(), cfg.APIOrigin, token)
+			if err != nil {
+				sp.Fail("Failed to fetch positions")
+				return err
+			}
+			rec, err := reconcileCopyTrades(cmd.Context(), cfg, upstream, sig.CopyReconcileOptions{Partial: !complete})
+			if err != nil {
+				sp.Fail("Reconciliation failed")
+				return err
+			}
+			sp.Stop()
+
+			opts := sig.CopyReconcileOptions{
+				EnterMissed:  enterMissed && len(rec.Missed) > 0,
+				CloseOrphans: closeOrphans && len(rec.Orphaned) > 0,
+				Partial:      !complete,
+			}
+			if opts.EnterMissed || opts.CloseOrphans {
+				if !skipConfirm {
+					printCopyReconciliation(rec)
+					fmt.Println()
+					prompt := "Enter missed and close orphaned positions?"
+					if !opts.CloseOrphans {
+						prompt = "Enter missed positions?"
+					} else if !opts.EnterMissed {
+						prompt = "Close orphaned positions?"
+					}
+					ok, err := tui.Confirm(prompt, false)
+					if err != nil {
+						return err
+					}
+					if !ok {
+						fmt.Println("No orders placed.")
+						return nil
+					}
+				}
+				if rec, err = reconcileCopyTrades(cmd.Context(), cfg, upstream, opts); err != nil {
+					return err
+				}
+			}
+
+			if asJSON {
+				out, _ := json.MarshalIndent(rec, "", "  ")
+				fmt.Println(string(out))
+				return nil
+			}
+			printCopyReconciliation(rec)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&enterMissed, "enter-missed", false, "Copy upstream positions the broker does not hold")
+	cmd.Flags().BoolVar(&closeOrphans, "close-orphans", false, "Close broker positions no upstream position accounts for")
+	cmd.Flags().BoolVarP(&skipConfirm, "yes", "y", false, "Skip confirmation")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Output as JSON")
+	return cmd
+}
+
+// reconcileCopyTrades reconciles through the running daemon, or with a
+// one-off engine on the profile's saved state when no daemon is running.
+func reconcileCopyTrades(ctx context.Context, cfg *config.Config, upstream []sig.PositionEvent, opts sig.CopyReconcileOptions) (*sig.CopyReconciliation, error) {
+	if client, err := sig.DialControl(cfg.Profile); err == nil {
+		return client.ReconcileCopyTrades(ctx, upstream, opts)
+	}
+
+	b, err := connectBroker(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer b.Close()
+
+	ecfg := sig.DefaultEngineConfig()
+	ecfg.DaemonID = fmt.Sprintf("cli-%d", os.Getpid())
+	ecfg.Safety = safetyConfig(cfg)
+	ecfg.Safety.ConfirmOrders = false
+	engine := sig.NewEngine(b, ecfg, nil)
+
+	filter, err := sig.LoadPositionFilter(cfg.Profile)
+	if err != nil {
+		return nil, err
+	}
+	engine.SetPositionFilter(filter)
+	if path, err := broker.BaselinePath(cfg.Profile); err == nil {
+		engine.SetBaseline(broker.OpenBaseline(path))
+	}
+
+	// Events queue in the outbox for the next daemon to deliver, and the
+	// rebuilt mapping is saved for it to pick up.
+	outboxPath, err := sig.OutboxPath(cfg.Profile)
+	if err != nil {
+		return nil, err
+	}
+	outbox, err := sig.OpenOutbox(outboxPath)
+	if err != nil {
+		return nil, fmt.Errorf("open outbox: %w", err)
+	}
+	defer outbox.Close()
+	engine.SetOutbox(outbox)
+
+	statePath, err := sig.StatePath(cfg.Profile)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := engine.RestoreState(statePath); err != nil {
+		return nil, fmt.Errorf("restore engine state: %w", err)
+	}
+	engine.SetStatePath(statePath)
+
+	return engine.ReconcileCopyTrades(ctx, upstream, opts)
+}
+
+func printCopyReconciliation(rec *sig.CopyReconciliation) {
+	tui.InlineDisclaimer(os.Stdout)
+	tui.TableRow(os.Stdout, "Matched", fmt.Sprintf("%d", len(rec.Matched)))
+	tui.TableRow(os.Stdout, "Missed", fmt.Sprintf("%d", len(rec.Missed)))
+	tui.TableRow(os.Stdout, "Orphaned", fmt.Sprintf("%d", len(rec.Orphaned)))
+	if len(rec.Dropped) > 0 {
+		tui.TableRow(os.Stdout, "Untracked", fmt.Sprintf("%d no longer active upstream", len(rec.Dropped)))
+	}
+	if rec.Partial {
+		fmt.Printf("\n%s Upstream returned a full page of active positions; orphans are not\n  checked and tracked positions are kept until the list fits one page\n",
+			tui.C(tui.Yellow, "!"))
+	}
+
+	if len(rec.Missed) > 0 {
+		fmt.Printf("\n%s\n", tui.C(tui.Yellow, "Missed (active upstream, not held):"))
+		for _, ev := range rec.Missed {
+			fmt.Printf("  %-22s %-5s %-10s %s\n", ev.ContractName, ev.EntrySide, ev.Strategy, tui.C(tui.Gray, ev.ID))
+		}
+	}
+	if len(rec.Orphaned) > 0 {
+		fmt.Printf("\n%s\n", tui.C(tui.Yellow, "Orphaned (held, not active upstream):"))
+		for _, p := range rec.Orphaned {
+			fmt.Printf("  %-22s %8.0f  %s\n", p.Symbol, p.Qty, tui.FormatMoneyPlain(p.MarketValue))
+		}
+	}
+
+	for _, id := range rec.Entered {
+		fmt.Printf("%s Entered %s\n", tui.C(tui.Green, "✓"), id)
+	}
+	for _, id := range rec.Closed {
+		fmt.Printf("%s Closing order %s\n", tui.C(tui.Green, "✓"), truncID(id))
+	}
+	for _, msg := range rec.Errors {
+		fmt.Printf("%s %s\n", tui.C(tui.Red, "✗"), msg)
+	}
+	if rec.Clean() && !rec.Partial {
+		fmt.Printf("\n%s Copy-trade positions match the broker\n", tui.C(tui.Green, "✓"))
+	}
+}
+
 // ---- signal filter ----
 
 func cmdSignalFilter(cfg *config.Config) *cobra.Command {
@@ -788,6 +1927,9 @@ func cmdSignalFilterSet(cfg *config.Config) *cobra.Command {
 			if f.ScaleFactor <= 0 {
 				f.ScaleFactor = 1.0
 			}
+			if err := f.Validate(); err != nil {
+				return err
+			}
 
 			if err := sig.SavePositionFilter(cfg.Profile, &f); err != nil {
 				return err
@@ -804,7 +1946,56 @@ func cmdSignalFilterSet(cfg *config.Config) *cobra.Command {
 			if f.ScaleFactor != 1.0 {
 				fmt.Printf("  Scale Factor: %.2f\n", f.ScaleFactor)
 			}
-			fmt.Printf("  Note: restart daemon for changes to take effect\n")
+			switch strings.ToLower(f.Sizing) {
+			case sig.CopySizeProportional:
+				fmt.Printf("  Sizing: %gx upstream quantity\n", f.ScaleFactor)
+			case sig.CopySizeNotional:
+				fmt.Printf("  Sizing: %s premium per trade\n", tui.FormatMoneyPlain(f.Notional))
+			}
+			if f.UnderlyingBudget > 0 {
+				fmt.Printf("  Budget per underlying: %s\n", tui.FormatMoneyPlain(f.UnderlyingBudget))
+			}
+			if f.TotalBudget > 0 {
+				fmt.Printf("  Total budget: %s\n", tui.FormatMoneyPlain(f.TotalBudget))
+			}
+			if len(f.EntryConditions) > 0 {
+				fmt.Printf("  Entry conditions: %s\n", strings.Join(f.EntryConditions, ", "))
+			}
+			if len(f.ExcludeEntryConditions) > 0 {
+				fmt.Printf("  Excluded entry conditions: %s\n", strings.Join(f.ExcludeEntryConditions, ", "))
+			}
+			if f.MinDTE > 0 || f.MaxDTE != nil {
+				maxDTE := "any"
+				if f.MaxDTE != nil {
+					maxDTE = fmt.Sprintf("%d", *f.MaxDTE)
+				}
+				fmt.Printf("  Days to expiration: %d to %s\n", f.MinDTE, maxDTE)
+			}
+			if f.MinIV > 0 {
+				fmt.Printf("  Min IV: %g\n", f.MinIV)
+			}
+			if f.MaxIV > 0 {
+				fmt.Printf("  Max IV: %g\n", f.MaxIV)
+			}
+			if f.MinMoneyness != nil || f.MaxMoneyness != nil {
+				fmt.Printf("  Moneyness: %s to %s\n", formatBound(f.MinMoneyness), formatBound(f.MaxMoneyness))
+			}
+			if f.MinBid > 0 {
+				fmt.Printf("  Min bid: %s\n", tui.FormatMoneyPlain(f.MinBid))
+			}
+			if f.MaxSpread > 0 {
+				fmt.Printf("  Max spread: %s\n", tui.FormatMoneyPlain(f.MaxSpread))
+			}
+			if f.MaxSpreadPct > 0 {
+				fmt.Printf("  Max spread: %.1f%% of mid\n", f.MaxSpreadPct*100)
+			}
+			if f.MaxPositions > 0 {
+				fmt.Printf("  Max open copies: %d\n", f.MaxPositions)
+			}
+			if f.MaxPositionsPerUnderlying > 0 {
+				fmt.Printf("  Max open copies per underlying: %d\n", f.MaxPositionsPerUnderlying)
+			}
+			fmt.Printf("  Note: a running daemon picks up the change automatically\n")
 			return nil
 		},
 	}
@@ -812,6 +2003,14 @@ func cmdSignalFilterSet(cfg *config.Config) *cobra.Command {
 
 // ---- helpers ----
 
+// formatBound renders an optional filter bound, "any" when unset.
+func formatBound(v *float64) string {
+	if v == nil {
+		return "any"
+	}
+	return fmt.Sprintf("%+.1f%%", *v*100)
+}
+
 func requireSignalTOTP(cfg *config.Config) error {
 	bs, err := brokerstore.New(cfg.Profile)
 	if err != nil {
@@ -831,44 +2030,52 @@ func requireSignalTOTP(cfg *config.Config) error {
 	return nil
 }
 
-func fetchLatestSnapshot(ctx context.Context, apiOrigin, token string) ([]byte, error) {
-	data, err := util.ServiceGet(ctx, apiOrigin, "/v1/trades/latest", token)
+// findRule loads the named rule from the profile's signals directory,
+// assigning its deterministic ID if the YAML omits one.
+func findRule(cfg *config.Config, name string) (*sig.Rule, error) {
+	rulesDir, err := sig.SignalsDir(cfg.Profile)
 	if err != nil {
 		return nil, err
 	}
 
-	// The response is a TradesJson object; add type field for ParseSnapshot
-	var obj map[string]interface{}
-	if err := json.Unmarshal(data, &obj); err != nil {
+	rules, err := sig.LoadRulesFromDir(rulesDir)
+	if err != nil {
 		return nil, err
 	}
-	obj["type"] = "snapshot"
-	return json.Marshal(obj)
-}
-
-func collectKPIs(r *sig.Rule) []string {
-	var kpis []string
-	seen := make(map[string]bool)
 
-	var walk func(items []sig.ConditionOrGroup)
-	walk = func(items []sig.ConditionOrGroup) {
-		for _, c := range items {
-			if c.KPI != "" && !seen[c.KPI] {
-				kpis = append(kpis, c.KPI)
-				seen[c.KPI] = true
+	for _, r := range rules {
+		if strings.EqualFold(r.Name, name) {
+			if r.RuleID == "" {
+				r.RuleID = sig.DeterministicID("", r.Name)
 			}
-			walk(c.AllOf)
-			walk(c.AnyOf)
+			return r, nil
 		}
 	}
+	return nil, fmt.Errorf("rule %q not found", name)
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
 
-	if r.Entry != nil {
-		walk(r.Entry.AllOf)
-		walk(r.Entry.AnyOf)
+func fetchLatestSnapshot(ctx context.Context, apiOrigin, token string) ([]byte, error) {
+	data, err := util.ServiceGet(ctx, apiOrigin, "/v1/trades/latest", token)
+	if err != nil {
+		return nil, err
 	}
-	if r.Exit != nil {
-		walk(r.Exit.AllOf)
-		walk(r.Exit.AnyOf)
+
+	// The response is a TradesJson object; add type field for ParseSnapshot
+	var obj map[string]interface{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, err
 	}
-	return kpis
+	obj["type"] = "snapshot"
+	return json.Marshal(obj)
 }
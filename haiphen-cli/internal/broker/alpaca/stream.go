@@ -109,12 +109,14 @@ func parseTradeUpdate(data json.RawMessage) (broker.StreamEvent, error) {
 		Event     string `json:"event"`
 		Timestamp string `json:"timestamp"`
 		Order     struct {
-			ID     string `json:"id"`
-			Symbol string `json:"symbol"`
-			Side   string `json:"side"`
-			Qty    string `json:"qty"`
-			Type   string `json:"type"`
-			Status string `json:"status"`
+			ID             string  `json:"id"`
+			Symbol         string  `json:"symbol"`
+			Side           string  `json:"side"`
+			Qty            string  `json:"qty"`
+			Type           string  `json:"type"`
+			Status         string  `json:"status"`
+			FilledQty      string  `json:"filled_qty"`
+			FilledAvgPrice *string `json:"filled_avg_price"`
 		} `json:"order"`
 		Price string `json:"price"`
 		Qty   string `json:"qty"`
@@ -132,6 +134,11 @@ func parseTradeUpdate(data json.RawMessage) (broker.StreamEvent, error) {
 		Status:    update.Order.Status,
 		OrderID:   update.Order.ID,
 		Timestamp: time.Now(),
+		FillQty:   parseFloat(update.Qty),
+		FilledQty: parseFloat(update.Order.FilledQty),
+	}
+	if update.Order.FilledAvgPrice != nil {
+		event.FilledAvgPrice = parseFloat(*update.Order.FilledAvgPrice)
 	}
 
 	if t, err := time.Parse(time.RFC3339Nano, update.Timestamp); err == nil {
@@ -2,6 +2,7 @@ package alpaca
 
 import (
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/haiphen/haiphen-cli/internal/broker"
@@ -17,6 +18,7 @@ type alpacaAccount struct {
 	Cash              string `json:"cash"`
 	BuyingPower       string `json:"buying_power"`
 	Equity            string `json:"equity"`
+	LastEquity        string `json:"last_equity"`
 	PortfolioValue    string `json:"portfolio_value"`
 	LongMarketValue   string `json:"long_market_value"`
 	ShortMarketValue  string `json:"short_market_value"`
@@ -26,11 +28,13 @@ type alpacaAccount struct {
 	TradeSuspendedByUser bool `json:"trade_suspended_by_user"`
 	ShortingEnabled   bool   `json:"shorting_enabled"`
 	CryptoStatus      string `json:"crypto_status"`
+	OptionsTradingLevel int  `json:"options_trading_level"`
 }
 
 type alpacaPosition struct {
 	AssetID        string `json:"asset_id"`
 	Symbol         string `json:"symbol"`
+	AssetClass     string `json:"asset_class"`
 	Qty            string `json:"qty"`
 	Side           string `json:"side"`
 	AvgEntryPrice  string `json:"avg_entry_price"`
@@ -41,30 +45,46 @@ type alpacaPosition struct {
 }
 
 type alpacaOrder struct {
-	ID             string  `json:"id"`
-	ClientOrderID  string  `json:"client_order_id"`
-	Symbol         string  `json:"symbol"`
-	Qty            string  `json:"qty"`
-	FilledQty      string  `json:"filled_qty"`
-	Side           string  `json:"side"`
-	Type           string  `json:"type"`
-	TimeInForce    string  `json:"time_in_force"`
-	LimitPrice     *string `json:"limit_price"`
-	StopPrice      *string `json:"stop_price"`
-	FilledAvgPrice *string `json:"filled_avg_price"`
-	Status         string  `json:"status"`
-	CreatedAt      string  `json:"created_at"`
-	FilledAt       *string `json:"filled_at"`
+	ID             string        `json:"id"`
+	ClientOrderID  string        `json:"client_order_id"`
+	Symbol         string        `json:"symbol"`
+	AssetClass     string        `json:"asset_class"`
+	Qty            string        `json:"qty"`
+	FilledQty      string        `json:"filled_qty"`
+	Side           string        `json:"side"`
+	Type           string        `json:"type"`
+	TimeInForce    string        `json:"time_in_force"`
+	LimitPrice     *string       `json:"limit_price"`
+	StopPrice      *string       `json:"stop_price"`
+	FilledAvgPrice *string       `json:"filled_avg_price"`
+	Status         string        `json:"status"`
+	CreatedAt      string        `json:"created_at"`
+	FilledAt       *string       `json:"filled_at"`
+	OrderClass     string        `json:"order_class"`
+	Legs           []alpacaOrder `json:"legs"`
 }
 
 type alpacaOrderRequest struct {
-	Symbol      string `json:"symbol"`
-	Qty         string `json:"qty"`
-	Side        string `json:"side"`
-	Type        string `json:"type"`
-	TimeInForce string `json:"time_in_force"`
-	LimitPrice  string `json:"limit_price,omitempty"`
-	StopPrice   string `json:"stop_price,omitempty"`
+	Symbol         string            `json:"symbol"`
+	Qty            string            `json:"qty"`
+	Side           string            `json:"side"`
+	Type           string            `json:"type"`
+	TimeInForce    string            `json:"time_in_force"`
+	LimitPrice     string            `json:"limit_price,omitempty"`
+	StopPrice      string            `json:"stop_price,omitempty"`
+	OrderClass     string            `json:"order_class,omitempty"`
+	TakeProfit     *alpacaTakeProfit `json:"take_profit,omitempty"`
+	StopLoss       *alpacaStopLoss   `json:"stop_loss,omitempty"`
+	PositionIntent string            `json:"position_intent,omitempty"` // buy_to_open, sell_to_close, ...
+}
+
+type alpacaTakeProfit struct {
+	LimitPrice string `json:"limit_price"`
+}
+
+type alpacaStopLoss struct {
+	StopPrice  string `json:"stop_price"`
+	LimitPrice string `json:"limit_price,omitempty"`
 }
 
 // Conversion functions.
@@ -81,6 +101,7 @@ func (a *alpacaAccount) toBroker() *broker.Account {
 		Cash:           parseFloat(a.Cash),
 		BuyingPower:    parseFloat(a.BuyingPower),
 		Equity:         parseFloat(a.Equity),
+		LastEquity:     parseFloat(a.LastEquity),
 		PortfolioValue: parseFloat(a.PortfolioValue),
 		DayTradeCount:  a.DaytradeCount,
 		IsPaper:        true, // we only connect to paper-api
@@ -93,6 +114,7 @@ func (a *alpacaAccount) toConstraints() *broker.AccountConstraints {
 		DayTradeLimit:   3,
 		ShortingEnabled: a.ShortingEnabled,
 		CryptoEnabled:   a.CryptoStatus == "ACTIVE",
+		OptionsLevel:    a.OptionsTradingLevel,
 		RateLimitRPM:    200,
 	}
 }
@@ -100,6 +122,7 @@ func (a *alpacaAccount) toConstraints() *broker.AccountConstraints {
 func (p *alpacaPosition) toBroker() broker.Position {
 	return broker.Position{
 		Symbol:        p.Symbol,
+		AssetClass:    p.AssetClass,
 		Qty:           parseFloat(p.Qty),
 		Side:          p.Side,
 		EntryPrice:    parseFloat(p.AvgEntryPrice),
@@ -114,12 +137,17 @@ func (o *alpacaOrder) toBroker() broker.Order {
 	order := broker.Order{
 		OrderID:   o.ID,
 		Symbol:    o.Symbol,
+		AssetClass: o.AssetClass,
 		Qty:       parseFloat(o.Qty),
 		FilledQty: parseFloat(o.FilledQty),
 		Side:      o.Side,
 		Type:      o.Type,
 		TIF:       o.TimeInForce,
 		Status:    o.Status,
+		OrderClass: o.OrderClass,
+	}
+	for i := range o.Legs {
+		order.Legs = append(order.Legs, o.Legs[i].toBroker())
 	}
 	if o.LimitPrice != nil {
 		order.LimitPrice = parseFloat(*o.LimitPrice)
@@ -155,5 +183,31 @@ func brokerOrderRequest(req broker.OrderRequest) alpacaOrderRequest {
 	if req.StopPrice > 0 {
 		ar.StopPrice = strconv.FormatFloat(req.StopPrice, 'f', 2, 64)
 	}
+	if req.OrderClass != "" && req.OrderClass != broker.OrderClassSimple {
+		ar.OrderClass = req.OrderClass
+	}
+	if req.IsOption() {
+		// Alpaca takes the compact OCC symbol and, optionally, the intent
+		// joined to the side.
+		if c, err := broker.ParseOCC(req.Symbol); err == nil {
+			ar.Symbol = c.Symbol()
+		}
+		if req.PositionIntent != "" {
+			ar.PositionIntent = strings.ToLower(req.Side + "_to_" + req.PositionIntent)
+		}
+	}
+	if req.TakeProfit != nil {
+		ar.TakeProfit = &alpacaTakeProfit{
+			LimitPrice: strconv.FormatFloat(req.TakeProfit.LimitPrice, 'f', 2, 64),
+		}
+	}
+	if req.StopLoss != nil {
+		ar.StopLoss = &alpacaStopLoss{
+			StopPrice: strconv.FormatFloat(req.StopLoss.StopPrice, 'f', 2, 64),
+		}
+		if req.StopLoss.LimitPrice > 0 {
+			ar.StopLoss.LimitPrice = strconv.FormatFloat(req.StopLoss.LimitPrice, 'f', 2, 64)
+		}
+	}
 	return ar
 }
@@ -0,0 +1,159 @@
+package broker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ExchangeTimezone is the timezone trading days are counted in.
+const ExchangeTimezone = "America/New_York"
+
+var exchangeLocation = sync.OnceValue(func() *time.Location {
+	loc, err := time.LoadLocation(ExchangeTimezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+})
+
+// TradingDate returns the exchange date of t as YYYY-MM-DD.
+func TradingDate(t time.Time) string {
+	return t.In(exchangeLocation()).Format("2006-01-02")
+}
+
+// DayBaseline is the account equity at the start of a trading day. Daily P&L
+// is measured against it, so losses realized by closing positions still
+// count toward the daily loss limit.
+type DayBaseline struct {
+	Date       string    `json:"date"` // exchange date, YYYY-MM-DD
+	Equity     float64   `json:"equity"`
+	CapturedAt time.Time `json:"captured_at"`
+}
+
+// Baseline tracks the day-start equity for a profile. It is shared by every
+// process trading the profile through a file, so the CLI, the signal daemon
+// and copy-trade all measure the day from the same starting point.
+type Baseline struct {
+	mu   sync.Mutex
+	path string // "" keeps the baseline in memory only
+	cur  *DayBaseline
+}
+
+// BaselinePath returns the baseline file for a profile:
+// ~/.config/haiphen/broker.<profile>.baseline.json
+func BaselinePath(profile string) (string, error) {
+	if profile == "" {
+		profile = "default"
+	}
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "haiphen", fmt.Sprintf("broker.%s.baseline.json", profile)), nil
+}
+
+// OpenBaseline returns the baseline persisted at path. An empty path keeps
+// the baseline in memory, for tests and one-off runs.
+func OpenBaseline(path string) *Baseline {
+	return &Baseline{path: path}
+}
+
+// DailyPL returns the account's P&L for the trading day containing now:
+// current equity minus the day-start baseline. The first call of a new day
+// captures the baseline, from the previous close when the broker reports it
+// and from current equity otherwise.
+func (b *Baseline) DailyPL(acct *Account, now time.Time) (float64, error) {
+	if acct == nil {
+		return 0, fmt.Errorf("account is nil")
+	}
+	base, err := b.forDay(acct, now)
+	if err != nil {
+		return 0, err
+	}
+	return acct.Equity - base.Equity, nil
+}
+
+// Current returns the baseline for the trading day containing now, or nil if
+// none has been captured yet.
+func (b *Baseline) Current(now time.Time) *DayBaseline {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	day := TradingDate(now)
+	if err := b.load(day); err != nil || b.cur == nil || b.cur.Date != day {
+		return nil
+	}
+	cp := *b.cur
+	return &cp
+}
+
+func (b *Baseline) forDay(acct *Account, now time.Time) (DayBaseline, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	day := TradingDate(now)
+	if err := b.load(day); err != nil {
+		return DayBaseline{}, err
+	}
+	if b.cur != nil && b.cur.Date == day {
+		return *b.cur, nil
+	}
+
+	equity := acct.LastEquity
+	if equity <= 0 {
+		equity = acct.Equity
+	}
+	b.cur = &DayBaseline{Date: day, Equity: equity, CapturedAt: now.UTC()}
+	if err := b.save(); err != nil {
+		return DayBaseline{}, err
+	}
+	return *b.cur, nil
+}
+
+// load reads the persisted baseline unless the one in memory is already for
+// day; another process may have captured it first. Callers hold b.mu.
+func (b *Baseline) load(day string) error {
+	if (b.cur != nil && b.cur.Date == day) || b.path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(b.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read baseline: %w", err)
+	}
+	var cur DayBaseline
+	if err := json.Unmarshal(data, &cur); err != nil {
+		// Failing here would turn the daily loss limit off for good; treat
+		// the file as missing so the day's baseline is captured again.
+		log.Printf("[broker] baseline %s is corrupt, recapturing: %v", b.path, err)
+		return nil
+	}
+	b.cur = &cur
+	return nil
+}
+
+// save writes the baseline atomically. Callers hold b.mu.
+func (b *Baseline) save() error {
+	if b.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(b.cur, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(b.path), 0o700); err != nil {
+		return err
+	}
+	tmp := b.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("write baseline: %w", err)
+	}
+	return os.Rename(tmp, b.path)
+}
@@ -0,0 +1,73 @@
+package broker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBaseline_DailyPL(t *testing.T) {
+	b := OpenBaseline("")
+	open := time.Date(2026, 3, 2, 14, 30, 0, 0, time.UTC) // 09:30 New York
+
+	pl, err := b.DailyPL(&Account{Equity: 100500, LastEquity: 100000}, open)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pl != 500 {
+		t.Fatalf("daily P&L = %v, want 500 from the previous close", pl)
+	}
+
+	// A realized loss shows up even with no open positions left.
+	pl, _ = b.DailyPL(&Account{Equity: 97000, LastEquity: 100000}, open.Add(3*time.Hour))
+	if pl != -3000 {
+		t.Fatalf("daily P&L = %v, want -3000", pl)
+	}
+
+	// The next trading day starts from a new baseline.
+	pl, _ = b.DailyPL(&Account{Equity: 97000}, open.Add(24*time.Hour))
+	if pl != 0 {
+		t.Fatalf("daily P&L on a new day = %v, want 0", pl)
+	}
+	if cur := b.Current(open.Add(24 * time.Hour)); cur == nil || cur.Date != "2026-03-03" || cur.Equity != 97000 {
+		t.Fatalf("baseline = %+v, want 97000 on 2026-03-03", cur)
+	}
+}
+
+func TestBaseline_Persisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broker.test.baseline.json")
+	now := time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC)
+
+	if _, err := OpenBaseline(path).DailyPL(&Account{Equity: 50000}, now); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another process on the same profile measures from the same baseline.
+	pl, err := OpenBaseline(path).DailyPL(&Account{Equity: 48000, LastEquity: 60000}, now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pl != -2000 {
+		t.Fatalf("daily P&L = %v, want -2000 against the persisted baseline", pl)
+	}
+}
+
+func TestBaseline_CorruptFileRecaptured(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broker.test.baseline.json")
+	if err := os.WriteFile(path, []byte(`{"date": "2026-03-0`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC)
+
+	pl, err := OpenBaseline(path).DailyPL(&Account{Equity: 48000, LastEquity: 50000}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pl != -2000 {
+		t.Fatalf("daily P&L = %v, want -2000 from the recaptured previous close", pl)
+	}
+	if cur := OpenBaseline(path).Current(now); cur == nil || cur.Equity != 50000 {
+		t.Fatalf("baseline = %+v, want the recaptured 50000 written back", cur)
+	}
+}
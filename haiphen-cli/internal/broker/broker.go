@@ -51,6 +51,7 @@ type Account struct {
 	Cash          float64 `json:"cash"`
 	BuyingPower   float64 `json:"buying_power"`
 	Equity        float64 `json:"equity"`
+	LastEquity    float64 `json:"last_equity,omitempty"` // equity at the previous close
 	PortfolioValue float64 `json:"portfolio_value"`
 	DayTradeCount int     `json:"day_trade_count"`
 	IsPaper       bool    `json:"is_paper"`
@@ -59,6 +60,7 @@ type Account struct {
 // Position represents an open position.
 type Position struct {
 	Symbol        string  `json:"symbol"`
+	AssetClass    string  `json:"asset_class,omitempty"` // us_equity, us_option
 	Qty           float64 `json:"qty"`
 	Side          string  `json:"side"`
 	EntryPrice    float64 `json:"entry_price"`
@@ -77,23 +79,59 @@ type OrderRequest struct {
 	LimitPrice float64 `json:"limit_price,omitempty"`
 	StopPrice  float64 `json:"stop_price,omitempty"`
 	TIF        string  `json:"time_in_force"` // day, gtc, ioc, fok
+
+	// AssetClass defaults from the symbol: OCC symbols are options (see
+	// option.go). PositionIntent tells an opening option order from a
+	// closing one.
+	AssetClass     string `json:"asset_class,omitempty"`     // us_equity, us_option
+	PositionIntent string `json:"position_intent,omitempty"` // open, close
+
+	// Advanced order classes. Bracket submits the entry with both exit legs
+	// attached, OTO attaches one leg, and OCO submits two exit orders where
+	// filling one cancels the other. Empty means a simple order.
+	OrderClass string      `json:"order_class,omitempty"` // simple, bracket, oco, oto
+	TakeProfit *TakeProfit `json:"take_profit,omitempty"`
+	StopLoss   *StopLoss   `json:"stop_loss,omitempty"`
+}
+
+// Order classes for OrderRequest.OrderClass.
+const (
+	OrderClassSimple  = "simple"
+	OrderClassBracket = "bracket"
+	OrderClassOCO     = "oco"
+	OrderClassOTO     = "oto"
+)
+
+// TakeProfit is the limit leg that closes a position at a profit.
+type TakeProfit struct {
+	LimitPrice float64 `json:"limit_price"`
+}
+
+// StopLoss is the stop leg that closes a position at a loss. With LimitPrice
+// set the leg is a stop-limit order.
+type StopLoss struct {
+	StopPrice  float64 `json:"stop_price"`
+	LimitPrice float64 `json:"limit_price,omitempty"`
 }
 
 // Order represents a submitted order.
 type Order struct {
-	OrderID     string    `json:"order_id"`
-	Symbol      string    `json:"symbol"`
-	Qty         float64   `json:"qty"`
-	FilledQty   float64   `json:"filled_qty"`
-	Side        string    `json:"side"`
-	Type        string    `json:"type"`
-	LimitPrice  float64   `json:"limit_price,omitempty"`
-	StopPrice   float64   `json:"stop_price,omitempty"`
-	TIF         string    `json:"time_in_force"`
-	Status      string    `json:"status"`
-	FilledAvgPrice float64 `json:"filled_avg_price,omitempty"`
-	CreatedAt   time.Time `json:"created_at"`
-	FilledAt    *time.Time `json:"filled_at,omitempty"`
+	OrderID        string     `json:"order_id"`
+	Symbol         string     `json:"symbol"`
+	AssetClass     string     `json:"asset_class,omitempty"`
+	Qty            float64    `json:"qty"`
+	FilledQty      float64    `json:"filled_qty"`
+	Side           string     `json:"side"`
+	Type           string     `json:"type"`
+	LimitPrice     float64    `json:"limit_price,omitempty"`
+	StopPrice      float64    `json:"stop_price,omitempty"`
+	TIF            string     `json:"time_in_force"`
+	Status         string     `json:"status"`
+	FilledAvgPrice float64    `json:"filled_avg_price,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	FilledAt       *time.Time `json:"filled_at,omitempty"`
+	OrderClass     string     `json:"order_class,omitempty"`
+	Legs           []Order    `json:"legs,omitempty"` // attached take-profit / stop-loss orders
 }
 
 // AccountConstraints describes what the account supports.
@@ -102,6 +140,7 @@ type AccountConstraints struct {
 	DayTradeLimit int  `json:"day_trade_limit"`
 	ShortingEnabled bool `json:"shorting_enabled"`
 	CryptoEnabled bool `json:"crypto_enabled"`
+	OptionsLevel  int  `json:"options_level"` // approved options trading level; 0 = none
 	RateLimitRPM  int  `json:"rate_limit_rpm"`
 }
 
@@ -115,4 +154,10 @@ type StreamEvent struct {
 	Status    string    `json:"status,omitempty"`
 	OrderID   string    `json:"order_id,omitempty"`
 	Timestamp time.Time `json:"timestamp"`
+
+	// Fill details on trade updates: Price and FillQty are this execution,
+	// FilledQty and FilledAvgPrice the order's running totals.
+	FillQty        float64 `json:"fill_qty,omitempty"`
+	FilledQty      float64 `json:"filled_qty,omitempty"`
+	FilledAvgPrice float64 `json:"filled_avg_price,omitempty"`
 }
@@ -0,0 +1,136 @@
+package broker
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Asset classes for orders and positions.
+const (
+	AssetClassEquity = "us_equity"
+	AssetClassOption = "us_option"
+)
+
+// Position intents for option orders, which brokers need to tell an
+// opening sell (writing a contract) from a closing one.
+const (
+	IntentOpen  = "open"
+	IntentClose = "close"
+)
+
+// OptionMultiplier is the number of shares one standard equity option
+// contract covers.
+const OptionMultiplier = 100
+
+// occSuffixLen is the fixed tail of an OCC symbol: YYMMDD, C or P, and the
+// strike × 1000 in 8 digits.
+const occSuffixLen = 15
+
+// OptionContract is a listed option identified by its OCC symbol.
+type OptionContract struct {
+	Underlying string    `json:"underlying"`
+	Expiration time.Time `json:"expiration"` // expiration date, midnight UTC
+	Type       string    `json:"type"`       // call, put
+	Strike     float64   `json:"strike"`
+}
+
+// ParseOCC parses an OCC option symbol, either the padded 21-character form
+// ("AAPL  260220C00230000") or the compact form brokers use
+// ("AAPL260220C00230000").
+func ParseOCC(symbol string) (OptionContract, error) {
+	s := strings.ToUpper(strings.ReplaceAll(symbol, " ", ""))
+	if len(s) <= occSuffixLen || len(s) > occSuffixLen+6 {
+		return OptionContract{}, fmt.Errorf("invalid OCC symbol %q", symbol)
+	}
+	root, suffix := s[:len(s)-occSuffixLen], s[len(s)-occSuffixLen:]
+
+	exp, err := time.Parse("060102", suffix[:6])
+	if err != nil {
+		return OptionContract{}, fmt.Errorf("invalid OCC symbol %q: bad expiration", symbol)
+	}
+	var typ string
+	switch suffix[6] {
+	case 'C':
+		typ = "call"
+	case 'P':
+		typ = "put"
+	default:
+		return OptionContract{}, fmt.Errorf("invalid OCC symbol %q: type must be C or P", symbol)
+	}
+	strike, err := strconv.ParseUint(suffix[7:], 10, 32)
+	if err != nil || strike == 0 {
+		return OptionContract{}, fmt.Errorf("invalid OCC symbol %q: bad strike", symbol)
+	}
+
+	return OptionContract{
+		Underlying: root,
+		Expiration: exp,
+		Type:       typ,
+		Strike:     float64(strike) / 1000,
+	}, nil
+}
+
+// Symbol builds the compact OCC symbol for c.
+func (c OptionContract) Symbol() string {
+	cp := "C"
+	if strings.EqualFold(c.Type, "put") {
+		cp = "P"
+	}
+	return fmt.Sprintf("%s%s%s%08d", strings.ToUpper(c.Underlying), c.Expiration.Format("060102"), cp,
+		int64(math.Round(c.Strike*1000)))
+}
+
+// IsOptionSymbol reports whether symbol is an OCC option symbol.
+func IsOptionSymbol(symbol string) bool {
+	_, err := ParseOCC(symbol)
+	return err == nil
+}
+
+// IsOption reports whether the order is for an option contract: its asset
+// class says so or, when unset, its symbol is an OCC symbol.
+func (r OrderRequest) IsOption() bool {
+	if r.AssetClass != "" {
+		return r.AssetClass == AssetClassOption
+	}
+	return IsOptionSymbol(r.Symbol)
+}
+
+// Multiplier is the number of shares one unit of the order covers.
+func (r OrderRequest) Multiplier() float64 {
+	if r.IsOption() {
+		return OptionMultiplier
+	}
+	return 1
+}
+
+// validateOption checks the constraints listed options trade under: whole
+// contracts, a valid OCC symbol, market or limit orders for the day, and no
+// attached legs.
+func validateOption(req OrderRequest) error {
+	if req.Qty != math.Trunc(req.Qty) {
+		return fmt.Errorf("option orders must be for whole contracts, got %g", req.Qty)
+	}
+	if _, err := ParseOCC(req.Symbol); err != nil {
+		return err
+	}
+	switch strings.ToLower(req.Type) {
+	case "market", "limit":
+	default:
+		return fmt.Errorf("option orders must be type market or limit, got %q", req.Type)
+	}
+	if tif := strings.ToLower(req.TIF); tif != "" && tif != "day" {
+		return fmt.Errorf("option orders must be time-in-force day, got %q", req.TIF)
+	}
+	if class := strings.ToLower(req.OrderClass); class != "" && class != OrderClassSimple {
+		return fmt.Errorf("option orders do not support order class %s", class)
+	}
+	switch strings.ToLower(req.PositionIntent) {
+	case "", IntentOpen, IntentClose:
+	default:
+		return fmt.Errorf("invalid position intent %q: must be open or close", req.PositionIntent)
+	}
+	return nil
+}
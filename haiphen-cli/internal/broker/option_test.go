@@ -0,0 +1,66 @@
+package broker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseOCC(t *testing.T) {
+	for _, sym := range []string{"AAPL260220C00230000", "AAPL  260220C00230000", "aapl260220c00230000"} {
+		c, err := ParseOCC(sym)
+		if err != nil {
+			t.Fatalf("ParseOCC(%q): %v", sym, err)
+		}
+		want := OptionContract{Underlying: "AAPL", Expiration: time.Date(2026, 2, 20, 0, 0, 0, 0, time.UTC), Type: "call", Strike: 230}
+		if c != want {
+			t.Errorf("ParseOCC(%q) = %+v, want %+v", sym, c, want)
+		}
+		if got := c.Symbol(); got != "AAPL260220C00230000" {
+			t.Errorf("Symbol() = %q", got)
+		}
+	}
+
+	c, err := ParseOCC("SPXW260315P05512500")
+	if err != nil || c.Underlying != "SPXW" || c.Type != "put" || c.Strike != 5512.5 {
+		t.Errorf("ParseOCC(SPXW) = %+v, %v", c, err)
+	}
+
+	for _, sym := range []string{"", "AAPL", "260220C00230000", "AAPL260220X00230000", "AAPL261320C00230000", "AAPL260220C0023000A", "TOOLONGX260220C00230000"} {
+		if _, err := ParseOCC(sym); err == nil {
+			t.Errorf("ParseOCC(%q) should fail", sym)
+		}
+	}
+}
+
+func TestValidateOrderLimits_Options(t *testing.T) {
+	cfg := DefaultSafetyConfig()
+	call := "AAPL260220C00230000"
+
+	tests := []struct {
+		name    string
+		req     OrderRequest
+		wantErr bool
+	}{
+		{"market", OrderRequest{Symbol: call, Qty: 2, Type: "market", TIF: "day"}, false},
+		{"inferred from symbol", OrderRequest{Symbol: call, Qty: 1.5, Type: "market"}, true},
+		{"equity class skips option checks", OrderRequest{Symbol: call, AssetClass: AssetClassEquity, Qty: 1.5, Type: "market"}, false},
+		{"bad symbol", OrderRequest{Symbol: "AAPL", AssetClass: AssetClassOption, Qty: 1, Type: "market"}, true},
+		{"stop order", OrderRequest{Symbol: call, Qty: 1, Type: "stop", StopPrice: 2}, true},
+		{"gtc", OrderRequest{Symbol: call, Qty: 1, Type: "limit", LimitPrice: 2, TIF: "gtc"}, true},
+		{"bracket", OrderRequest{Symbol: call, Qty: 1, Type: "limit", LimitPrice: 2, OrderClass: OrderClassBracket}, true},
+		{"intent", OrderRequest{Symbol: call, Qty: 1, Type: "market", PositionIntent: IntentClose}, false},
+		{"bad intent", OrderRequest{Symbol: call, Qty: 1, Type: "market", PositionIntent: "roll"}, true},
+		// 100 contracts × $4.00 × 100 shares = $40,000
+		{"value within limit", OrderRequest{Symbol: call, Qty: 100, Type: "limit", LimitPrice: 4}, false},
+		// 200 contracts × $4.00 × 100 shares = $80,000
+		{"value counts multiplier", OrderRequest{Symbol: call, Qty: 200, Type: "limit", LimitPrice: 4}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateOrderLimits(tt.req, cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateOrderLimits() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
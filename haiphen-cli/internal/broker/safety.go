@@ -58,7 +58,9 @@ func ValidateAccountPaper(acct *Account) error {
 	return nil
 }
 
-// ValidateOrderLimits checks order against safety limits.
+// ValidateOrderLimits checks order against safety limits. Option orders are
+// also checked against the constraints options trade under, and their value
+// counts the contract multiplier.
 func ValidateOrderLimits(req OrderRequest, cfg SafetyConfig) error {
 	if req.Qty <= 0 {
 		return fmt.Errorf("quantity must be positive")
@@ -66,17 +68,22 @@ func ValidateOrderLimits(req OrderRequest, cfg SafetyConfig) error {
 	if int(req.Qty) > cfg.MaxOrderQty {
 		return fmt.Errorf("quantity %d exceeds max order quantity of %d (change with: haiphen broker config --max-order-qty)", int(req.Qty), cfg.MaxOrderQty)
 	}
+	if req.IsOption() {
+		if err := validateOption(req); err != nil {
+			return err
+		}
+	}
 
 	// Estimate order value for limit/stop orders.
 	var estValue float64
 	switch req.Type {
 	case "limit", "stop_limit":
 		if req.LimitPrice > 0 {
-			estValue = req.Qty * req.LimitPrice
+			estValue = req.Qty * req.LimitPrice * req.Multiplier()
 		}
 	case "stop":
 		if req.StopPrice > 0 {
-			estValue = req.Qty * req.StopPrice
+			estValue = req.Qty * req.StopPrice * req.Multiplier()
 		}
 	}
 
@@ -87,10 +94,82 @@ func ValidateOrderLimits(req OrderRequest, cfg SafetyConfig) error {
 	return nil
 }
 
-// ValidateDailyLoss checks if unrealized P&L exceeds the daily loss limit.
-func ValidateDailyLoss(unrealizedPL float64, cfg SafetyConfig) error {
-	if unrealizedPL < 0 && (-unrealizedPL) >= cfg.DailyLossLimit {
-		return fmt.Errorf("daily loss limit reached: unrealized P&L $%.2f exceeds -$%.2f limit; new orders blocked (change with: haiphen broker config --daily-loss-limit)", unrealizedPL, cfg.DailyLossLimit)
+// ValidateOrderClass checks that the take-profit and stop-loss legs match the
+// order class and sit on the correct side of each other for the order side.
+func ValidateOrderClass(req OrderRequest) error {
+	class := strings.ToLower(req.OrderClass)
+	tp, sl := req.TakeProfit, req.StopLoss
+
+	switch class {
+	case "", OrderClassSimple:
+		if tp != nil || sl != nil {
+			return fmt.Errorf("take-profit/stop-loss legs require order class bracket, oco or oto")
+		}
+		return nil
+	case OrderClassBracket, OrderClassOCO:
+		if tp == nil || sl == nil {
+			return fmt.Errorf("%s orders require both a take-profit and a stop-loss", class)
+		}
+	case OrderClassOTO:
+		if (tp == nil) == (sl == nil) {
+			return fmt.Errorf("oto orders require exactly one of take-profit or stop-loss")
+		}
+	default:
+		return fmt.Errorf("invalid order class %q: must be one of: simple, bracket, oco, oto", req.OrderClass)
+	}
+
+	if class == OrderClassOCO && strings.ToLower(req.Type) != "limit" {
+		return fmt.Errorf("oco orders must be type limit (the take-profit leg)")
+	}
+	if class == OrderClassBracket || class == OrderClassOCO {
+		tif := strings.ToLower(req.TIF)
+		if tif != "day" && tif != "gtc" {
+			return fmt.Errorf("%s orders require time-in-force day or gtc", class)
+		}
+	}
+
+	if tp != nil && tp.LimitPrice <= 0 {
+		return fmt.Errorf("take-profit limit price must be positive")
+	}
+	if sl != nil {
+		if sl.StopPrice <= 0 {
+			return fmt.Errorf("stop-loss stop price must be positive")
+		}
+		if sl.LimitPrice < 0 {
+			return fmt.Errorf("stop-loss limit price must not be negative")
+		}
+	}
+
+	// Legs close the position, so for a buy the take-profit sits above the
+	// stop-loss (and above a limit entry); for a sell the reverse.
+	buy := strings.ToLower(req.Side) == "buy"
+	if class == OrderClassOCO {
+		buy = !buy // OCO legs are themselves the closing orders
+	}
+	if tp != nil && sl != nil {
+		if buy && tp.LimitPrice <= sl.StopPrice {
+			return fmt.Errorf("take-profit %.2f must be above stop-loss %.2f for a long position", tp.LimitPrice, sl.StopPrice)
+		}
+		if !buy && tp.LimitPrice >= sl.StopPrice {
+			return fmt.Errorf("take-profit %.2f must be below stop-loss %.2f for a short position", tp.LimitPrice, sl.StopPrice)
+		}
+	}
+	if class != OrderClassOCO && req.LimitPrice > 0 {
+		if tp != nil && ((buy && tp.LimitPrice <= req.LimitPrice) || (!buy && tp.LimitPrice >= req.LimitPrice)) {
+			return fmt.Errorf("take-profit %.2f is on the wrong side of the entry limit %.2f", tp.LimitPrice, req.LimitPrice)
+		}
+		if sl != nil && ((buy && sl.StopPrice >= req.LimitPrice) || (!buy && sl.StopPrice <= req.LimitPrice)) {
+			return fmt.Errorf("stop-loss %.2f is on the wrong side of the entry limit %.2f", sl.StopPrice, req.LimitPrice)
+		}
+	}
+	return nil
+}
+
+// ValidateDailyLoss checks if the day's P&L exceeds the daily loss limit.
+// dailyPL is realized plus unrealized, as returned by Baseline.DailyPL.
+func ValidateDailyLoss(dailyPL float64, cfg SafetyConfig) error {
+	if dailyPL < 0 && (-dailyPL) >= cfg.DailyLossLimit {
+		return fmt.Errorf("daily loss limit reached: daily P&L $%.2f exceeds -$%.2f limit; new orders blocked (change with: haiphen broker config --daily-loss-limit)", dailyPL, cfg.DailyLossLimit)
 	}
 	return nil
 }
@@ -76,6 +76,39 @@ func TestValidateOrderLimits(t *testing.T) {
 	}
 }
 
+func TestValidateOrderClass(t *testing.T) {
+	tp := func(p float64) *TakeProfit { return &TakeProfit{LimitPrice: p} }
+	sl := func(p float64) *StopLoss { return &StopLoss{StopPrice: p} }
+
+	tests := []struct {
+		name    string
+		req     OrderRequest
+		wantErr bool
+	}{
+		{"simple", OrderRequest{Side: "buy", Type: "market", TIF: "day"}, false},
+		{"simple with legs", OrderRequest{Side: "buy", Type: "market", TakeProfit: tp(110)}, true},
+		{"bracket buy", OrderRequest{OrderClass: "bracket", Side: "buy", Type: "market", TIF: "gtc", TakeProfit: tp(110), StopLoss: sl(95)}, false},
+		{"bracket buy inverted", OrderRequest{OrderClass: "bracket", Side: "buy", Type: "market", TIF: "gtc", TakeProfit: tp(95), StopLoss: sl(110)}, true},
+		{"bracket sell", OrderRequest{OrderClass: "bracket", Side: "sell", Type: "market", TIF: "day", TakeProfit: tp(95), StopLoss: sl(110)}, false},
+		{"bracket missing leg", OrderRequest{OrderClass: "bracket", Side: "buy", Type: "market", TIF: "day", TakeProfit: tp(110)}, true},
+		{"bracket ioc", OrderRequest{OrderClass: "bracket", Side: "buy", Type: "market", TIF: "ioc", TakeProfit: tp(110), StopLoss: sl(95)}, true},
+		{"bracket leg below limit entry", OrderRequest{OrderClass: "bracket", Side: "buy", Type: "limit", TIF: "day", LimitPrice: 100, TakeProfit: tp(99), StopLoss: sl(95)}, true},
+		{"oco sell", OrderRequest{OrderClass: "oco", Side: "sell", Type: "limit", TIF: "gtc", TakeProfit: tp(110), StopLoss: sl(95)}, false},
+		{"oco market", OrderRequest{OrderClass: "oco", Side: "sell", Type: "market", TIF: "gtc", TakeProfit: tp(110), StopLoss: sl(95)}, true},
+		{"oto stop only", OrderRequest{OrderClass: "oto", Side: "buy", Type: "market", TIF: "day", StopLoss: sl(95)}, false},
+		{"oto both legs", OrderRequest{OrderClass: "oto", Side: "buy", Type: "market", TIF: "day", TakeProfit: tp(110), StopLoss: sl(95)}, true},
+		{"unknown class", OrderRequest{OrderClass: "trailing", Side: "buy", Type: "market"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateOrderClass(tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateOrderClass() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestValidateDailyLoss(t *testing.T) {
 	cfg := DefaultSafetyConfig() // limit = 10000
 
@@ -366,9 +366,10 @@ func stepTradingTrade(cfg *config.Config) Step {
 			}
 
 			// Large-order guard
-			estimatedValue := float64(qty) * limitPrice
+			multiplier := broker.OrderRequest{Symbol: symbol}.Multiplier()
+			estimatedValue := float64(qty) * limitPrice * multiplier
 			if limitPrice == 0 && stopPrice > 0 {
-				estimatedValue = float64(qty) * stopPrice
+				estimatedValue = float64(qty) * stopPrice * multiplier
 			}
 			if estimatedValue > 0 && estimatedValue > cfg.BrokerMaxOrderValue*0.5 {
 				fmt.Fprintf(w, "\n  %s Estimated value $%.0f exceeds 50%% of max order value ($%.0f)\n",
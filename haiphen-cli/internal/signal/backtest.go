@@ -0,0 +1,478 @@
+package signal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/haiphen/haiphen-cli/internal/broker"
+)
+
+// BacktestConfig controls a historical replay of a rule.
+type BacktestConfig struct {
+	PriceKPI     string       // KPI used as the simulated fill and mark price
+	StartingCash float64      // simulated account cash at the start of the run
+	Engine       EngineConfig // DryRun is ignored; orders always go to the simulator
+}
+
+// BacktestFill is a single simulated execution.
+type BacktestFill struct {
+	Time       time.Time `json:"time"`
+	Symbol     string    `json:"symbol"`
+	Side       string    `json:"side"`
+	Qty        float64   `json:"qty"`
+	Price      float64   `json:"price"`
+	RealizedPL float64   `json:"realized_pl"`
+}
+
+// BacktestResult summarizes how a rule would have behaved over a snapshot history.
+type BacktestResult struct {
+	Rule           string         `json:"rule"`
+	From           string         `json:"from"`
+	To             string         `json:"to"`
+	Snapshots      int            `json:"snapshots"`
+	Triggers       map[string]int `json:"triggers"` // event type → count
+	Fills          []BacktestFill `json:"fills"`
+	StartingEquity float64        `json:"starting_equity"`
+	EndingEquity   float64        `json:"ending_equity"`
+	RealizedPL     float64        `json:"realized_pl"`
+	UnrealizedPL   float64        `json:"unrealized_pl"`
+	TotalPL        float64        `json:"total_pl"`
+	MaxDrawdown    float64        `json:"max_drawdown"`     // peak-to-trough equity drop in dollars
+	MaxDrawdownPct float64        `json:"max_drawdown_pct"` // same drop as a percent of the peak
+	ClosedTrades   int            `json:"closed_trades"`
+	Wins           int            `json:"wins"`
+	HitRate        float64        `json:"hit_rate"` // wins / closed trades, 0-1
+}
+
+// RunBacktest replays snapshots through a fresh Engine holding only rule,
+// routing orders to a simulated broker that fills at cfg.PriceKPI.
+// Cooldowns and hourly caps run on snapshot time, not wall-clock time.
+func RunBacktest(ctx context.Context, rule *Rule, snaps []*Snapshot, cfg BacktestConfig) (*BacktestResult, error) {
+	if rule == nil {
+		return nil, fmt.Errorf("rule is required")
+	}
+	if len(snaps) == 0 {
+		return nil, fmt.Errorf("no snapshots to replay")
+	}
+	if cfg.PriceKPI == "" {
+		return nil, fmt.Errorf("price KPI is required")
+	}
+	if cfg.StartingCash <= 0 {
+		return nil, fmt.Errorf("starting cash must be positive")
+	}
+
+	r := *rule
+	r.Status = "active"
+	if r.RuleID == "" {
+		r.RuleID = DeterministicID("", r.Name)
+	}
+
+	sim := newSimBroker(cfg.StartingCash)
+	ecfg := cfg.Engine
+	ecfg.DryRun = false
+
+	// Buffered generously and drained after every snapshot so nothing is dropped.
+	events := make(chan Event, 256)
+	engine := NewEngine(sim, ecfg, events)
+	engine.SetRules([]*Rule{&r})
+
+	var clock time.Time
+	engine.SetClock(func() time.Time { return clock })
+
+	res := &BacktestResult{
+		Rule:           r.Name,
+		From:           snaps[0].Date,
+		To:             snaps[len(snaps)-1].Date,
+		Snapshots:      len(snaps),
+		Triggers:       make(map[string]int),
+		StartingEquity: cfg.StartingCash,
+	}
+
+	peak := cfg.StartingCash
+	for i, snap := range snaps {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if t, ok := snapshotTime(snap); ok {
+			clock = t
+		} else if i > 0 {
+			clock = clock.Add(time.Minute)
+		}
+		sim.now = clock
+		if px, ok := snap.KPIs[cfg.PriceKPI]; ok && px > 0 {
+			sim.price = px
+		}
+
+		engine.Evaluate(ctx, snap)
+
+		for _, ev := range drainEvents(events) {
+			res.Triggers[ev.EventType]++
+		}
+
+		equity := sim.equity()
+		if equity > peak {
+			peak = equity
+		}
+		if dd := peak - equity; dd > res.MaxDrawdown {
+			res.MaxDrawdown = dd
+			res.MaxDrawdownPct = dd / peak * 100
+		}
+	}
+
+	res.Fills = sim.fills
+	res.EndingEquity = sim.equity()
+	res.RealizedPL = sim.realized
+	res.UnrealizedPL = sim.unrealized()
+	res.TotalPL = res.EndingEquity - res.StartingEquity
+	for _, f := range sim.fills {
+		if f.RealizedPL != 0 {
+			res.ClosedTrades++
+			if f.RealizedPL > 0 {
+				res.Wins++
+			}
+		}
+	}
+	if res.ClosedTrades > 0 {
+		res.HitRate = float64(res.Wins) / float64(res.ClosedTrades)
+	}
+
+	return res, nil
+}
+
+// snapshotTime returns the moment a snapshot describes: UpdatedAt when it
+// parses, otherwise midnight UTC of Date.
+func snapshotTime(s *Snapshot) (time.Time, bool) {
+	if s.UpdatedAt != "" {
+		if t, err := time.Parse(time.RFC3339Nano, s.UpdatedAt); err == nil {
+			return t, true
+		}
+	}
+	if s.Date != "" {
+		if t, err := time.Parse("2006-01-02", s.Date); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// LoadSnapshotsFile reads snapshots from a JSONL file or a recorded feed
+// journal (*.jsonl.gz). Each JSONL line is a raw feed message
+// ({"type":"snapshot","rows":[...]}), a Snapshot object
+// ({"date":...,"kpis":{...}}), or an exported journal entry
+// ({"recv_at":...,"msg":{...}}). Other feed message types are skipped.
+func LoadSnapshotsFile(path string) ([]*Snapshot, error) {
+	var snaps []*Snapshot
+
+	if strings.HasSuffix(path, recordingExt) {
+		err := ReadRecording(path, func(m RecordedMessage) error {
+			snap, err := snapshotFromLine(m.Msg)
+			if err != nil {
+				return nil // the daemon skipped malformed frames too
+			}
+			if snap != nil {
+				snaps = append(snaps, snap)
+			}
+			return nil
+		})
+		return snaps, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		snap, err := snapshotFromLine([]byte(line))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if snap != nil {
+			snaps = append(snaps, snap)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return snaps, nil
+}
+
+// snapshotFromLine decodes one JSONL line, returning nil for lines that
+// carry something other than a snapshot.
+func snapshotFromLine(line []byte) (*Snapshot, error) {
+	var envelope struct {
+		Type string          `json:"type"`
+		Msg  json.RawMessage `json:"msg"`
+	}
+	if err := json.Unmarshal(line, &envelope); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case envelope.Type == "snapshot":
+		return ParseSnapshot(line)
+	case envelope.Type != "":
+		return nil, nil
+	case len(envelope.Msg) > 0:
+		return snapshotFromLine(envelope.Msg)
+	}
+
+	var snap Snapshot
+	if err := json.Unmarshal(line, &snap); err != nil {
+		return nil, err
+	}
+	if snap.KPIs == nil {
+		snap.KPIs = make(map[string]float64)
+	}
+	return &snap, nil
+}
+
+// FilterSnapshots keeps snapshots whose Date falls within [from, to].
+// Empty bounds are open-ended; dates compare as YYYY-MM-DD strings.
+func FilterSnapshots(snaps []*Snapshot, from, to string) []*Snapshot {
+	var out []*Snapshot
+	for _, s := range snaps {
+		if from != "" && s.Date < from {
+			continue
+		}
+		if to != "" && s.Date > to {
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// simBroker is an in-memory broker.Broker that fills orders at a single mark
+// price. It backs RunBacktest and never talks to a real brokerage.
+type simBroker struct {
+	cash      float64
+	price     float64
+	now       time.Time
+	realized  float64
+	positions map[string]*simPosition
+	orders    []broker.Order
+	fills     []BacktestFill
+}
+
+type simPosition struct {
+	qty      float64 // signed: negative is short
+	avgPrice float64
+}
+
+func newSimBroker(cash float64) *simBroker {
+	return &simBroker{
+		cash:      cash,
+		positions: make(map[string]*simPosition),
+	}
+}
+
+func (b *simBroker) equity() float64 {
+	eq := b.cash
+	for _, p := range b.positions {
+		eq += p.qty * b.price
+	}
+	return eq
+}
+
+func (b *simBroker) unrealized() float64 {
+	var pl float64
+	for _, p := range b.positions {
+		pl += (b.price - p.avgPrice) * p.qty
+	}
+	return pl
+}
+
+func (b *simBroker) Name() string                  { return "backtest" }
+func (b *simBroker) Connect(context.Context) error { return nil }
+func (b *simBroker) Close() error                  { return nil }
+
+func (b *simBroker) GetAccount(context.Context) (*broker.Account, error) {
+	eq := b.equity()
+	return &broker.Account{
+		AccountID:      "backtest",
+		Currency:       "USD",
+		Cash:           b.cash,
+		BuyingPower:    math.Max(b.cash, 0),
+		Equity:         eq,
+		PortfolioValue: eq,
+		IsPaper:        true,
+	}, nil
+}
+
+func (b *simBroker) GetPositions(context.Context) ([]broker.Position, error) {
+	var out []broker.Position
+	for sym, p := range b.positions {
+		if p.qty == 0 {
+			continue
+		}
+		side := "long"
+		if p.qty < 0 {
+			side = "short"
+		}
+		pl := (b.price - p.avgPrice) * p.qty
+		var plp float64
+		if p.avgPrice > 0 {
+			plp = pl / math.Abs(p.avgPrice*p.qty) * 100
+		}
+		out = append(out, broker.Position{
+			Symbol:        sym,
+			Qty:           math.Abs(p.qty),
+			Side:          side,
+			EntryPrice:    p.avgPrice,
+			CurrentPrice:  b.price,
+			MarketValue:   p.qty * b.price,
+			UnrealizedPL:  pl,
+			UnrealizedPLP: plp,
+		})
+	}
+	return out, nil
+}
+
+// CreateOrder fills marketable orders immediately at the mark price.
+// Limit and stop orders that are not marketable on this snapshot expire unfilled.
+func (b *simBroker) CreateOrder(_ context.Context, req broker.OrderRequest) (*broker.Order, error) {
+	if b.price <= 0 {
+		return nil, fmt.Errorf("backtest: no price available for %s", req.Symbol)
+	}
+
+	order := broker.Order{
+		OrderID:    fmt.Sprintf("bt-%d", len(b.orders)+1),
+		Symbol:     req.Symbol,
+		Qty:        req.Qty,
+		Side:       req.Side,
+		Type:       req.Type,
+		LimitPrice: req.LimitPrice,
+		StopPrice:  req.StopPrice,
+		TIF:        req.TIF,
+		OrderClass: req.OrderClass,
+		Status:     "filled",
+		CreatedAt:  b.now,
+	}
+
+	buy := req.Side == "buy"
+	marketable := true
+	switch req.Type {
+	case "limit":
+		marketable = (buy && b.price <= req.LimitPrice) || (!buy && b.price >= req.LimitPrice)
+	case "stop":
+		marketable = (buy && b.price >= req.StopPrice) || (!buy && b.price <= req.StopPrice)
+	case "stop_limit":
+		triggered := (buy && b.price >= req.StopPrice) || (!buy && b.price <= req.StopPrice)
+		inLimit := (buy && b.price <= req.LimitPrice) || (!buy && b.price >= req.LimitPrice)
+		marketable = triggered && inLimit
+	}
+	if !marketable {
+		order.Status = "expired"
+		b.orders = append(b.orders, order)
+		return &order, nil
+	}
+
+	if buy && req.Qty*b.price > b.cash {
+		return nil, fmt.Errorf("backtest: insufficient buying power for %.0f %s @ %.2f", req.Qty, req.Symbol, b.price)
+	}
+
+	realized := b.apply(req.Symbol, req.Side, req.Qty, b.price)
+
+	filledAt := b.now
+	order.FilledQty = req.Qty
+	order.FilledAvgPrice = b.price
+	order.FilledAt = &filledAt
+	b.orders = append(b.orders, order)
+	b.fills = append(b.fills, BacktestFill{
+		Time:       b.now,
+		Symbol:     req.Symbol,
+		Side:       req.Side,
+		Qty:        req.Qty,
+		Price:      b.price,
+		RealizedPL: realized,
+	})
+	return &order, nil
+}
+
+// apply books a fill against the position and returns the realized P&L.
+func (b *simBroker) apply(symbol, side string, qty, price float64) float64 {
+	signed := qty
+	if side == "sell" {
+		signed = -qty
+	}
+
+	p, ok := b.positions[symbol]
+	if !ok {
+		p = &simPosition{}
+		b.positions[symbol] = p
+	}
+
+	var realized float64
+	reducing := p.qty != 0 && (p.qty > 0) != (signed > 0)
+	if reducing {
+		closing := math.Min(math.Abs(signed), math.Abs(p.qty))
+		if p.qty > 0 {
+			realized = (price - p.avgPrice) * closing
+		} else {
+			realized = (p.avgPrice - price) * closing
+		}
+	}
+
+	newQty := p.qty + signed
+	switch {
+	case newQty == 0:
+		p.avgPrice = 0
+	case !reducing:
+		p.avgPrice = (p.avgPrice*math.Abs(p.qty) + price*math.Abs(signed)) / math.Abs(newQty)
+	case (newQty > 0) != (p.qty > 0):
+		// Flipped through flat: the remainder opens at the fill price.
+		p.avgPrice = price
+	}
+	p.qty = newQty
+
+	b.cash -= signed * price
+	b.realized += realized
+	return realized
+}
+
+func (b *simBroker) CancelOrder(context.Context, string) error    { return nil }
+func (b *simBroker) CancelAllOrders(context.Context) (int, error) { return 0, nil }
+
+func (b *simBroker) GetOrders(_ context.Context, _ string, limit int) ([]broker.Order, error) {
+	out := b.orders
+	if limit > 0 && len(out) > limit {
+		out = out[len(out)-limit:]
+	}
+	return out, nil
+}
+
+func (b *simBroker) GetOrderByID(_ context.Context, orderID string) (*broker.Order, error) {
+	for i := range b.orders {
+		if b.orders[i].OrderID == orderID {
+			o := b.orders[i]
+			return &o, nil
+		}
+	}
+	return nil, fmt.Errorf("order %s not found", orderID)
+}
+
+func (b *simBroker) ProbeConstraints(context.Context) (*broker.AccountConstraints, error) {
+	return &broker.AccountConstraints{ShortingEnabled: true}, nil
+}
+
+func (b *simBroker) StreamUpdates(ctx context.Context, _ chan<- broker.StreamEvent) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
@@ -0,0 +1,193 @@
+package signal
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func backtestRule() *Rule {
+	return &Rule{
+		Name:     "bt",
+		Symbols:  []string{"SPY"},
+		Entry:    &ConditionGroup{AllOf: []ConditionOrGroup{{KPI: "Signal", Operator: "crosses_above", Value: 0}}},
+		Exit:     &ConditionGroup{AllOf: []ConditionOrGroup{{KPI: "Signal", Operator: "crosses_below", Value: 0}}},
+		Order:    OrderParams{Side: "buy", Type: "market", Qty: 10, TIF: "day"},
+		Cooldown: 60,
+	}
+}
+
+func btSnap(ts string, signal, price float64) *Snapshot {
+	return &Snapshot{
+		Date:      ts[:10],
+		UpdatedAt: ts,
+		KPIs:      map[string]float64{"Signal": signal, "Price": price},
+	}
+}
+
+func TestRunBacktest_FillsAndPL(t *testing.T) {
+	rule := backtestRule()
+	rule.Exit = nil
+
+	snaps := []*Snapshot{
+		btSnap("2026-02-10T14:30:00Z", -1, 100),
+		btSnap("2026-02-10T14:31:00Z", 1, 101), // entry: buy 10 @ 101
+		btSnap("2026-02-10T14:40:00Z", 2, 110),
+	}
+
+	cfg := BacktestConfig{PriceKPI: "Price", StartingCash: 10000, Engine: DefaultEngineConfig()}
+	res, err := RunBacktest(context.Background(), rule, snaps, cfg)
+	if err != nil {
+		t.Fatalf("RunBacktest: %v", err)
+	}
+
+	if res.Triggers["entry_triggered"] != 1 {
+		t.Fatalf("entry triggers = %d, want 1", res.Triggers["entry_triggered"])
+	}
+	if res.Triggers["order_placed"] != 1 {
+		t.Fatalf("order_placed = %d, want 1", res.Triggers["order_placed"])
+	}
+	if len(res.Fills) != 1 || res.Fills[0].Price != 101 {
+		t.Fatalf("unexpected fills: %+v", res.Fills)
+	}
+	if math.Abs(res.UnrealizedPL-90) > 1e-9 {
+		t.Errorf("UnrealizedPL = %.2f, want 90", res.UnrealizedPL)
+	}
+	if math.Abs(res.TotalPL-90) > 1e-9 {
+		t.Errorf("TotalPL = %.2f, want 90", res.TotalPL)
+	}
+	if !res.Fills[0].Time.Equal(time.Date(2026, 2, 10, 14, 31, 0, 0, time.UTC)) {
+		t.Errorf("fill time = %v, want snapshot time", res.Fills[0].Time)
+	}
+}
+
+func TestRunBacktest_CooldownUsesSnapshotTime(t *testing.T) {
+	rule := backtestRule()
+	rule.Exit = nil
+	rule.Entry = &ConditionGroup{AllOf: []ConditionOrGroup{{KPI: "Signal", Operator: ">", Value: 0}}}
+	rule.Cooldown = 300
+
+	snaps := []*Snapshot{
+		btSnap("2026-02-10T14:30:00Z", 1, 100), // trigger
+		btSnap("2026-02-10T14:32:00Z", 1, 100), // within 5m cooldown
+		btSnap("2026-02-10T14:36:00Z", 1, 100), // cooldown elapsed
+	}
+
+	cfg := BacktestConfig{PriceKPI: "Price", StartingCash: 100000, Engine: DefaultEngineConfig()}
+	res, err := RunBacktest(context.Background(), rule, snaps, cfg)
+	if err != nil {
+		t.Fatalf("RunBacktest: %v", err)
+	}
+	if res.Triggers["entry_triggered"] != 2 {
+		t.Fatalf("entry triggers = %d, want 2", res.Triggers["entry_triggered"])
+	}
+}
+
+func TestRunBacktest_DrawdownAndHitRate(t *testing.T) {
+	rule := backtestRule()
+	rule.Exit.AllOf[0] = ConditionOrGroup{KPI: "Exit", Operator: ">", Value: 0}
+	rule.Order.Qty = 1
+
+	snaps := []*Snapshot{
+		btSnap("2026-02-10T14:30:00Z", -1, 100),
+		btSnap("2026-02-10T14:31:00Z", 1, 100), // buy 1 @ 100
+		btSnap("2026-02-10T14:40:00Z", 1, 80),  // equity drops 20
+		btSnap("2026-02-10T14:50:00Z", 1, 120),
+	}
+	cfg := BacktestConfig{PriceKPI: "Price", StartingCash: 1000, Engine: DefaultEngineConfig()}
+	res, err := RunBacktest(context.Background(), rule, snaps, cfg)
+	if err != nil {
+		t.Fatalf("RunBacktest: %v", err)
+	}
+	if math.Abs(res.MaxDrawdown-20) > 1e-9 {
+		t.Errorf("MaxDrawdown = %.2f, want 20", res.MaxDrawdown)
+	}
+	if res.ClosedTrades != 0 || res.HitRate != 0 {
+		t.Errorf("expected no closed trades, got %d (hit rate %.2f)", res.ClosedTrades, res.HitRate)
+	}
+}
+
+func TestSimBroker_RealizedPL(t *testing.T) {
+	b := newSimBroker(10000)
+	b.price = 50
+	if r := b.apply("SPY", "buy", 10, 50); r != 0 {
+		t.Fatalf("opening fill realized %.2f", r)
+	}
+	if r := b.apply("SPY", "sell", 4, 60); math.Abs(r-40) > 1e-9 {
+		t.Fatalf("partial close realized %.2f, want 40", r)
+	}
+	if r := b.apply("SPY", "sell", 10, 40); math.Abs(r-(-60)) > 1e-9 {
+		t.Fatalf("flip realized %.2f, want -60", r)
+	}
+	p := b.positions["SPY"]
+	if p.qty != -4 || p.avgPrice != 40 {
+		t.Fatalf("after flip: qty=%.0f avg=%.2f, want -4 @ 40", p.qty, p.avgPrice)
+	}
+	if math.Abs(b.realized-(-20)) > 1e-9 {
+		t.Fatalf("total realized %.2f, want -20", b.realized)
+	}
+}
+
+func TestRunBacktest_RequiresPriceKPI(t *testing.T) {
+	_, err := RunBacktest(context.Background(), backtestRule(), []*Snapshot{btSnap("2026-02-10T14:30:00Z", 1, 1)}, BacktestConfig{StartingCash: 1})
+	if err == nil {
+		t.Fatal("expected error without price KPI")
+	}
+}
+
+func TestLoadSnapshotsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snaps.jsonl")
+	data := `{"type":"hello"}
+{"type":"snapshot","date":"2026-02-10","updated_at":"2026-02-10T14:30:00Z","rows":[{"kpi":"Price","value":"101.5"}]}
+
+{"date":"2026-02-11","updated_at":"2026-02-11T14:30:00Z","kpis":{"Price":102}}
+{"type":"position_events","positions":[]}
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	snaps, err := LoadSnapshotsFile(path)
+	if err != nil {
+		t.Fatalf("LoadSnapshotsFile: %v", err)
+	}
+	if len(snaps) != 2 {
+		t.Fatalf("got %d snapshots, want 2", len(snaps))
+	}
+	if snaps[0].KPIs["Price"] != 101.5 || snaps[1].KPIs["Price"] != 102 {
+		t.Fatalf("unexpected prices: %v, %v", snaps[0].KPIs, snaps[1].KPIs)
+	}
+
+	filtered := FilterSnapshots(snaps, "2026-02-11", "")
+	if len(filtered) != 1 || filtered[0].Date != "2026-02-11" {
+		t.Fatalf("FilterSnapshots: got %d", len(filtered))
+	}
+}
+
+func TestFetchSnapshotHistory_FromBeforeOldestDate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A full page of dates, newest first: 2026-04-10 back to 2026-01-01.
+		var items []map[string]string
+		start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		for i := tradeDatesPageSize - 1; i >= 0; i-- {
+			items = append(items, map[string]string{"date": start.AddDate(0, 0, i).Format("2006-01-02")})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"items": items})
+	}))
+	defer srv.Close()
+
+	_, err := FetchSnapshotHistory(context.Background(), srv.URL, "tok", []string{"X"}, "2025-12-01", "2025-12-31")
+	if err == nil {
+		t.Fatal("expected an error for a from date before the oldest listed date")
+	}
+	if !strings.Contains(err.Error(), "before 2026-01-01") {
+		t.Errorf("error = %v, want it to name 2026-01-01", err)
+	}
+}
@@ -0,0 +1,131 @@
+package signal
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/haiphen/haiphen-cli/internal/broker"
+)
+
+// PriceOffset places a take-profit or stop-loss leg at a distance from the
+// entry reference price, either in price units or as a percentage.
+type PriceOffset struct {
+	Offset float64 `yaml:"offset,omitempty" json:"offset,omitempty"`
+	Pct    float64 `yaml:"pct,omitempty"    json:"pct,omitempty"`
+	// LimitPct turns a stop-loss into a stop-limit, placing the limit this
+	// percent beyond the stop price.
+	LimitPct float64 `yaml:"limit_pct,omitempty" json:"limit_pct,omitempty"`
+}
+
+func (p *PriceOffset) validate(label string) error {
+	if (p.Offset > 0) == (p.Pct > 0) {
+		return fmt.Errorf("order.%s: set exactly one of offset or pct", label)
+	}
+	if p.Offset < 0 || p.Pct < 0 || p.Pct >= 100 {
+		return fmt.Errorf("order.%s: offset must be positive and pct below 100", label)
+	}
+	if p.LimitPct < 0 || p.LimitPct >= 100 {
+		return fmt.Errorf("order.%s: limit_pct must be in [0, 100)", label)
+	}
+	return nil
+}
+
+// distance returns the offset in price units from ref.
+func (p *PriceOffset) distance(ref float64) float64 {
+	if p.Offset > 0 {
+		return p.Offset
+	}
+	return ref * p.Pct / 100
+}
+
+// hasExits reports whether the order attaches take-profit or stop-loss legs.
+func (o OrderParams) hasExits() bool {
+	return o.TakeProfit != nil || o.StopLoss != nil
+}
+
+// referencePriceKPI is the KPI that offsets are measured from.
+func (o OrderParams) referencePriceKPI() string {
+	if o.PriceKPI != "" {
+		return o.PriceKPI
+	}
+	if o.Sizing != nil {
+		return o.Sizing.PriceKPI
+	}
+	return ""
+}
+
+// validateExits checks the take-profit/stop-loss configuration.
+func (o OrderParams) validateExits() error {
+	if o.PriceKPI != "" && !kpiNameRe.MatchString(strings.ReplaceAll(o.PriceKPI, SymbolPlaceholder, "X")) {
+		return fmt.Errorf("invalid order price_kpi %q", o.PriceKPI)
+	}
+	if !o.hasExits() {
+		return nil
+	}
+	if o.referencePriceKPI() == "" {
+		return fmt.Errorf("order.take_profit/stop_loss need order.price_kpi (or sizing.price_kpi) as the reference price")
+	}
+	if o.TakeProfit != nil {
+		if err := o.TakeProfit.validate("take_profit"); err != nil {
+			return err
+		}
+		if o.TakeProfit.LimitPct > 0 {
+			return fmt.Errorf("order.take_profit: limit_pct only applies to stop_loss")
+		}
+	}
+	if o.StopLoss != nil {
+		if err := o.StopLoss.validate("stop_loss"); err != nil {
+			return err
+		}
+	}
+	tif := strings.ToLower(o.TIF)
+	if o.TakeProfit != nil && o.StopLoss != nil && tif != "" && tif != "day" && tif != "gtc" {
+		return fmt.Errorf("bracket orders require tif day or gtc")
+	}
+	return nil
+}
+
+// attachExits sets the order class and exit legs on req, priced from the
+// snapshot's reference price for symbol. Legs sit above/below the reference
+// according to the entry side.
+func (o OrderParams) attachExits(req *broker.OrderRequest, symbol string, snap *Snapshot) error {
+	if !o.hasExits() {
+		return nil
+	}
+	kpi := strings.ReplaceAll(o.referencePriceKPI(), SymbolPlaceholder, symbol)
+	ref, ok := snap.KPIs[kpi]
+	if !ok || ref <= 0 {
+		return fmt.Errorf("reference price KPI %q missing from snapshot", kpi)
+	}
+
+	// dir is +1 when profits come from a rising price (long entries).
+	dir := 1.0
+	if strings.EqualFold(req.Side, "sell") {
+		dir = -1
+	}
+
+	if o.TakeProfit != nil {
+		req.TakeProfit = &broker.TakeProfit{LimitPrice: roundCents(ref + dir*o.TakeProfit.distance(ref))}
+	}
+	if o.StopLoss != nil {
+		stop := roundCents(ref - dir*o.StopLoss.distance(ref))
+		if stop <= 0 {
+			return fmt.Errorf("stop-loss price %.2f is not positive", stop)
+		}
+		req.StopLoss = &broker.StopLoss{StopPrice: stop}
+		if o.StopLoss.LimitPct > 0 {
+			req.StopLoss.LimitPrice = roundCents(stop * (1 - dir*o.StopLoss.LimitPct/100))
+		}
+	}
+
+	req.OrderClass = broker.OrderClassOTO
+	if req.TakeProfit != nil && req.StopLoss != nil {
+		req.OrderClass = broker.OrderClassBracket
+	}
+	return nil
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,108 @@
+package signal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/haiphen/haiphen-cli/internal/broker"
+)
+
+func TestEngine_BracketOrder(t *testing.T) {
+	tests := []struct {
+		side   string
+		wantTP float64
+		wantSL float64
+	}{
+		{"buy", 104, 98},
+		{"sell", 96, 102},
+	}
+	for _, tt := range tests {
+		b := &mockBroker{}
+		engine := NewEngine(b, DefaultEngineConfig(), make(chan Event, 16))
+		engine.SetRules([]*Rule{{
+			RuleID:  "bracket",
+			Name:    "bracket",
+			Status:  "active",
+			Symbols: []string{"AAPL"},
+			Entry:   &ConditionGroup{AllOf: []ConditionOrGroup{{KPI: "Go", Operator: ">", Value: 0}}},
+			Order: OrderParams{Side: tt.side, Type: "market", Qty: 1, TIF: "gtc",
+				PriceKPI:   "{symbol}.price",
+				TakeProfit: &PriceOffset{Pct: 4},
+				StopLoss:   &PriceOffset{Offset: 2}},
+			Cooldown: 60,
+		}})
+
+		engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"Go": 1, "AAPL.price": 100}})
+		if len(b.orders) != 1 {
+			t.Fatalf("%s: orders = %+v", tt.side, b.orders)
+		}
+		req := b.orders[0]
+		if req.OrderClass != broker.OrderClassBracket || req.TakeProfit == nil || req.StopLoss == nil {
+			t.Fatalf("%s: expected bracket order, got %+v", tt.side, req)
+		}
+		if req.TakeProfit.LimitPrice != tt.wantTP || req.StopLoss.StopPrice != tt.wantSL {
+			t.Errorf("%s: tp/sl = %v/%v, want %v/%v", tt.side,
+				req.TakeProfit.LimitPrice, req.StopLoss.StopPrice, tt.wantTP, tt.wantSL)
+		}
+	}
+}
+
+func TestEngine_BracketMissingReferencePrice(t *testing.T) {
+	b := &mockBroker{}
+	events := make(chan Event, 16)
+	engine := NewEngine(b, DefaultEngineConfig(), events)
+	engine.SetRules([]*Rule{{
+		RuleID:   "oto",
+		Name:     "oto",
+		Status:   "active",
+		Entry:    &ConditionGroup{AllOf: []ConditionOrGroup{{KPI: "Go", Operator: ">", Value: 0}}},
+		Order:    OrderParams{Side: "buy", Type: "market", Qty: 1, TIF: "day", PriceKPI: "P", StopLoss: &PriceOffset{Pct: 1}},
+		Cooldown: 60,
+	}})
+
+	engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"Go": 1}})
+	if len(b.orders) != 0 {
+		t.Fatalf("expected no order, got %+v", b.orders)
+	}
+	evs := drainEvents(events)
+	if len(evs) != 2 || evs[1].EventType != "order_failed" {
+		t.Fatalf("events = %+v", evs)
+	}
+}
+
+func TestValidateRule_Brackets(t *testing.T) {
+	base := func() *Rule {
+		return &Rule{
+			Name:     "test",
+			Entry:    &ConditionGroup{AllOf: []ConditionOrGroup{{KPI: "X", Operator: ">", Value: 5}}},
+			Order:    OrderParams{Side: "buy", Type: "market", Qty: 1, TIF: "day", PriceKPI: "X"},
+			Cooldown: 60,
+		}
+	}
+
+	r := base()
+	r.Order.TakeProfit = &PriceOffset{Pct: 5}
+	r.Order.StopLoss = &PriceOffset{Offset: 1, LimitPct: 0.5}
+	if err := ValidateRule(r, 1000); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	bad := map[string]func(*Rule){
+		"no reference price": func(r *Rule) { r.Order.PriceKPI = ""; r.Order.StopLoss = &PriceOffset{Pct: 1} },
+		"offset and pct":     func(r *Rule) { r.Order.StopLoss = &PriceOffset{Pct: 1, Offset: 1} },
+		"empty offset":       func(r *Rule) { r.Order.TakeProfit = &PriceOffset{} },
+		"tp limit pct":       func(r *Rule) { r.Order.TakeProfit = &PriceOffset{Pct: 1, LimitPct: 1} },
+		"bracket ioc": func(r *Rule) {
+			r.Order.TIF = "ioc"
+			r.Order.TakeProfit = &PriceOffset{Pct: 1}
+			r.Order.StopLoss = &PriceOffset{Pct: 1}
+		},
+	}
+	for name, mutate := range bad {
+		r := base()
+		mutate(r)
+		if err := ValidateRule(r, 1000); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package signal
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/haiphen/haiphen-cli/internal/broker"
+)
+
+// BreakerConfig sets when the engine's circuit breaker trips. A tripped
+// engine places no orders (entries, exits or copy-trades) until it is
+// resumed explicitly. Zero disables a condition.
+type BreakerConfig struct {
+	// MaxConsecutiveFailures trips after this many broker order failures
+	// (submission errors or rejections) with no successful order between.
+	MaxConsecutiveFailures int
+	// MaxSafetyBlocks trips after this many orders blocked by safety checks
+	// (order limits, daily loss, sizing) within SafetyBlockWindow.
+	MaxSafetyBlocks   int
+	SafetyBlockWindow time.Duration
+	// MaxDrawdownPct trips when account equity falls this percent below its
+	// high of the trading day. Equity is polled at most once a minute.
+	MaxDrawdownPct float64
+	// CancelOpenOrders cancels every open order at the broker on tripping.
+	CancelOpenOrders bool
+}
+
+// DefaultBreakerConfig trips on 5 consecutive order failures or 20 safety
+// blocks in 10 minutes; drawdown is off.
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		MaxConsecutiveFailures: 5,
+		MaxSafetyBlocks:        20,
+		SafetyBlockWindow:      10 * time.Minute,
+	}
+}
+
+// drawdownPollInterval limits account polling for the drawdown check.
+const drawdownPollInterval = time.Minute
+
+// BreakerState is the circuit breaker's state, checkpointed with the engine.
+type BreakerState struct {
+	Open                bool        `json:"open"`
+	Reason              string      `json:"reason,omitempty"`
+	OpenedAt            time.Time   `json:"opened_at,omitempty"`
+	ConsecutiveFailures int         `json:"consecutive_failures,omitempty"`
+	SafetyBlocks        []time.Time `json:"safety_blocks,omitempty"`
+	PeakEquity          float64     `json:"peak_equity,omitempty"`
+	PeakDate            string      `json:"peak_date,omitempty"` // exchange date of PeakEquity
+
+	lastEquityCheck time.Time
+}
+
+// halted reports whether the breaker is open. Callers hold e.mu.
+func (e *Engine) halted() bool {
+	return e.breaker.Open
+}
+
+// recordOrderResult counts a broker order failure, or resets the count on
+// success. Callers hold e.mu.
+func (e *Engine) recordOrderResult(ctx context.Context, failed bool, now time.Time) {
+	if !failed {
+		e.breaker.ConsecutiveFailures = 0
+		return
+	}
+	e.breaker.ConsecutiveFailures++
+	if max := e.config.Breaker.MaxConsecutiveFailures; max > 0 && e.breaker.ConsecutiveFailures >= max {
+		e.tripBreaker(ctx, fmt.Sprintf("%d consecutive order failures", e.breaker.ConsecutiveFailures), now)
+	}
+}
+
+// recordSafetyBlock counts an order blocked by a safety check. Callers hold
+// e.mu.
+func (e *Engine) recordSafetyBlock(ctx context.Context, now time.Time) {
+	cfg := e.config.Breaker
+	if cfg.MaxSafetyBlocks <= 0 {
+		return
+	}
+	cutoff := now.Add(-cfg.SafetyBlockWindow)
+	recent := e.breaker.SafetyBlocks[:0]
+	for _, t := range e.breaker.SafetyBlocks {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	e.breaker.SafetyBlocks = append(recent, now)
+	if len(e.breaker.SafetyBlocks) >= cfg.MaxSafetyBlocks {
+		e.tripBreaker(ctx, fmt.Sprintf("%d safety blocks within %s", len(e.breaker.SafetyBlocks), cfg.SafetyBlockWindow), now)
+	}
+}
+
+// checkDrawdown polls account equity and trips on a drawdown from the day's
+// high. Callers hold e.mu.
+func (e *Engine) checkDrawdown(ctx context.Context, now time.Time) {
+	maxPct := e.config.Breaker.MaxDrawdownPct
+	if maxPct <= 0 || e.broker == nil || e.breaker.Open || now.Sub(e.breaker.lastEquityCheck) < drawdownPollInterval {
+		return
+	}
+	e.breaker.lastEquityCheck = now
+	acct, err := e.broker.GetAccount(ctx)
+	if err != nil || acct.Equity <= 0 {
+		return
+	}
+
+	day := broker.TradingDate(now)
+	if e.breaker.PeakDate != day || acct.Equity > e.breaker.PeakEquity {
+		e.breaker.PeakEquity, e.breaker.PeakDate = acct.Equity, day
+	}
+	drawdown := (e.breaker.PeakEquity - acct.Equity) / e.breaker.PeakEquity * 100
+	if drawdown >= maxPct {
+		e.tripBreaker(ctx, fmt.Sprintf("equity $%.2f is %.1f%% below today's high of $%.2f", acct.Equity, drawdown, e.breaker.PeakEquity), now)
+	}
+}
+
+// tripBreaker halts the engine. Callers hold e.mu.
+func (e *Engine) tripBreaker(ctx context.Context, reason string, now time.Time) {
+	if e.breaker.Open {
+		return
+	}
+	e.breaker.Open, e.breaker.Reason, e.breaker.OpenedAt = true, reason, now
+	e.metrics.set(metricBreakerOpen, 1)
+	log.Printf("[engine] CIRCUIT BREAKER OPEN: %s; no orders until resumed (haiphen signal resume)", reason)
+
+	e.emitEvent(Event{
+		EventID:   e.nextEventID(),
+		RuleID:    "engine",
+		EventType: "circuit_open",
+		Reason:    reason,
+		DaemonID:  e.config.DaemonID,
+		CreatedAt: now.UTC().Format(time.RFC3339),
+	})
+
+	if e.config.Breaker.CancelOpenOrders && e.broker != nil && !e.config.DryRun {
+		n, err := e.broker.CancelAllOrders(ctx)
+		if err != nil {
+			log.Printf("[engine] circuit breaker: cancel open orders failed: %v", err)
+		} else {
+			log.Printf("[engine] circuit breaker: canceled %d open orders", n)
+		}
+	}
+}
+
+// resetBreaker closes the breaker and clears its counters. Callers hold e.mu.
+func (e *Engine) resetBreaker(now time.Time) {
+	if e.breaker.Open {
+		log.Printf("[engine] circuit breaker closed after %s (was: %s)", now.Sub(e.breaker.OpenedAt).Round(time.Second), e.breaker.Reason)
+		e.emitEvent(Event{
+			EventID:   e.nextEventID(),
+			RuleID:    "engine",
+			EventType: "circuit_closed",
+			DaemonID:  e.config.DaemonID,
+			CreatedAt: now.UTC().Format(time.RFC3339),
+		})
+	}
+	e.metrics.set(metricBreakerOpen, 0)
+	peak, day := e.breaker.PeakEquity, e.breaker.PeakDate
+	e.breaker = BreakerState{PeakEquity: peak, PeakDate: day}
+}
@@ -0,0 +1,141 @@
+package signal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/haiphen/haiphen-cli/internal/broker"
+)
+
+// failingBroker rejects every order and counts cancel-all calls.
+type failingBroker struct {
+	mockBroker
+	attempts  int
+	cancelAll int
+	equity    float64
+}
+
+func (b *failingBroker) CreateOrder(context.Context, broker.OrderRequest) (*broker.Order, error) {
+	b.attempts++
+	return nil, errors.New("insufficient buying power")
+}
+
+func (b *failingBroker) CancelAllOrders(context.Context) (int, error) {
+	b.cancelAll++
+	return 2, nil
+}
+
+func (b *failingBroker) GetAccount(context.Context) (*broker.Account, error) {
+	return &broker.Account{Equity: b.equity, IsPaper: true}, nil
+}
+
+func TestBreaker_ConsecutiveFailures(t *testing.T) {
+	b := &failingBroker{}
+	events := make(chan Event, 64)
+	cfg := DefaultEngineConfig()
+	cfg.Breaker = BreakerConfig{MaxConsecutiveFailures: 3, CancelOpenOrders: true}
+	engine := NewEngine(b, cfg, events)
+	now := time.Date(2026, 2, 10, 15, 0, 0, 0, time.UTC)
+	engine.SetClock(func() time.Time { return now })
+	engine.SetRules([]*Rule{qualityRule()})
+
+	for i := 0; i < 6; i++ {
+		now = now.Add(2 * time.Minute)
+		engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"Go": 1}})
+	}
+	if b.attempts != 3 {
+		t.Fatalf("order attempts = %d, want 3 before halting", b.attempts)
+	}
+	if b.cancelAll != 1 {
+		t.Fatalf("cancel-all calls = %d, want 1", b.cancelAll)
+	}
+	opened := eventsOfType(drainEvents(events), "circuit_open")
+	if len(opened) != 1 || opened[0].Reason != "3 consecutive order failures" {
+		t.Fatalf("circuit_open events = %+v", opened)
+	}
+	if st := engine.Status(); !st.Breaker.Open {
+		t.Fatal("status does not report the open breaker")
+	}
+
+	// Only an explicit resume closes the breaker.
+	if err := engine.Resume(""); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(2 * time.Minute)
+	engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"Go": 1}})
+	if b.attempts != 4 {
+		t.Fatalf("order attempts after resume = %d, want 4", b.attempts)
+	}
+	if closed := eventsOfType(drainEvents(events), "circuit_closed"); len(closed) != 1 {
+		t.Fatalf("circuit_closed events = %+v", closed)
+	}
+}
+
+func TestBreaker_SafetyBlocksInWindow(t *testing.T) {
+	b := &mockBroker{}
+	cfg := DefaultEngineConfig()
+	cfg.Safety.MaxOrderQty = 1
+	cfg.Breaker = BreakerConfig{MaxSafetyBlocks: 2, SafetyBlockWindow: 5 * time.Minute}
+	engine := NewEngine(b, cfg, make(chan Event, 64))
+	now := time.Date(2026, 2, 10, 15, 0, 0, 0, time.UTC)
+	engine.SetClock(func() time.Time { return now })
+	r := qualityRule()
+	r.Order.Qty = 5 // over the limit: every trigger is safety-blocked
+	engine.SetRules([]*Rule{r})
+
+	step := func(d time.Duration) {
+		now = now.Add(d)
+		engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"Go": 1}})
+	}
+	step(0)
+	step(10 * time.Minute) // first block fell out of the window
+	if engine.Status().Breaker.Open {
+		t.Fatal("blocks outside the window tripped the breaker")
+	}
+	step(2 * time.Minute)
+	if !engine.Status().Breaker.Open {
+		t.Fatal("two blocks within the window did not trip the breaker")
+	}
+}
+
+func TestBreaker_Drawdown(t *testing.T) {
+	b := &failingBroker{equity: 100000}
+	cfg := DefaultEngineConfig()
+	cfg.Breaker = BreakerConfig{MaxDrawdownPct: 5}
+	engine := NewEngine(b, cfg, make(chan Event, 64))
+	now := time.Date(2026, 2, 10, 15, 0, 0, 0, time.UTC)
+	engine.SetClock(func() time.Time { return now })
+
+	step := func(equity float64) bool {
+		b.equity = equity
+		now = now.Add(2 * time.Minute)
+		engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{}})
+		return engine.Status().Breaker.Open
+	}
+	if step(100000) || step(104000) || step(99500) {
+		t.Fatal("tripped within the allowed drawdown")
+	}
+	if !step(98700) { // 5.1% below the 104000 high
+		t.Fatal("drawdown from the day's high did not trip the breaker")
+	}
+}
+
+func TestBreaker_SurvivesRestart(t *testing.T) {
+	path := t.TempDir() + "/state.json"
+	first := NewEngine(nil, DefaultEngineConfig(), nil)
+	first.SetStatePath(path)
+	first.mu.Lock()
+	first.tripBreaker(context.Background(), "test", first.now())
+	first.checkpoint()
+	first.mu.Unlock()
+
+	second := NewEngine(nil, DefaultEngineConfig(), nil)
+	if _, err := second.RestoreState(path); err != nil {
+		t.Fatal(err)
+	}
+	if st := second.Status(); !st.Breaker.Open || st.Breaker.Reason != "test" {
+		t.Fatalf("breaker after restart = %+v", st.Breaker)
+	}
+}
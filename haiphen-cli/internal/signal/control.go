@@ -0,0 +1,484 @@
+package signal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The control socket is a Unix-domain HTTP API served by the running daemon.
+// It exposes the engine's live state and lets the CLI pause rules or the
+// whole engine and toggle dry-run without a restart:
+//
+//	GET  /v1/status   ControlStatus
+//	GET  /v1/rules    loaded rules
+//	POST /v1/pause    {"rule": "<name or id>"}; no rule pauses the engine
+//	POST /v1/resume   {"rule": "<name or id>"}; no rule resumes the engine
+//	POST /v1/dry-run  {"enabled": true|false}
+//	POST /v1/positions/reconcile  ReconcileRequest; replies CopyReconciliation
+//
+// The other mutating endpoints reply with the updated ControlStatus.
+
+// ReconcileRequest asks the daemon to reconcile copy-trade positions against
+// the given upstream positions, which the CLI fetches with its own token.
+type ReconcileRequest struct {
+	Upstream []PositionEvent `json:"upstream"`
+	CopyReconcileOptions
+}
+
+// ControlStatus is the daemon state reported over the control socket.
+type ControlStatus struct {
+	PID       int          `json:"pid"`
+	Profile   string       `json:"profile"`
+	StartedAt time.Time    `json:"started_at"`
+	Engine    EngineStatus `json:"engine"`
+}
+
+// EngineStatus is a point-in-time view of the engine's safety state.
+type EngineStatus struct {
+	Paused              bool              `json:"paused"`
+	Breaker             BreakerState      `json:"breaker"`
+	DryRun              bool              `json:"dry_run"`
+	SessionOrders       int               `json:"session_orders"`
+	MaxOrdersPerSession int               `json:"max_orders_per_session"`
+	Rules               []RuleStatus      `json:"rules"`
+	TrackedPositions    map[string]string `json:"tracked_positions"`
+	CopyPremium         float64           `json:"copy_premium"` // premium deployed by open copies
+}
+
+// RuleStatus is one loaded rule and the live state of each of its targets.
+type RuleStatus struct {
+	RuleID  string         `json:"rule_id"`
+	Name    string         `json:"name"`
+	Status  string         `json:"status"`
+	Paused  bool           `json:"paused"`
+	Targets []TargetStatus `json:"targets"`
+}
+
+// TargetStatus is the cooldown, hourly trigger count and position state of
+// one rule+symbol.
+type TargetStatus struct {
+	Symbol           string        `json:"symbol,omitempty"`
+	CooldownUntil    *time.Time    `json:"cooldown_until,omitempty"`
+	TriggersLastHour int           `json:"triggers_last_hour"`
+	State            PositionState `json:"state,omitempty"`
+}
+
+// Status returns the engine's current state.
+func (e *Engine) Status() EngineStatus {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	now := e.now()
+
+	st := EngineStatus{
+		Paused:              e.paused,
+		Breaker:             e.breaker,
+		DryRun:              e.config.DryRun,
+		SessionOrders:       e.sessionOrders,
+		MaxOrdersPerSession: e.config.MaxOrdersPerSession,
+		TrackedPositions:    make(map[string]string, len(e.trackedPositions)),
+	}
+	for id, orderID := range e.trackedPositions {
+		st.TrackedPositions[id] = orderID
+	}
+	for _, a := range e.copyAllocs {
+		st.CopyPremium += a.Premium
+	}
+	st.Breaker.SafetyBlocks = append([]time.Time(nil), e.breaker.SafetyBlocks...)
+
+	byRule := make(map[*Rule]*RuleStatus, len(e.rules))
+	for _, r := range e.rules {
+		st.Rules = append(st.Rules, RuleStatus{
+			RuleID: r.RuleID,
+			Name:   r.Name,
+			Status: r.Status,
+			Paused: e.pausedRules[r.RuleID],
+		})
+	}
+	for i, r := range e.rules {
+		byRule[r] = &st.Rules[i]
+	}
+	for _, t := range e.targets {
+		ts := TargetStatus{Symbol: t.symbol}
+		if until, ok := e.cooldowns[t.key]; ok && now.Before(until) {
+			ts.CooldownUntil = &until
+		}
+		for _, at := range e.triggerCount[t.key] {
+			if now.Sub(at) < time.Hour {
+				ts.TriggersLastHour++
+			}
+		}
+		if rs, ok := e.ruleStates[t.key]; ok {
+			ts.State = rs.State
+		}
+		rs := byRule[t.rule]
+		rs.Targets = append(rs.Targets, ts)
+	}
+	return st
+}
+
+// Pause stops the engine (rule == "") or one rule, by name or ID, from
+// opening new positions. Exits, flattening and copy-trade closes still run
+// so that nothing already held is stranded.
+func (e *Engine) Pause(rule string) error {
+	return e.setPaused(rule, true)
+}
+
+// Resume undoes Pause for the engine (rule == "") or one rule. Resuming the
+// engine also closes a tripped circuit breaker.
+func (e *Engine) Resume(rule string) error {
+	return e.setPaused(rule, false)
+}
+
+func (e *Engine) setPaused(rule string, paused bool) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	defer e.checkpoint()
+
+	if rule == "" {
+		e.paused = paused
+		if !paused {
+			e.resetBreaker(e.now())
+		}
+		return nil
+	}
+	r := e.findRule(rule)
+	if r == nil {
+		return fmt.Errorf("rule %q is not loaded", rule)
+	}
+	if paused {
+		e.pausedRules[r.RuleID] = true
+	} else {
+		delete(e.pausedRules, r.RuleID)
+	}
+	return nil
+}
+
+// SetDryRun switches dry-run mode on or off. Switching it on survives
+// restarts like a pause does. Leaving dry-run needs a broker: a daemon
+// started with --dry-run never connected one.
+func (e *Engine) SetDryRun(on bool) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !on && e.broker == nil {
+		return fmt.Errorf("no broker connected; restart the daemon without --dry-run to place orders")
+	}
+	e.config.DryRun = on
+	e.dryRunSwitch = on
+	e.checkpoint()
+	return nil
+}
+
+// findRule returns the loaded rule with the given ID or name (case
+// insensitive). Callers hold e.mu.
+func (e *Engine) findRule(nameOrID string) *Rule {
+	for _, r := range e.rules {
+		if r.RuleID == nameOrID || strings.EqualFold(r.Name, nameOrID) {
+			return r
+		}
+	}
+	return nil
+}
+
+// SocketPath returns the control socket path for a profile.
+func SocketPath(profile string) (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "haiphen", fmt.Sprintf("signal.%s.sock", profile)), nil
+}
+
+// ServeControl serves the control API on path until ctx is done. A stale
+// socket left by a crashed daemon is replaced; the socket is only accessible
+// to the current user.
+func ServeControl(ctx context.Context, engine *Engine, path string, profile string) error {
+	os.Remove(path)
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		ln.Close()
+		return err
+	}
+
+	h := &controlHandler{engine: engine, profile: profile, started: time.Now().UTC()}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/status", h.handleStatus)
+	mux.HandleFunc("/v1/rules", h.handleRules)
+	mux.HandleFunc("/v1/pause", h.handlePause)
+	mux.HandleFunc("/v1/resume", h.handlePause)
+	mux.HandleFunc("/v1/dry-run", h.handleDryRun)
+	mux.HandleFunc("/v1/positions/reconcile", h.handleReconcile)
+
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+		os.Remove(path)
+	}()
+	err = srv.Serve(ln)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+type controlHandler struct {
+	engine  *Engine
+	profile string
+	started time.Time
+}
+
+func (h *controlHandler) status() ControlStatus {
+	return ControlStatus{
+		PID:       os.Getpid(),
+		Profile:   h.profile,
+		StartedAt: h.started,
+		Engine:    h.engine.Status(),
+	}
+}
+
+func (h *controlHandler) handleStatus(w http.ResponseWriter, r *http.Request) {
+	writeControlJSON(w, http.StatusOK, h.status())
+}
+
+func (h *controlHandler) handleRules(w http.ResponseWriter, r *http.Request) {
+	rules := h.engine.Rules()
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name < rules[j].Name })
+	writeControlJSON(w, http.StatusOK, rules)
+}
+
+func (h *controlHandler) handlePause(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeControlError(w, http.StatusMethodNotAllowed, "POST only")
+		return
+	}
+	var body struct {
+		Rule string `json:"rule"`
+	}
+	if err := decodeControlBody(r, &body); err != nil {
+		writeControlError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	action := "pause"
+	apply := h.engine.Pause
+	if r.URL.Path == "/v1/resume" {
+		action, apply = "resume", h.engine.Resume
+	}
+	if err := apply(body.Rule); err != nil {
+		writeControlError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	target := body.Rule
+	if target == "" {
+		target = "engine"
+	}
+	LogJSON("info", "control: "+action, map[string]interface{}{"target": target})
+	writeControlJSON(w, http.StatusOK, h.status())
+}
+
+func (h *controlHandler) handleDryRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeControlError(w, http.StatusMethodNotAllowed, "POST only")
+		return
+	}
+	var body struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := decodeControlBody(r, &body); err != nil {
+		writeControlError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if body.Enabled == nil {
+		writeControlError(w, http.StatusBadRequest, `"enabled" is required`)
+		return
+	}
+	if err := h.engine.SetDryRun(*body.Enabled); err != nil {
+		writeControlError(w, http.StatusConflict, err.Error())
+		return
+	}
+	LogJSON("info", "control: dry-run", map[string]interface{}{"enabled": *body.Enabled})
+	writeControlJSON(w, http.StatusOK, h.status())
+}
+
+func (h *controlHandler) handleReconcile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeControlError(w, http.StatusMethodNotAllowed, "POST only")
+		return
+	}
+	var body ReconcileRequest
+	if err := decodeControlBody(r, &body); err != nil {
+		writeControlError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	rec, err := h.engine.ReconcileCopyTrades(r.Context(), body.Upstream, body.CopyReconcileOptions)
+	if err != nil {
+		writeControlError(w, http.StatusConflict, err.Error())
+		return
+	}
+	LogJSON("info", "control: reconcile positions", map[string]interface{}{
+		"missed":        len(rec.Missed),
+		"orphaned":      len(rec.Orphaned),
+		"enter_missed":  body.EnterMissed,
+		"close_orphans": body.CloseOrphans,
+	})
+	writeControlJSON(w, http.StatusOK, rec)
+}
+
+// maxControlBody bounds request bodies; reconcile requests carry the
+// upstream position list.
+const maxControlBody = 4 << 20
+
+func decodeControlBody(r *http.Request, v interface{}) error {
+	err := json.NewDecoder(io.LimitReader(r.Body, maxControlBody)).Decode(v)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+func writeControlJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeControlError(w http.ResponseWriter, code int, msg string) {
+	writeControlJSON(w, code, map[string]string{"error": msg})
+}
+
+// ControlClient talks to a running daemon over its control socket.
+type ControlClient struct {
+	http *http.Client
+}
+
+// DialControl returns a client for the profile's daemon. It fails if the
+// daemon is not serving a control socket.
+func DialControl(profile string) (*ControlClient, error) {
+	path, err := SocketPath(profile)
+	if err != nil {
+		return nil, err
+	}
+	c, err := dialControlPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("daemon control socket unavailable (profile=%s): %w", profile, err)
+	}
+	return c, nil
+}
+
+func dialControlPath(path string) (*ControlClient, error) {
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err != nil {
+		return nil, err
+	}
+	conn.Close()
+
+	return &ControlClient{http: &http.Client{
+		// Long enough for a reconcile, which calls the broker.
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", path)
+			},
+		},
+	}}, nil
+}
+
+// Status returns the daemon's status.
+func (c *ControlClient) Status(ctx context.Context) (*ControlStatus, error) {
+	var st ControlStatus
+	if err := c.do(ctx, http.MethodGet, "/v1/status", nil, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// Rules returns the rules loaded in the daemon.
+func (c *ControlClient) Rules(ctx context.Context) ([]*Rule, error) {
+	var rules []*Rule
+	if err := c.do(ctx, http.MethodGet, "/v1/rules", nil, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Pause pauses one rule, or the whole engine when rule is "".
+func (c *ControlClient) Pause(ctx context.Context, rule string) (*ControlStatus, error) {
+	var st ControlStatus
+	if err := c.do(ctx, http.MethodPost, "/v1/pause", map[string]string{"rule": rule}, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// Resume resumes one rule, or the whole engine when rule is "".
+func (c *ControlClient) Resume(ctx context.Context, rule string) (*ControlStatus, error) {
+	var st ControlStatus
+	if err := c.do(ctx, http.MethodPost, "/v1/resume", map[string]string{"rule": rule}, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// SetDryRun toggles the daemon's dry-run mode.
+func (c *ControlClient) SetDryRun(ctx context.Context, on bool) (*ControlStatus, error) {
+	var st ControlStatus
+	if err := c.do(ctx, http.MethodPost, "/v1/dry-run", map[string]bool{"enabled": on}, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// ReconcileCopyTrades has the daemon reconcile copy-trade positions against
+// upstream and act on the differences according to opts.
+func (c *ControlClient) ReconcileCopyTrades(ctx context.Context, upstream []PositionEvent, opts CopyReconcileOptions) (*CopyReconciliation, error) {
+	var rec CopyReconciliation
+	req := ReconcileRequest{Upstream: upstream, CopyReconcileOptions: opts}
+	if err := c.do(ctx, http.MethodPost, "/v1/positions/reconcile", req, &rec); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+func (c *ControlClient) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	// The host is ignored: the transport always dials the socket.
+	req, err := newJSONRequest(method, "http://signal-daemon"+path, data)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var e struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&e) == nil && e.Error != "" {
+			return errors.New(e.Error)
+		}
+		return fmt.Errorf("control: HTTP %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,127 @@
+package signal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/haiphen/haiphen-cli/internal/broker"
+)
+
+func startControl(t *testing.T, engine *Engine) *ControlClient {
+	t.Helper()
+	// Unix socket paths are length-limited; keep this one short.
+	dir, err := os.MkdirTemp("", "ctl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "s.sock")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := ServeControl(ctx, engine, path, "test"); err != nil {
+			t.Errorf("ServeControl: %v", err)
+		}
+	}()
+	t.Cleanup(func() { cancel(); <-done })
+
+	for i := 0; i < 100; i++ {
+		if c, err := dialControlPath(path); err == nil {
+			return c
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("control socket never came up")
+	return nil
+}
+
+func TestControl_StatusAndPause(t *testing.T) {
+	b := &mockBroker{}
+	engine := NewEngine(b, DefaultEngineConfig(), make(chan Event, 16))
+	now := time.Date(2026, 2, 10, 15, 0, 0, 0, time.UTC)
+	engine.SetClock(func() time.Time { return now })
+	engine.SetRules([]*Rule{qualityRule()})
+	client := startControl(t, engine)
+	ctx := context.Background()
+
+	if _, err := client.Pause(ctx, "DQ"); err != nil {
+		t.Fatalf("Pause: %v", err)
+	}
+	engine.Evaluate(ctx, &Snapshot{KPIs: map[string]float64{"Go": 1}})
+	if len(b.orders) != 0 {
+		t.Fatalf("paused rule placed %d orders", len(b.orders))
+	}
+
+	if _, err := client.Resume(ctx, "dq"); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	engine.Evaluate(ctx, &Snapshot{KPIs: map[string]float64{"Go": 1}})
+	if len(b.orders) != 1 {
+		t.Fatalf("resumed rule orders = %d, want 1", len(b.orders))
+	}
+
+	st, err := client.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Engine.SessionOrders != 1 || len(st.Engine.Rules) != 1 {
+		t.Fatalf("status = %+v", st.Engine)
+	}
+	tgt := st.Engine.Rules[0].Targets
+	if len(tgt) != 1 || tgt[0].Symbol != "SPY" || tgt[0].TriggersLastHour != 1 || tgt[0].CooldownUntil == nil {
+		t.Fatalf("targets = %+v", tgt)
+	}
+
+	if _, err := client.Pause(ctx, "nope"); err == nil || !strings.Contains(err.Error(), "not loaded") {
+		t.Fatalf("unknown rule err = %v", err)
+	}
+}
+
+func TestControl_EnginePauseAndDryRun(t *testing.T) {
+	engine := NewEngine(nil, DefaultEngineConfig(), make(chan Event, 16))
+	engine.SetRules([]*Rule{qualityRule()})
+	client := startControl(t, engine)
+	ctx := context.Background()
+
+	st, err := client.Pause(ctx, "")
+	if err != nil || !st.Engine.Paused {
+		t.Fatalf("engine pause: %+v, %v", st, err)
+	}
+	st, err = client.SetDryRun(ctx, true)
+	if err != nil || !st.Engine.DryRun {
+		t.Fatalf("dry-run on: %+v, %v", st, err)
+	}
+	// Without a broker the daemon cannot leave dry-run.
+	if _, err := client.SetDryRun(ctx, false); err == nil {
+		t.Fatal("expected dry-run off to be refused without a broker")
+	}
+
+	rules, err := client.Rules(ctx)
+	if err != nil || len(rules) != 1 || rules[0].Name != "dq" {
+		t.Fatalf("rules = %v, %v", rules, err)
+	}
+}
+
+func TestControl_ReconcileCopyTrades(t *testing.T) {
+	b := &holdingsBroker{positions: []broker.Position{{Symbol: "AAPL260220C00230000", Qty: 1}}}
+	engine := newCopyEngine(b, nil)
+	client := startControl(t, engine)
+
+	upstream := []PositionEvent{copyPosition("1_1", "AAPL260220C00230000")}
+	rec, err := client.ReconcileCopyTrades(context.Background(), upstream, CopyReconcileOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rec.Clean() || len(rec.Matched) != 1 {
+		t.Fatalf("reconciliation = %+v, want one match", rec)
+	}
+	if _, ok := engine.TrackedPositions()["1_1"]; !ok {
+		t.Error("daemon engine should track 1_1 after reconciling")
+	}
+}
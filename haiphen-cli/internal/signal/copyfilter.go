@@ -0,0 +1,164 @@
+package signal
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/haiphen/haiphen-cli/internal/broker"
+)
+
+// matchContract checks the contract and market criteria of the filter: entry
+// condition, days to expiration, IV, moneyness and liquidity. A position
+// missing the data a criterion needs does not match it.
+func (f *PositionFilter) matchContract(p PositionEvent, now time.Time) bool {
+	if len(f.EntryConditions) > 0 && !containsFold(f.EntryConditions, p.EntryCondition) {
+		return false
+	}
+	if containsFold(f.ExcludeEntryConditions, p.EntryCondition) {
+		return false
+	}
+
+	if f.MinDTE > 0 || f.MaxDTE != nil {
+		dte, ok := p.DTE(now)
+		if !ok || dte < f.MinDTE || (f.MaxDTE != nil && dte > *f.MaxDTE) {
+			return false
+		}
+	}
+
+	if (f.MinIV > 0 || f.MaxIV > 0) && p.IV <= 0 {
+		return false
+	}
+	if f.MinIV > 0 && p.IV < f.MinIV {
+		return false
+	}
+	if f.MaxIV > 0 && p.IV > f.MaxIV {
+		return false
+	}
+
+	if f.MinMoneyness != nil || f.MaxMoneyness != nil {
+		m, ok := p.Moneyness()
+		if !ok || (f.MinMoneyness != nil && m < *f.MinMoneyness) || (f.MaxMoneyness != nil && m > *f.MaxMoneyness) {
+			return false
+		}
+	}
+
+	if f.MinBid > 0 && p.BidPrice < f.MinBid {
+		return false
+	}
+	if f.MaxSpread > 0 || f.MaxSpreadPct > 0 {
+		if p.BidPrice <= 0 || p.AskPrice < p.BidPrice {
+			return false
+		}
+		spread := p.AskPrice - p.BidPrice
+		if f.MaxSpread > 0 && spread > f.MaxSpread+qtyEpsilon {
+			return false
+		}
+		if f.MaxSpreadPct > 0 && spread/((p.AskPrice+p.BidPrice)/2) > f.MaxSpreadPct {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validateContract checks the contract, market and concurrency criteria.
+func (f *PositionFilter) validateContract() error {
+	if f.MinDTE < 0 || (f.MaxDTE != nil && *f.MaxDTE < 0) {
+		return fmt.Errorf("position filter: min_dte and max_dte must not be negative")
+	}
+	if f.MaxDTE != nil && f.MinDTE > *f.MaxDTE {
+		return fmt.Errorf("position filter: min_dte %d is above max_dte %d", f.MinDTE, *f.MaxDTE)
+	}
+	if f.MinIV < 0 || f.MaxIV < 0 {
+		return fmt.Errorf("position filter: min_iv and max_iv must not be negative")
+	}
+	if f.MaxIV > 0 && f.MinIV > f.MaxIV {
+		return fmt.Errorf("position filter: min_iv %g is above max_iv %g", f.MinIV, f.MaxIV)
+	}
+	if f.MinMoneyness != nil && f.MaxMoneyness != nil && *f.MinMoneyness > *f.MaxMoneyness {
+		return fmt.Errorf("position filter: min_moneyness %g is above max_moneyness %g", *f.MinMoneyness, *f.MaxMoneyness)
+	}
+	if f.MinBid < 0 || f.MaxSpread < 0 || f.MaxSpreadPct < 0 {
+		return fmt.Errorf("position filter: min_bid, max_spread and max_spread_pct must not be negative")
+	}
+	if f.MaxPositions < 0 || f.MaxPositionsPerUnderlying < 0 {
+		return fmt.Errorf("position filter: max_positions and max_positions_per_underlying must not be negative")
+	}
+	return nil
+}
+
+// DTE returns the calendar days from the exchange date of now to the
+// position's expiration, and false when the expiration is unknown.
+func (p *PositionEvent) DTE(now time.Time) (int, bool) {
+	exp, ok := p.expiration()
+	if !ok {
+		return 0, false
+	}
+	today, _ := time.Parse("2006-01-02", broker.TradingDate(now))
+	return int(exp.Sub(today).Hours() / 24), true
+}
+
+// expiration returns the expiration date at midnight UTC, from
+// ExpirationDate (a date or an RFC 3339 timestamp) or else the OCC symbol.
+func (p *PositionEvent) expiration() (time.Time, bool) {
+	if exp, err := time.Parse("2006-01-02", p.ExpirationDate); err == nil {
+		return exp, true
+	}
+	if t, err := time.Parse(time.RFC3339, p.ExpirationDate); err == nil {
+		exp, _ := time.Parse("2006-01-02", broker.TradingDate(t))
+		return exp, true
+	}
+	if c, err := broker.ParseOCC(p.ContractName); err == nil {
+		return c.Expiration, true
+	}
+	return time.Time{}, false
+}
+
+// Moneyness returns how far in the money the option is, as a fraction of
+// spot: (spot - strike) / spot for calls and the negation for puts. Positive
+// is in the money, negative out of the money. It is false when the position
+// is not an option or lacks a strike or spot price.
+func (p *PositionEvent) Moneyness() (float64, bool) {
+	if p.StrikePrice <= 0 || p.SpotPrice <= 0 {
+		return 0, false
+	}
+	m := (p.SpotPrice - p.StrikePrice) / p.SpotPrice
+	switch strings.ToLower(p.OptionType) {
+	case "call":
+		return m, true
+	case "put":
+		return -m, true
+	}
+	return 0, false
+}
+
+// concurrencyBlock returns why a new copy of p would exceed the filter's
+// caps on open copied positions, or "" if it fits. Callers hold e.mu.
+func (e *Engine) concurrencyBlock(p PositionEvent) string {
+	f := e.posFilter
+	if f.MaxPositions > 0 && len(e.trackedPositions) >= f.MaxPositions {
+		return fmt.Sprintf("copy-trade position cap reached: %d open", len(e.trackedPositions))
+	}
+	if f.MaxPositionsPerUnderlying > 0 {
+		n := 0
+		for _, a := range e.copyAllocs {
+			if strings.EqualFold(a.Underlying, p.Underlying) {
+				n++
+			}
+		}
+		if n >= f.MaxPositionsPerUnderlying {
+			return fmt.Sprintf("copy-trade position cap reached: %d open on %s", n, p.Underlying)
+		}
+	}
+	return ""
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package signal
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPositionFilter_ContractCriteria(t *testing.T) {
+	// 10:00 New York on Friday 2026-02-13.
+	now := time.Date(2026, 2, 13, 15, 0, 0, 0, time.UTC)
+	zero, seven := 0, 7
+	atm, itm, otm := 0.0, 0.02, -0.05
+
+	base := PositionEvent{
+		Underlying:     "AAPL",
+		OptionType:     "call",
+		StrikePrice:    230,
+		SpotPrice:      225, // 2.2% out of the money
+		ExpirationDate: "2026-02-20",
+		EntryCondition: "breakout",
+		IV:             0.32,
+		BidPrice:       2.40,
+		AskPrice:       2.50,
+	}
+	with := func(mod func(*PositionEvent)) PositionEvent {
+		p := base
+		mod(&p)
+		return p
+	}
+
+	tests := []struct {
+		name string
+		f    PositionFilter
+		ev   PositionEvent
+		want bool
+	}{
+		{"no criteria", PositionFilter{}, base, true},
+		{"entry condition allowed", PositionFilter{EntryConditions: []string{"Breakout"}}, base, true},
+		{"entry condition not allowed", PositionFilter{EntryConditions: []string{"mean_reversion"}}, base, false},
+		{"entry condition excluded", PositionFilter{ExcludeEntryConditions: []string{"breakout"}}, base, false},
+		{"dte in range", PositionFilter{MinDTE: 5, MaxDTE: &seven}, base, true},
+		{"dte too short", PositionFilter{MinDTE: 10}, base, false},
+		{"dte too long", PositionFilter{MaxDTE: &zero}, base, false},
+		{"0dte", PositionFilter{MaxDTE: &zero}, with(func(p *PositionEvent) { p.ExpirationDate = "2026-02-13" }), true},
+		{"dte from timestamp", PositionFilter{MaxDTE: &seven}, with(func(p *PositionEvent) { p.ExpirationDate = "2026-02-20T21:00:00Z" }), true},
+		{"dte unknown", PositionFilter{MinDTE: 1}, with(func(p *PositionEvent) { p.ExpirationDate = "" }), false},
+		{"iv in range", PositionFilter{MinIV: 0.2, MaxIV: 0.4}, base, true},
+		{"iv too high", PositionFilter{MaxIV: 0.3}, base, false},
+		{"iv unknown", PositionFilter{MaxIV: 0.5}, with(func(p *PositionEvent) { p.IV = 0 }), false},
+		{"otm within bound", PositionFilter{MinMoneyness: &otm}, base, true},
+		{"itm only", PositionFilter{MinMoneyness: &atm}, base, false},
+		{"put itm", PositionFilter{MinMoneyness: &itm}, with(func(p *PositionEvent) { p.OptionType = "put" }), true},
+		{"moneyness unknown", PositionFilter{MaxMoneyness: &itm}, with(func(p *PositionEvent) { p.SpotPrice = 0 }), false},
+		{"min bid", PositionFilter{MinBid: 2.50}, base, false},
+		{"spread dollars", PositionFilter{MaxSpread: 0.10}, base, true},
+		{"spread pct", PositionFilter{MaxSpreadPct: 0.03}, base, false},
+		{"spread without quote", PositionFilter{MaxSpread: 0.10}, with(func(p *PositionEvent) { p.BidPrice = 0 }), false},
+	}
+	for _, tt := range tests {
+		tt.f.Enabled = true
+		if got := tt.f.MatchAt(tt.ev, now); got != tt.want {
+			t.Errorf("%s: MatchAt() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPositionFilter_ValidateContract(t *testing.T) {
+	three, lo, hi := 3, -0.1, 0.1
+	bad := []PositionFilter{
+		{MinDTE: 5, MaxDTE: &three},
+		{MinIV: 0.5, MaxIV: 0.3},
+		{MinMoneyness: &hi, MaxMoneyness: &lo},
+		{MaxSpreadPct: -0.01},
+		{MaxPositionsPerUnderlying: -1},
+	}
+	for _, f := range bad {
+		if err := f.Validate(); err == nil {
+			t.Errorf("expected %+v to be invalid", f)
+		}
+	}
+}
+
+func TestEngine_CopyConcurrencyCaps(t *testing.T) {
+	b := &mockBroker{}
+	events := make(chan Event, 32)
+	engine := NewEngine(b, DefaultEngineConfig(), events)
+	engine.SetPositionFilter(&PositionFilter{
+		Enabled:                   true,
+		ScaleFactor:               1,
+		MaxPositions:              2,
+		MaxPositionsPerUnderlying: 1,
+	})
+	ctx := context.Background()
+
+	engine.ProcessPositionEvents(ctx, []PositionEvent{
+		copyPosition("1", "AAPL260220C00230000"),
+		copyPosition("2", "AAPL260220C00235000"), // one AAPL copy already open
+		{ID: "3", Underlying: "MSFT", ContractName: "MSFT260220C00400000", TradeStatus: "active"},
+		{ID: "4", Underlying: "NVDA", ContractName: "NVDA260220C00180000", TradeStatus: "active"}, // two open
+	})
+	if len(b.orders) != 2 || b.orders[0].Symbol != "AAPL260220C00230000" || b.orders[1].Symbol != "MSFT260220C00400000" {
+		t.Fatalf("orders = %+v, want AAPL and MSFT entries", b.orders)
+	}
+	if failed := eventsOfType(drainEvents(events), "order_failed"); len(failed) != 2 {
+		t.Fatalf("order_failed = %+v, want 2 cap blocks", failed)
+	}
+}
+
+func TestEngine_TrackedCopyIgnoresEntryCriteria(t *testing.T) {
+	b := &mockBroker{}
+	engine := NewEngine(b, DefaultEngineConfig(), make(chan Event, 16))
+	engine.SetPositionFilter(&PositionFilter{Enabled: true, ScaleFactor: 1, MaxIV: 0.5})
+	ctx := context.Background()
+
+	pos := copyPosition("1", "AAPL260220C00230000")
+	pos.IV = 0.3
+	engine.ProcessPositionEvents(ctx, []PositionEvent{pos})
+
+	// IV spiked after entry; the close is still mirrored.
+	pos.IV, pos.TradeStatus = 0.9, "closing"
+	engine.ProcessPositionEvents(ctx, []PositionEvent{pos})
+	if len(b.orders) != 2 || b.orders[1].Side != "sell" {
+		t.Fatalf("orders = %+v, want entry and exit", b.orders)
+	}
+}
@@ -0,0 +1,151 @@
+package signal
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/haiphen/haiphen-cli/internal/broker"
+)
+
+// Copy-trade sizing modes for PositionFilter.Sizing.
+const (
+	CopySizeFixed        = "fixed"        // ScaleFactor contracts per trade (default)
+	CopySizeProportional = "proportional" // upstream quantity × ScaleFactor
+	CopySizeNotional     = "notional"     // Notional dollars of premium per trade
+)
+
+// CopyAllocation is what a copied position was entered with. Its premium
+// counts against the copy-trade budgets until the position is closed, and
+// its quantity is what the exit closes.
+type CopyAllocation struct {
+	Underlying string  `json:"underlying"`
+	Qty        float64 `json:"qty"`
+	Premium    float64 `json:"premium"` // qty × price × multiplier at entry
+}
+
+// Validate checks the sizing and budget settings, then the entry criteria.
+func (f *PositionFilter) Validate() error {
+	switch strings.ToLower(f.Sizing) {
+	case "", CopySizeFixed, CopySizeProportional:
+	case CopySizeNotional:
+		if f.Notional <= 0 {
+			return fmt.Errorf("position filter: notional sizing needs a positive notional")
+		}
+	default:
+		return fmt.Errorf("position filter: invalid sizing %q: must be one of: %s, %s, %s",
+			f.Sizing, CopySizeFixed, CopySizeProportional, CopySizeNotional)
+	}
+	if f.ScaleFactor < 0 || f.Notional < 0 || f.Multiplier < 0 || f.MaxQty < 0 {
+		return fmt.Errorf("position filter: scale_factor, notional, multiplier and max_qty must not be negative")
+	}
+	if f.UnderlyingBudget < 0 || f.TotalBudget < 0 {
+		return fmt.Errorf("position filter: budgets must not be negative")
+	}
+	return f.validateContract()
+}
+
+// multiplier is the contract multiplier for p: the configured one, or the
+// standard option multiplier for options and 1 for everything else.
+func (f *PositionFilter) multiplier(p PositionEvent) float64 {
+	if f.Multiplier > 0 {
+		return f.Multiplier
+	}
+	if p.isOption() {
+		return broker.OptionMultiplier
+	}
+	return 1
+}
+
+// price is the per-unit price a copy of p pays: the upstream entry premium,
+// or the last price when the premium is not reported.
+func (p *PositionEvent) price() float64 {
+	if p.EntryPremium > 0 {
+		return p.EntryPremium
+	}
+	return p.LastPrice
+}
+
+// entryQty is the quantity to copy p with, before budgets. Proportional and
+// notional sizing round down to whole contracts and may return 0.
+func (f *PositionFilter) entryQty(p PositionEvent) float64 {
+	scale := f.ScaleFactor
+	if scale <= 0 {
+		scale = 1
+	}
+
+	var qty float64
+	switch strings.ToLower(f.Sizing) {
+	case CopySizeProportional:
+		// Without an upstream quantity, treat the source as one contract.
+		src := p.Qty
+		if src <= 0 {
+			src = 1
+		}
+		qty = math.Floor(src*scale + qtyEpsilon)
+	case CopySizeNotional:
+		unit := p.price() * f.multiplier(p)
+		if unit <= 0 {
+			return 0
+		}
+		qty = math.Floor(f.Notional/unit + qtyEpsilon)
+	default:
+		qty = scale
+	}
+
+	if f.MaxQty > 0 && qty > float64(f.MaxQty) {
+		qty = float64(f.MaxQty)
+	}
+	return qty
+}
+
+// hasBudget reports whether copy-trade capital is capped.
+func (f *PositionFilter) hasBudget() bool {
+	return f.UnderlyingBudget > 0 || f.TotalBudget > 0
+}
+
+// budgetQty shrinks qty to what the remaining per-underlying and total
+// premium budgets allow. It returns 0 and a reason when nothing fits.
+// Callers hold e.mu.
+func (e *Engine) budgetQty(p PositionEvent, qty float64) (float64, string) {
+	f := e.posFilter
+	if !f.hasBudget() {
+		return qty, ""
+	}
+	unit := p.price() * f.multiplier(p)
+	if unit <= 0 {
+		return 0, "no entry premium or last price to budget against"
+	}
+
+	var total, underlying float64
+	for _, a := range e.copyAllocs {
+		total += a.Premium
+		if strings.EqualFold(a.Underlying, p.Underlying) {
+			underlying += a.Premium
+		}
+	}
+	remaining := math.Inf(1)
+	if f.TotalBudget > 0 {
+		remaining = f.TotalBudget - total
+	}
+	if f.UnderlyingBudget > 0 {
+		remaining = math.Min(remaining, f.UnderlyingBudget-underlying)
+	}
+
+	if fit := math.Floor(remaining/unit + qtyEpsilon); qty > fit {
+		qty = math.Max(fit, 0)
+	}
+	if qty <= 0 {
+		return 0, fmt.Sprintf("copy-trade budget exhausted: $%.2f deployed, %s $%.2f", total, p.Underlying, underlying)
+	}
+	return qty, ""
+}
+
+// allocate records the premium a copied position deployed. Callers hold e.mu.
+func (e *Engine) allocate(p PositionEvent, qty float64) {
+	e.copyAllocs[p.ID] = CopyAllocation{
+		Underlying: p.Underlying,
+		Qty:        qty,
+		Premium:    qty * p.price() * e.posFilter.multiplier(p),
+	}
+}
@@ -0,0 +1,103 @@
+package signal
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestPositionFilter_EntryQty(t *testing.T) {
+	call := PositionEvent{OptionType: "call", Qty: 10, EntryPremium: 2.50}
+
+	tests := []struct {
+		name string
+		f    PositionFilter
+		ev   PositionEvent
+		want float64
+	}{
+		{"fixed", PositionFilter{ScaleFactor: 2}, call, 2},
+		{"fixed capped", PositionFilter{ScaleFactor: 8, MaxQty: 5}, call, 5},
+		{"proportional", PositionFilter{Sizing: CopySizeProportional, ScaleFactor: 0.5}, call, 5},
+		{"proportional rounds down", PositionFilter{Sizing: CopySizeProportional, ScaleFactor: 0.25}, call, 2},
+		{"proportional without upstream qty", PositionFilter{Sizing: CopySizeProportional, ScaleFactor: 3}, PositionEvent{}, 3},
+		{"notional option", PositionFilter{Sizing: CopySizeNotional, Notional: 1000}, call, 4},
+		{"notional last price", PositionFilter{Sizing: CopySizeNotional, Notional: 1000}, PositionEvent{OptionType: "put", LastPrice: 4}, 2},
+		{"notional multiplier", PositionFilter{Sizing: CopySizeNotional, Notional: 1000, Multiplier: 1}, PositionEvent{LastPrice: 40}, 25},
+		{"notional without price", PositionFilter{Sizing: CopySizeNotional, Notional: 1000}, PositionEvent{OptionType: "call"}, 0},
+		{"notional too small", PositionFilter{Sizing: CopySizeNotional, Notional: 100}, call, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.f.entryQty(tt.ev); got != tt.want {
+			t.Errorf("%s: qty = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPositionFilter_Validate(t *testing.T) {
+	bad := []PositionFilter{
+		{Sizing: "kelly"},
+		{Sizing: CopySizeNotional},
+		{ScaleFactor: -1},
+		{TotalBudget: -100},
+	}
+	for _, f := range bad {
+		if err := f.Validate(); err == nil {
+			t.Errorf("expected %+v to be invalid", f)
+		}
+	}
+	ok := PositionFilter{Sizing: "Notional", Notional: 500, UnderlyingBudget: 2000, TotalBudget: 5000}
+	if err := ok.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestEngine_CopyBudget(t *testing.T) {
+	b := &mockBroker{}
+	events := make(chan Event, 32)
+	engine := NewEngine(b, DefaultEngineConfig(), events)
+	engine.SetPositionFilter(&PositionFilter{
+		Enabled:          true,
+		ScaleFactor:      2,
+		UnderlyingBudget: 600,
+		TotalBudget:      1000,
+	})
+	ctx := context.Background()
+
+	// Each copy is 2 contracts at $2.00 × 100 = $400 of premium.
+	pos := func(id, underlying string) PositionEvent {
+		return PositionEvent{
+			ID: id, Underlying: underlying, ContractName: underlying + "260220C00100000",
+			OptionType: "call", EntrySide: "buy", EntryPremium: 2, TradeStatus: "active",
+		}
+	}
+	engine.ProcessPositionEvents(ctx, []PositionEvent{
+		pos("1", "AAPL"), // $400
+		pos("2", "AAPL"), // $200 left for AAPL: shrinks to 1 contract
+		pos("3", "AAPL"), // AAPL budget spent
+		pos("4", "MSFT"), // $400 total left: 2 contracts
+		pos("5", "MSFT"), // total budget spent
+	})
+
+	var qtys []float64
+	for _, o := range b.orders {
+		qtys = append(qtys, o.Qty)
+	}
+	if len(qtys) != 3 || qtys[0] != 2 || qtys[1] != 1 || qtys[2] != 2 {
+		t.Fatalf("order qtys = %v, want [2 1 2]", qtys)
+	}
+	failed := eventsOfType(drainEvents(events), "order_failed")
+	if len(failed) != 2 || !strings.Contains(failed[0].Reason, "budget") {
+		t.Fatalf("order_failed = %+v, want 2 budget blocks", failed)
+	}
+	if got := engine.Status().CopyPremium; got != 1000 {
+		t.Errorf("deployed premium = %v, want 1000", got)
+	}
+
+	// Closing exits what was entered and frees the budget.
+	closing := pos("2", "AAPL")
+	closing.TradeStatus = "closing"
+	engine.ProcessPositionEvents(ctx, []PositionEvent{closing, pos("6", "AAPL")})
+	if n := len(b.orders); n != 5 || b.orders[3].Qty != 1 || b.orders[4].Qty != 1 {
+		t.Fatalf("orders = %+v, want a 1-lot exit and a 1-lot entry", b.orders[3:])
+	}
+}
@@ -0,0 +1,349 @@
+package signal
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/haiphen/haiphen-cli/internal/broker"
+)
+
+// Copy-trade reconciliation. Position events sent while the daemon is
+// disconnected are lost, so it can miss an upstream entry it should have
+// copied, or a close it should have mirrored, and a discarded state file
+// forgets which broker positions copy-trade opened. ReconcileCopyTrades
+// compares the upstream active positions with what the broker holds and
+// rebuilds the position_id → order mapping that closing events rely on.
+
+// reconciledOrderID marks a tracked position adopted from the broker's
+// positions during reconciliation, whose entry order is not known.
+const reconciledOrderID = "reconciled"
+
+// maxOpenOrders bounds the open orders fetched for reconciliation.
+const maxOpenOrders = 500
+
+// qtyEpsilon absorbs float error when matching fractional quantities.
+const qtyEpsilon = 1e-9
+
+// CopyMatch is an upstream position the broker holds, or has an open entry
+// order for.
+type CopyMatch struct {
+	PositionID string  `json:"position_id"`
+	Symbol     string  `json:"symbol"`
+	OrderID    string  `json:"order_id"`
+	Qty        float64 `json:"qty"`
+	Pending    bool    `json:"pending,omitempty"` // entry order still open
+}
+
+// CopyReconciliation is the difference between the positions copy-trade
+// should hold (upstream active positions passing the filter) and what the
+// broker holds.
+type CopyReconciliation struct {
+	Matched []CopyMatch `json:"matched"`
+	// Missed are upstream positions with no broker position or open order.
+	Missed []PositionEvent `json:"missed"`
+	// Orphaned is broker quantity no upstream position or rule accounts for,
+	// typically a copied position whose upstream close was missed.
+	Orphaned []broker.Position `json:"orphaned"`
+	// Dropped are tracked position IDs no longer active upstream.
+	Dropped []string `json:"dropped,omitempty"`
+	// Partial is set when the upstream list was incomplete, so nothing was
+	// dropped or flagged as orphaned.
+	Partial bool `json:"partial,omitempty"`
+
+	// Actions taken with CopyReconcileOptions.
+	Entered []string `json:"entered,omitempty"` // missed position IDs copied now
+	Closed  []string `json:"closed,omitempty"`  // orders closing orphaned positions
+	Errors  []string `json:"errors,omitempty"`
+}
+
+// Clean reports whether upstream and the broker agree.
+func (r *CopyReconciliation) Clean() bool {
+	return len(r.Missed) == 0 && len(r.Orphaned) == 0
+}
+
+// CopyReconcileOptions selects how ReconcileCopyTrades acts on the
+// differences. By default it only rebuilds the mapping and flags them.
+type CopyReconcileOptions struct {
+	EnterMissed  bool `json:"enter_missed,omitempty"`  // copy missed positions now
+	CloseOrphans bool `json:"close_orphans,omitempty"` // close orphaned broker positions
+
+	// Partial marks the upstream list as possibly incomplete (see
+	// FetchActivePositions). A position missing from it may still be
+	// active, so tracked positions are kept and no holdings are orphaned.
+	Partial bool `json:"partial,omitempty"`
+}
+
+// ReconcileCopyTrades compares upstream active positions with the broker's
+// positions and open orders. Positions the broker holds are tracked again,
+// tracked positions that are no longer active upstream are dropped, and
+// missed or orphaned positions are reported as copy_missed and copy_orphaned
+// events and acted on according to opts.
+func (e *Engine) ReconcileCopyTrades(ctx context.Context, upstream []PositionEvent, opts CopyReconcileOptions) (*CopyReconciliation, error) {
+	rec, err := e.reconcileCopyTrades(ctx, upstream, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.EnterMissed && len(rec.Missed) > 0 {
+		// Entries go through the normal copy path and its safety checks.
+		e.ProcessPositionEvents(ctx, rec.Missed)
+		tracked := e.TrackedPositions()
+		for _, ev := range rec.Missed {
+			if _, ok := tracked[ev.ID]; ok {
+				rec.Entered = append(rec.Entered, ev.ID)
+			} else {
+				rec.Errors = append(rec.Errors, fmt.Sprintf("position %s: entry not placed (see daemon log)", ev.ID))
+			}
+		}
+	}
+	return rec, nil
+}
+
+// CopyTradeLive reports whether copy-trade places real orders: it is enabled,
+// not in dry-run and has a broker to reconcile against.
+func (e *Engine) CopyTradeLive() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.broker != nil && !e.config.DryRun && e.posFilter.Enabled
+}
+
+func (e *Engine) reconcileCopyTrades(ctx context.Context, upstream []PositionEvent, opts CopyReconcileOptions) (*CopyReconciliation, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	defer e.checkpoint()
+
+	switch {
+	case e.broker == nil:
+		return nil, fmt.Errorf("no broker connected")
+	case e.config.DryRun:
+		return nil, fmt.Errorf("dry-run: copy-trade holds no broker positions to reconcile")
+	case !e.posFilter.Enabled:
+		return nil, fmt.Errorf("copy-trade is disabled (see: haiphen signal filter)")
+	}
+
+	positions, err := e.broker.GetPositions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get positions: %w", err)
+	}
+	open, err := e.broker.GetOrders(ctx, "open", maxOpenOrders)
+	if err != nil {
+		return nil, fmt.Errorf("get open orders: %w", err)
+	}
+
+	now := e.now()
+	rec := e.diffCopyPositions(upstream, positions, open, now)
+	if opts.Partial {
+		rec.Dropped, rec.Orphaned, rec.Partial = nil, nil, true
+	}
+
+	byID := make(map[string]PositionEvent, len(upstream))
+	for _, ev := range upstream {
+		byID[ev.ID] = ev
+	}
+	for _, m := range rec.Matched {
+		e.trackedPositions[m.PositionID] = m.OrderID
+		if _, ok := e.copyAllocs[m.PositionID]; !ok {
+			e.allocate(byID[m.PositionID], m.Qty)
+		}
+	}
+	for _, id := range rec.Dropped {
+		delete(e.trackedPositions, id)
+		delete(e.copyAllocs, id)
+	}
+	for _, ev := range rec.Missed {
+		// A tracked copy the broker no longer holds was rejected or closed
+		// outside the engine; untrack it so it can be entered again.
+		delete(e.trackedPositions, ev.ID)
+		delete(e.copyAllocs, ev.ID)
+		e.emitEvent(Event{
+			EventID:   e.nextEventID(),
+			RuleID:    "position:" + ev.ID,
+			EventType: "copy_missed",
+			Symbol:    ev.ContractName,
+			OrderSide: ev.EntrySide,
+			Reason:    "upstream position has no broker position or open order",
+			DaemonID:  e.config.DaemonID,
+			CreatedAt: now.UTC().Format(time.RFC3339),
+		})
+	}
+	for _, p := range rec.Orphaned {
+		e.emitEvent(Event{
+			EventID:   e.nextEventID(),
+			RuleID:    "copy-trade",
+			EventType: "copy_orphaned",
+			Symbol:    p.Symbol,
+			OrderQty:  math.Abs(p.Qty),
+			Reason:    "broker position not accounted for by any upstream position",
+			DaemonID:  e.config.DaemonID,
+			CreatedAt: now.UTC().Format(time.RFC3339),
+		})
+		if !opts.CloseOrphans {
+			continue
+		}
+		orderID, err := e.closeOrphan(ctx, p, now)
+		if err != nil {
+			rec.Errors = append(rec.Errors, fmt.Sprintf("close %s: %v", p.Symbol, err))
+			continue
+		}
+		rec.Closed = append(rec.Closed, orderID)
+	}
+
+	log.Printf("[engine] copy-trade reconciled: %d matched, %d missed, %d orphaned, %d dropped (partial=%v)",
+		len(rec.Matched), len(rec.Missed), len(rec.Orphaned), len(rec.Dropped), rec.Partial)
+	return rec, nil
+}
+
+// diffCopyPositions matches upstream active positions against broker
+// quantity and open orders, in position ID order. Quantity held by rule
+// lifecycles and orders placed by rules are left out. Callers hold e.mu.
+func (e *Engine) diffCopyPositions(upstream []PositionEvent, positions []broker.Position, open []broker.Order, now time.Time) *CopyReconciliation {
+	held := make(map[string]float64)
+	for _, p := range positions {
+		held[p.Symbol] += math.Abs(p.Qty)
+	}
+	for _, st := range e.ruleStates {
+		switch st.State {
+		case StateLong, StateShort, StateExiting:
+			held[st.Symbol] -= st.Qty
+		}
+	}
+
+	// Open copy entries are attributed by position; other untracked open
+	// orders may be entries placed before the state was lost.
+	byPosition := make(map[string]broker.Order)
+	pending := make(map[string][]broker.Order)
+	for _, o := range open {
+		ref := e.orders[o.OrderID]
+		switch {
+		case ref == nil:
+			pending[o.Symbol] = append(pending[o.Symbol], o)
+		case ref.Kind == orderCopyEntry:
+			byPosition[ref.PositionID] = o
+		}
+	}
+
+	var active []PositionEvent
+	for _, ev := range upstream {
+		_, tracked := e.trackedPositions[ev.ID]
+		if ev.TradeStatus == "active" && (tracked || e.posFilter.MatchAt(ev, now)) {
+			active = append(active, ev)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].ID < active[j].ID })
+
+	rec := &CopyReconciliation{}
+	live := make(map[string]bool, len(active))
+	for _, ev := range active {
+		live[ev.ID] = true
+		req := ev.ToEntryOrder(e.posFilter)
+		if a, ok := e.copyAllocs[ev.ID]; ok {
+			req.Qty = a.Qty
+		}
+		if req.Qty <= 0 {
+			continue // sizing would not copy it
+		}
+		// Broker holdings are keyed by the symbol the copy was ordered with,
+		// which for options is the compact OCC form of ContractName.
+		m := CopyMatch{PositionID: ev.ID, Symbol: req.Symbol, Qty: req.Qty}
+
+		if o, ok := byPosition[ev.ID]; ok {
+			m.OrderID, m.Pending = o.OrderID, true
+		} else if held[req.Symbol] >= req.Qty-qtyEpsilon {
+			held[req.Symbol] -= req.Qty
+			m.OrderID = e.trackedPositions[ev.ID]
+			if m.OrderID == "" {
+				m.OrderID = reconciledOrderID
+			}
+		} else if o, ok := takePendingEntry(pending, req); ok {
+			m.OrderID, m.Pending = o.OrderID, true
+		} else {
+			rec.Missed = append(rec.Missed, ev)
+			continue
+		}
+		rec.Matched = append(rec.Matched, m)
+	}
+
+	for id := range e.trackedPositions {
+		if !live[id] {
+			rec.Dropped = append(rec.Dropped, id)
+		}
+	}
+	sort.Strings(rec.Dropped)
+
+	for _, p := range positions {
+		if rem := held[p.Symbol]; rem > qtyEpsilon {
+			orphan := p
+			orphan.Qty = math.Copysign(math.Min(rem, math.Abs(p.Qty)), p.Qty)
+			rec.Orphaned = append(rec.Orphaned, orphan)
+			held[p.Symbol] = 0
+		}
+	}
+	return rec
+}
+
+// takePendingEntry removes and returns an open order that could be the
+// entry for req: same symbol and side.
+func takePendingEntry(pending map[string][]broker.Order, req broker.OrderRequest) (broker.Order, bool) {
+	orders := pending[req.Symbol]
+	for i, o := range orders {
+		if o.Side == req.Side {
+			pending[req.Symbol] = append(orders[:i:i], orders[i+1:]...)
+			return o, true
+		}
+	}
+	return broker.Order{}, false
+}
+
+// closeOrphan places a market order flattening an orphaned position.
+// Callers hold e.mu.
+func (e *Engine) closeOrphan(ctx context.Context, p broker.Position, now time.Time) (string, error) {
+	if e.halted() {
+		return "", fmt.Errorf("circuit breaker open")
+	}
+	side := "sell"
+	if p.Qty < 0 {
+		side = "buy"
+	}
+	req := broker.OrderRequest{Symbol: p.Symbol, Qty: math.Abs(p.Qty), Side: side, Type: "market", TIF: "day", AssetClass: p.AssetClass}
+	if req.IsOption() {
+		req.PositionIntent = broker.IntentClose
+	}
+
+	if err := broker.ValidateOrderLimits(req, e.config.Safety); err != nil {
+		e.metrics.inc(metricSafetyBlocks, blockOrderLimits)
+		e.recordSafetyBlock(ctx, now)
+		return "", err
+	}
+	order, err := e.broker.CreateOrder(ctx, req)
+	if err != nil {
+		e.metrics.inc(metricOrders, "failed")
+		e.recordOrderResult(ctx, true, now)
+		return "", err
+	}
+
+	e.sessionOrders++
+	e.metrics.inc(metricOrders, "placed")
+	e.recordOrderResult(ctx, false, now)
+	e.trackOrder(order, OrderRef{
+		RuleID: "copy-trade", Symbol: p.Symbol, Kind: orderCopyExit,
+		Side: side, PlacedAt: now,
+	})
+	e.emitEvent(Event{
+		EventID:   e.nextEventID(),
+		RuleID:    "copy-trade",
+		EventType: "order_placed",
+		Symbol:    p.Symbol,
+		OrderID:   order.OrderID,
+		OrderSide: side,
+		OrderQty:  req.Qty,
+		Reason:    "close orphaned position",
+		DaemonID:  e.config.DaemonID,
+		CreatedAt: now.UTC().Format(time.RFC3339),
+	})
+	log.Printf("[engine] closing orphaned position: %s %s %.0f (order=%s)",
+		side, p.Symbol, req.Qty, order.OrderID)
+	return order.OrderID, nil
+}
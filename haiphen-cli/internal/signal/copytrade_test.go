@@ -0,0 +1,235 @@
+package signal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/haiphen/haiphen-cli/internal/broker"
+)
+
+// holdingsBroker reports fixed positions and open orders.
+type holdingsBroker struct {
+	mockBroker
+	positions []broker.Position
+	open      []broker.Order
+}
+
+func (b *holdingsBroker) GetPositions(context.Context) ([]broker.Position, error) {
+	return b.positions, nil
+}
+
+func (b *holdingsBroker) GetOrders(context.Context, string, int) ([]broker.Order, error) {
+	return b.open, nil
+}
+
+func copyPosition(id, contract string) PositionEvent {
+	return PositionEvent{
+		ID:             id,
+		Underlying:     "AAPL",
+		ContractName:   contract,
+		EntrySide:      "buy",
+		EntryOrderType: "market",
+		TradeStatus:    "active",
+	}
+}
+
+func newCopyEngine(b broker.Broker, events chan Event) *Engine {
+	engine := NewEngine(b, DefaultEngineConfig(), events)
+	engine.SetPositionFilter(&PositionFilter{Enabled: true, ScaleFactor: 1.0})
+	return engine
+}
+
+func TestReconcileCopyTrades_AdoptsHeldPositions(t *testing.T) {
+	b := &holdingsBroker{positions: []broker.Position{{Symbol: "AAPL260220C00230000", Qty: 1}}}
+	engine := newCopyEngine(b, make(chan Event, 16))
+	ctx := context.Background()
+
+	// A restart forgot the copy; upstream and the broker still have it.
+	pos := copyPosition("1_1", "AAPL260220C00230000")
+	rec, err := engine.ReconcileCopyTrades(ctx, []PositionEvent{pos}, CopyReconcileOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rec.Clean() || len(rec.Matched) != 1 {
+		t.Fatalf("reconciliation = %+v, want one match", rec)
+	}
+	if got := engine.TrackedPositions()["1_1"]; got != reconciledOrderID {
+		t.Fatalf("tracked 1_1 = %q, want %q", got, reconciledOrderID)
+	}
+
+	// The closing event is now mirrored.
+	pos.TradeStatus = "closing"
+	engine.ProcessPositionEvents(ctx, []PositionEvent{pos})
+	if len(b.orders) != 1 || b.orders[0].Side != "sell" {
+		t.Fatalf("orders = %+v, want one closing sell", b.orders)
+	}
+}
+
+func TestReconcileCopyTrades_FlagsDifferences(t *testing.T) {
+	b := &holdingsBroker{positions: []broker.Position{
+		{Symbol: "AAPL260220C00230000", Qty: 2}, // upstream closed while we were down
+	}}
+	events := make(chan Event, 16)
+	engine := newCopyEngine(b, events)
+	engine.trackedPositions["1_1"] = "o-old"
+
+	upstream := []PositionEvent{copyPosition("2_1", "MSFT260220C00400000")}
+	rec, err := engine.ReconcileCopyTrades(context.Background(), upstream, CopyReconcileOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.Missed) != 1 || rec.Missed[0].ID != "2_1" {
+		t.Errorf("missed = %+v, want 2_1", rec.Missed)
+	}
+	if len(rec.Orphaned) != 1 || rec.Orphaned[0].Qty != 2 {
+		t.Errorf("orphaned = %+v, want 2 AAPL contracts", rec.Orphaned)
+	}
+	if len(rec.Dropped) != 1 || rec.Dropped[0] != "1_1" {
+		t.Errorf("dropped = %v, want [1_1]", rec.Dropped)
+	}
+	if len(engine.TrackedPositions()) != 0 {
+		t.Errorf("tracked = %v, want none", engine.TrackedPositions())
+	}
+	if len(b.orders) != 0 {
+		t.Errorf("flagging placed orders: %+v", b.orders)
+	}
+
+	evs := drainEvents(events)
+	if len(eventsOfType(evs, "copy_missed")) != 1 || len(eventsOfType(evs, "copy_orphaned")) != 1 {
+		t.Errorf("events = %+v, want one copy_missed and one copy_orphaned", evs)
+	}
+}
+
+func TestReconcileCopyTrades_Acts(t *testing.T) {
+	b := &holdingsBroker{positions: []broker.Position{{Symbol: "AAPL260220C00230000", Qty: 2}}}
+	engine := newCopyEngine(b, make(chan Event, 16))
+
+	upstream := []PositionEvent{copyPosition("2_1", "MSFT260220C00400000")}
+	rec, err := engine.ReconcileCopyTrades(context.Background(), upstream,
+		CopyReconcileOptions{EnterMissed: true, CloseOrphans: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.Entered) != 1 || len(rec.Closed) != 1 || len(rec.Errors) != 0 {
+		t.Fatalf("reconciliation = %+v, want one entry and one close", rec)
+	}
+
+	if len(b.orders) != 2 {
+		t.Fatalf("orders = %+v, want close + entry", b.orders)
+	}
+	if o := b.orders[0]; o.Symbol != "AAPL260220C00230000" || o.Side != "sell" || o.Qty != 2 {
+		t.Errorf("close order = %+v", o)
+	}
+	if o := b.orders[1]; o.Symbol != "MSFT260220C00400000" || o.Side != "buy" {
+		t.Errorf("entry order = %+v", o)
+	}
+	if engine.TrackedPositions()["2_1"] == "" {
+		t.Error("entered position should be tracked")
+	}
+}
+
+func TestReconcileCopyTrades_PendingAndRuleHoldings(t *testing.T) {
+	b := &holdingsBroker{
+		positions: []broker.Position{{Symbol: "SPY", Qty: 5}},
+		open:      []broker.Order{{OrderID: "o9", Symbol: "AAPL260220C00230000", Side: "buy", Status: "new"}},
+	}
+	engine := newCopyEngine(b, make(chan Event, 16))
+	engine.ruleStates["swing:SPY"] = &RuleState{RuleID: "swing", Symbol: "SPY", State: StateLong, Qty: 5}
+	engine.orders["o9"] = &OrderRef{Kind: orderCopyEntry, PositionID: "1_1", PlacedAt: time.Now()}
+
+	upstream := []PositionEvent{copyPosition("1_1", "AAPL260220C00230000")}
+	rec, err := engine.ReconcileCopyTrades(context.Background(), upstream, CopyReconcileOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rec.Clean() {
+		t.Fatalf("reconciliation = %+v, want clean: SPY belongs to a rule", rec)
+	}
+	if m := rec.Matched[0]; m.OrderID != "o9" || !m.Pending {
+		t.Errorf("match = %+v, want pending order o9", m)
+	}
+}
+
+func TestReconcileCopyTrades_PaddedOCCSymbol(t *testing.T) {
+	// Upstream sends the padded OCC form; the copy was ordered, and is
+	// held, under the compact one.
+	b := &holdingsBroker{positions: []broker.Position{{Symbol: "AAPL260220C00230000", Qty: 1}}}
+	engine := newCopyEngine(b, make(chan Event, 16))
+
+	upstream := []PositionEvent{copyPosition("1_1", "AAPL  260220C00230000")}
+	rec, err := engine.ReconcileCopyTrades(context.Background(), upstream, CopyReconcileOptions{CloseOrphans: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rec.Clean() || len(rec.Matched) != 1 || rec.Matched[0].Symbol != "AAPL260220C00230000" {
+		t.Fatalf("reconciliation = %+v, want one match on the compact symbol", rec)
+	}
+	if len(b.orders) != 0 {
+		t.Errorf("matched copy was closed as an orphan: %+v", b.orders)
+	}
+}
+
+func TestFetchActivePositions_FullPageIsIncomplete(t *testing.T) {
+	var n int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The API caps limit at one page, newest first.
+		events := make([]PositionEvent, n)
+		for i := range events {
+			events[i] = copyPosition(fmt.Sprintf("%d_1", i), "AAPL260220C00230000")
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "events": events})
+	}))
+	defer srv.Close()
+
+	for _, tt := range []struct {
+		n        int
+		complete bool
+	}{{5, true}, {positionEventsPageSize, false}} {
+		n = tt.n
+		events, complete, err := FetchActivePositions(context.Background(), srv.URL, "tok")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != tt.n || complete != tt.complete {
+			t.Errorf("%d upstream: got %d events, complete=%v; want complete=%v", tt.n, len(events), complete, tt.complete)
+		}
+	}
+}
+
+func TestReconcileCopyTrades_PartialUpstream(t *testing.T) {
+	// 1_1 is held and tracked but fell off the one page upstream returned.
+	b := &holdingsBroker{positions: []broker.Position{
+		{Symbol: "AAPL260220C00230000", Qty: 1},
+		{Symbol: "MSFT260220C00400000", Qty: 1},
+	}}
+	engine := newCopyEngine(b, make(chan Event, 16))
+	engine.trackedPositions["1_1"] = "o-1"
+
+	upstream := []PositionEvent{copyPosition("2_1", "MSFT260220C00400000")}
+	rec, err := engine.ReconcileCopyTrades(context.Background(), upstream,
+		CopyReconcileOptions{CloseOrphans: true, Partial: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rec.Partial || len(rec.Matched) != 1 || len(rec.Dropped) != 0 || len(rec.Orphaned) != 0 {
+		t.Fatalf("reconciliation = %+v, want one match and nothing dropped or orphaned", rec)
+	}
+	if engine.TrackedPositions()["1_1"] != "o-1" {
+		t.Error("position missing from a partial list should stay tracked")
+	}
+	if len(b.orders) != 0 {
+		t.Errorf("partial reconcile placed orders: %+v", b.orders)
+	}
+}
+
+func TestReconcileCopyTrades_RequiresLiveCopyTrade(t *testing.T) {
+	engine := NewEngine(&holdingsBroker{}, DefaultEngineConfig(), nil)
+	if _, err := engine.ReconcileCopyTrades(context.Background(), nil, CopyReconcileOptions{}); err == nil {
+		t.Error("expected an error with copy-trade disabled")
+	}
+}
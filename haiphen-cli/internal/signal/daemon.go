@@ -13,6 +13,8 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+
+	"github.com/haiphen/haiphen-cli/internal/broker"
 )
 
 // DaemonConfig holds daemon lifecycle config.
@@ -22,6 +24,16 @@ type DaemonConfig struct {
 	Token       string
 	RulesDir    string
 	MaxOrderQty int
+	Record      bool // journal every raw feed message under RecordingsDir
+	// ReloadInterval is how often rule and filter files are polled for
+	// changes; 0 uses the default, negative disables polling (SIGHUP still
+	// reloads).
+	ReloadInterval time.Duration
+	Explain        bool // log every rule's evaluation tree per snapshot at debug level
+	Fresh          bool // discard the saved engine state instead of restoring it
+	// MetricsAddr serves Prometheus metrics on http://MetricsAddr/metrics
+	// when set; it must be a localhost address.
+	MetricsAddr string
 }
 
 // PIDPath returns the PID file path for a profile.
@@ -100,6 +112,20 @@ func StopDaemon(profile string) error {
 	return proc.Signal(syscall.SIGTERM)
 }
 
+// ReloadDaemon sends SIGHUP to the running daemon so it reloads its rules and
+// position filter.
+func ReloadDaemon(profile string) error {
+	pid, running := IsRunning(profile)
+	if !running {
+		return fmt.Errorf("no daemon running (profile=%s)", profile)
+	}
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return proc.Signal(syscall.SIGHUP)
+}
+
 // SetupLogger configures structured JSON logging to the log file.
 func SetupLogger(profile string) (*os.File, error) {
 	logPath, err := LogPath(profile)
@@ -129,32 +155,82 @@ func LogJSON(level, msg string, fields map[string]interface{}) {
 	log.Println(string(data))
 }
 
+// captureBaseline records the day-start equity when the daemon starts, so the
+// session's daily P&L is measured from the open rather than the first order.
+func captureBaseline(ctx context.Context, b broker.Broker, baseline *broker.Baseline) {
+	acct, err := b.GetAccount(ctx)
+	if err == nil {
+		_, err = baseline.DailyPL(acct, time.Now())
+	}
+	if err != nil {
+		LogJSON("warn", "failed to capture daily loss baseline", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if cur := baseline.Current(time.Now()); cur != nil {
+		LogJSON("info", "daily loss baseline", map[string]interface{}{
+			"date":       cur.Date,
+			"equity":     cur.Equity,
+			"equity_now": acct.Equity,
+		})
+	}
+}
+
 // RunDaemon is the main daemon loop: load rules, connect WS, evaluate.
 func RunDaemon(ctx context.Context, engine *Engine, dcfg DaemonConfig) error {
-	// Load rules
-	rules, err := LoadRulesFromDir(dcfg.RulesDir)
+	// Load rules (assigns IDs, skips invalid rules)
+	active, skipped, err := LoadActiveRules(dcfg.RulesDir, dcfg.MaxOrderQty)
 	if err != nil {
 		return fmt.Errorf("load rules: %w", err)
 	}
+	for name, err := range skipped {
+		LogJSON("warn", "skipping invalid rule", map[string]interface{}{
+			"rule": name, "error": err.Error(),
+		})
+	}
 
-	// Assign IDs + filter valid rules
-	var active []*Rule
-	for _, r := range rules {
-		if r.RuleID == "" {
-			r.RuleID = DeterministicID("", r.Name)
-		}
-		if err := ValidateRule(r, dcfg.MaxOrderQty); err != nil {
-			LogJSON("warn", "skipping invalid rule", map[string]interface{}{
-				"rule": r.Name, "error": err.Error(),
-			})
-			continue
-		}
-		if r.Status == "active" {
-			active = append(active, r)
+	// Restore cooldowns, caps and positions from the previous run. The state
+	// is restored before SetRules so entries for deleted rules are pruned.
+	statePath, err := StatePath(dcfg.Profile)
+	if err != nil {
+		return err
+	}
+	if dcfg.Fresh {
+		if err := os.Remove(statePath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("discard engine state: %w", err)
 		}
+		LogJSON("info", "starting with fresh engine state", nil)
+	} else if st, err := engine.RestoreState(statePath); err != nil {
+		LogJSON("warn", "failed to restore engine state, starting clean", map[string]interface{}{
+			"error": err.Error(),
+		})
+	} else if st != nil {
+		LogJSON("info", "restored engine state", map[string]interface{}{
+			"saved_at":          st.SavedAt.Format(time.RFC3339),
+			"cooldowns":         len(st.Cooldowns),
+			"session_orders":    st.SessionOrders,
+			"tracked_positions": len(st.TrackedPositions),
+			"rule_states":       len(st.RuleStates),
+			"circuit_open":      st.Breaker.Open,
+			"dry_run":           st.DryRun,
+		})
 	}
 
 	engine.SetRules(active)
+	engine.SetStatePath(statePath)
+
+	// Day-start equity baseline for the daily loss limit, shared with the
+	// CLI so losses realized anywhere on the profile count toward it.
+	baselinePath, err := broker.BaselinePath(dcfg.Profile)
+	if err != nil {
+		return err
+	}
+	baseline := broker.OpenBaseline(baselinePath)
+	engine.SetBaseline(baseline)
+	if engine.broker != nil {
+		captureBaseline(ctx, engine.broker, baseline)
+	}
 
 	// Load position filter for copy-trade
 	posFilter, err := LoadPositionFilter(dcfg.Profile)
@@ -166,14 +242,62 @@ func RunDaemon(ctx context.Context, engine *Engine, dcfg DaemonConfig) error {
 	}
 	engine.SetPositionFilter(posFilter)
 
+	// Feed journal
+	var rec *Recorder
+	if dcfg.Record {
+		dir, err := RecordingsDir(dcfg.Profile)
+		if err != nil {
+			LogJSON("warn", "feed recording disabled", map[string]interface{}{
+				"error": err.Error(),
+			})
+		} else {
+			rec = NewRecorder(dir, 0, 0)
+			defer rec.Close()
+		}
+	}
+
 	LogJSON("info", "daemon started", map[string]interface{}{
 		"pid":              os.Getpid(),
 		"rules":            len(active),
 		"dry_run":          engine.config.DryRun,
 		"api":              dcfg.APIOrigin,
 		"copy_trade":       posFilter.Enabled,
+		"recording":        rec != nil,
 	})
 
+	// Pick up rule and filter edits without a restart
+	go watchConfig(ctx, engine, dcfg)
+
+	// Prometheus metrics
+	if dcfg.MetricsAddr != "" {
+		if engine.metrics == nil {
+			engine.SetMetrics(NewMetrics())
+		}
+		go func() {
+			if err := ServeMetrics(ctx, dcfg.MetricsAddr, engine.metrics); err != nil {
+				LogJSON("warn", "metrics endpoint unavailable", map[string]interface{}{
+					"error": err.Error(),
+				})
+			}
+		}()
+	}
+
+	// Fills, rejections and cancellations of the engine's orders
+	if engine.broker != nil {
+		go streamTradeUpdates(ctx, engine, engine.broker)
+	}
+
+	// Control socket for status, pause/resume and dry-run toggling
+	if sockPath, err := SocketPath(dcfg.Profile); err == nil {
+		go func() {
+			if err := ServeControl(ctx, engine, sockPath, dcfg.Profile); err != nil {
+				LogJSON("warn", "control socket unavailable", map[string]interface{}{
+					"error": err.Error(),
+				})
+			}
+		}()
+	}
+
 	// WebSocket connect loop with exponential backoff
 	backoff := time.Second
 	maxBackoff := 2 * time.Minute
@@ -186,10 +310,13 @@ func RunDaemon(ctx context.Context, engine *Engine, dcfg DaemonConfig) error {
 		default:
 		}
 
-		err := connectAndListen(ctx, engine, dcfg)
+		err := connectAndListen(ctx, engine, dcfg, rec)
 		if ctx.Err() != nil {
 			return nil
 		}
+		engine.metrics.set(metricConnected, 0)
+		engine.metrics.inc(metricReconnects, "")
+		engine.metrics.set(metricBackoff, backoff.Seconds())
 
 		LogJSON("warn", "WebSocket disconnected", map[string]interface{}{
 			"error":   fmt.Sprintf("%v", err),
@@ -209,7 +336,38 @@ func RunDaemon(ctx context.Context, engine *Engine, dcfg DaemonConfig) error {
 	}
 }
 
-func connectAndListen(ctx context.Context, engine *Engine, dcfg DaemonConfig) error {
+// reconcileCopyTrades compares upstream active positions with the broker and
+// logs the differences; acting on them is left to the user.
+func reconcileCopyTrades(ctx context.Context, engine *Engine, dcfg DaemonConfig) {
+	upstream, complete, err := FetchActivePositions(ctx, dcfg.APIOrigin, dcfg.Token)
+	if err != nil {
+		LogJSON("warn", "copy-trade reconciliation skipped", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	rec, err := engine.ReconcileCopyTrades(ctx, upstream, CopyReconcileOptions{Partial: !complete})
+	if err != nil {
+		LogJSON("warn", "copy-trade reconciliation failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	level := "info"
+	if !rec.Clean() {
+		level = "warn"
+	}
+	LogJSON(level, "copy-trade reconciled", map[string]interface{}{
+		"matched":  len(rec.Matched),
+		"missed":   len(rec.Missed),
+		"orphaned": len(rec.Orphaned),
+		"dropped":  len(rec.Dropped),
+		"partial":  rec.Partial,
+	})
+}
+
+func connectAndListen(ctx context.Context, engine *Engine, dcfg DaemonConfig, rec *Recorder) error {
 	wsURL := strings.Replace(dcfg.APIOrigin, "https://", "wss://", 1)
 	wsURL = strings.Replace(wsURL, "http://", "ws://", 1)
 	wsURL = strings.TrimRight(wsURL, "/") + "/v1/signal/stream?token=" + dcfg.Token
@@ -225,6 +383,13 @@ func connectAndListen(ctx context.Context, engine *Engine, dcfg DaemonConfig) er
 	defer conn.Close()
 
 	LogJSON("info", "connected to signal feed", nil)
+	engine.metrics.set(metricConnected, 1)
+
+	// Position events sent while disconnected are lost; catch up before
+	// processing new ones.
+	if engine.CopyTradeLive() {
+		reconcileCopyTrades(ctx, engine, dcfg)
+	}
 
 	// Read loop
 	for {
@@ -241,11 +406,20 @@ func connectAndListen(ctx context.Context, engine *Engine, dcfg DaemonConfig) er
 			return fmt.Errorf("read: %w", err)
 		}
 
+		if rec != nil {
+			if err := rec.Write(msg); err != nil {
+				LogJSON("warn", "feed recording failed", map[string]interface{}{
+					"error": err.Error(),
+				})
+			}
+		}
+
 		// Parse message type
 		var envelope struct {
 			Type string `json:"type"`
 		}
 		if err := json.Unmarshal(msg, &envelope); err != nil {
+			engine.metrics.inc(metricParseFailures, "envelope")
 			continue
 		}
 
@@ -253,22 +427,39 @@ func connectAndListen(ctx context.Context, engine *Engine, dcfg DaemonConfig) er
 		case "hello":
 			LogJSON("info", "received hello from signal feed", nil)
 		case "snapshot":
+			engine.metrics.inc(metricSnapshots, "")
 			snap, err := ParseSnapshot(msg)
 			if err != nil {
+				engine.metrics.inc(metricParseFailures, "snapshot")
 				LogJSON("warn", "parse snapshot failed", map[string]interface{}{
 					"error": err.Error(),
 				})
 				continue
 			}
+			snap.receivedAt = time.Now()
+			if at, err := time.Parse(time.RFC3339Nano, snap.UpdatedAt); err == nil {
+				engine.metrics.observe(metricSnapshotDelay, snap.receivedAt.Sub(at).Seconds())
+			}
 			LogJSON("debug", "snapshot received", map[string]interface{}{
 				"date":   snap.Date,
 				"kpis":   len(snap.KPIs),
 				"source": snap.Source,
 			})
+			if dcfg.Explain {
+				for _, tr := range engine.Explain(snap) {
+					LogJSON("debug", "rule evaluation", map[string]interface{}{
+						"rule":   tr.Name,
+						"symbol": tr.Symbol,
+						"entry":  tr.Entry,
+						"exit":   tr.Exit,
+					})
+				}
+			}
 			engine.Evaluate(ctx, snap)
 		case "position_events":
 			events, err := ParsePositionEvents(msg)
 			if err != nil {
+				engine.metrics.inc(metricParseFailures, "position_events")
 				LogJSON("warn", "parse position events failed", map[string]interface{}{
 					"error": err.Error(),
 				})
@@ -282,8 +473,9 @@ func connectAndListen(ctx context.Context, engine *Engine, dcfg DaemonConfig) er
 	}
 }
 
-// EventLogger runs a goroutine that logs events and optionally posts them to the API.
-func EventLogger(ctx context.Context, events <-chan Event, apiOrigin, token string) {
+// EventLogger runs a goroutine that logs events. Delivery to the API goes
+// through the outbox (see DeliverOutbox).
+func EventLogger(ctx context.Context, events <-chan Event) {
 	for {
 		select {
 		case <-ctx.Done():
@@ -299,37 +491,8 @@ func EventLogger(ctx context.Context, events <-chan Event, apiOrigin, token stri
 				"event_type": ev.EventType,
 				"symbol":     ev.Symbol,
 				"order_id":   ev.OrderID,
+				"reason":     ev.Reason,
 			})
-
-			// Async POST to API (best-effort)
-			if token != "" && apiOrigin != "" {
-				go postEvent(apiOrigin, token, ev)
-			}
 		}
 	}
 }
-
-func postEvent(apiOrigin, token string, ev Event) {
-	// Fire-and-forget: errors are logged but don't affect daemon
-	data, err := json.Marshal(ev)
-	if err != nil {
-		return
-	}
-
-	url := strings.TrimRight(apiOrigin, "/") + "/v1/signal/events"
-	req, err := newJSONRequest("POST", url, data)
-	if err != nil {
-		return
-	}
-	req.Header.Set("Authorization", "Bearer "+token)
-
-	client := newHTTPClient(10 * time.Second)
-	resp, err := client.Do(req)
-	if err != nil {
-		LogJSON("warn", "event post failed", map[string]interface{}{
-			"error": err.Error(),
-		})
-		return
-	}
-	resp.Body.Close()
-}
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"testing"
+	"time"
 )
 
 func TestPIDWriteReadRemove(t *testing.T) {
@@ -67,9 +68,10 @@ func TestLogJSON(t *testing.T) {
 	})
 }
 
-func TestGenerateEventID(t *testing.T) {
-	id1 := generateEventID()
-	id2 := generateEventID()
+func TestNextEventID(t *testing.T) {
+	engine := NewEngine(nil, DefaultEngineConfig(), nil)
+	id1 := engine.nextEventID()
+	id2 := engine.nextEventID()
 
 	if id1 == "" {
 		t.Fatal("empty event ID")
@@ -80,4 +82,14 @@ func TestGenerateEventID(t *testing.T) {
 	if len(id1) < 5 {
 		t.Fatalf("event ID too short: %s", id1)
 	}
+
+	// Same clock, fresh engine: identical IDs.
+	fixed := time.Date(2026, 2, 10, 14, 30, 0, 0, time.UTC)
+	a := NewEngine(nil, DefaultEngineConfig(), nil)
+	b := NewEngine(nil, DefaultEngineConfig(), nil)
+	a.SetClock(func() time.Time { return fixed })
+	b.SetClock(func() time.Time { return fixed })
+	if a.nextEventID() != b.nextEventID() {
+		t.Fatal("event IDs should be deterministic for a fixed clock")
+	}
 }
@@ -0,0 +1,158 @@
+package signal
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// DataQualityConfig guards every rule against bad snapshots. A trigger
+// suppressed by a guard emits a data_quality_blocked event.
+type DataQualityConfig struct {
+	// MaxSnapshotAge rejects snapshots whose UpdatedAt is older than this
+	// (or missing). 0 disables the check.
+	MaxSnapshotAge time.Duration
+	// RejectDuplicates ignores a snapshot identical to the previous one
+	// (same UpdatedAt and KPI values): it neither triggers nor enters
+	// indicator history.
+	RejectDuplicates bool
+}
+
+// RuleDataQuality holds a rule's own data requirements. KPI names may
+// contain {symbol}.
+type RuleDataQuality struct {
+	// RequiredKPIs must all be present for the rule to trigger.
+	RequiredKPIs []string `yaml:"required_kpis,omitempty" json:"required_kpis,omitempty"`
+	// MaxJumpPct is the largest change (percent of the previous snapshot's
+	// value) accepted per KPI; larger moves are treated as outliers.
+	MaxJumpPct map[string]float64 `yaml:"max_jump_pct,omitempty" json:"max_jump_pct,omitempty"`
+}
+
+// Validate checks the rule's data-quality settings.
+func (q *RuleDataQuality) Validate() error {
+	for _, k := range q.RequiredKPIs {
+		if !kpiNameRe.MatchString(strings.ReplaceAll(k, SymbolPlaceholder, "X")) {
+			return fmt.Errorf("data_quality: invalid required KPI %q", k)
+		}
+	}
+	for k, pct := range q.MaxJumpPct {
+		if !kpiNameRe.MatchString(strings.ReplaceAll(k, SymbolPlaceholder, "X")) {
+			return fmt.Errorf("data_quality: invalid max_jump_pct KPI %q", k)
+		}
+		if pct <= 0 {
+			return fmt.Errorf("data_quality: max_jump_pct for %q must be positive", k)
+		}
+	}
+	return nil
+}
+
+func (q *RuleDataQuality) usesSymbolPlaceholder() bool {
+	for _, k := range q.RequiredKPIs {
+		if strings.Contains(k, SymbolPlaceholder) {
+			return true
+		}
+	}
+	for k := range q.MaxJumpPct {
+		if strings.Contains(k, SymbolPlaceholder) {
+			return true
+		}
+	}
+	return false
+}
+
+// snapshotQuality returns why snap must not trigger any rule, or "".
+// Callers hold e.mu.
+func (e *Engine) snapshotQuality(snap *Snapshot, now time.Time) string {
+	dq := e.config.DataQuality
+	if dq.RejectDuplicates && isDuplicateSnapshot(e.prevSnapshot, snap) {
+		return "duplicate snapshot"
+	}
+	if dq.MaxSnapshotAge > 0 {
+		if snap.UpdatedAt == "" {
+			return "snapshot has no updated_at"
+		}
+		at, err := time.Parse(time.RFC3339Nano, snap.UpdatedAt)
+		if err != nil {
+			return fmt.Sprintf("unparseable updated_at %q", snap.UpdatedAt)
+		}
+		if age := now.Sub(at); age > dq.MaxSnapshotAge {
+			return fmt.Sprintf("snapshot is %s old (max %s)", age.Round(time.Second), dq.MaxSnapshotAge)
+		}
+	}
+	return ""
+}
+
+// isDuplicateSnapshot reports whether snap repeats prev. Snapshots without
+// UpdatedAt are never duplicates, since identical values can be genuine.
+func isDuplicateSnapshot(prev, snap *Snapshot) bool {
+	if prev == nil || snap.UpdatedAt == "" || snap.UpdatedAt != prev.UpdatedAt || len(snap.KPIs) != len(prev.KPIs) {
+		return false
+	}
+	for k, v := range snap.KPIs {
+		if pv, ok := prev.KPIs[k]; !ok || pv != v {
+			return false
+		}
+	}
+	return true
+}
+
+// targetQuality returns why a target must not trigger on snap, or "".
+// snapReason is the snapshot-level result. Callers hold e.mu.
+func (e *Engine) targetQuality(t ruleTarget, snap *Snapshot, snapReason string) string {
+	if snapReason != "" {
+		return snapReason
+	}
+	q := t.rule.DataQuality
+	if q == nil {
+		return ""
+	}
+	resolve := func(k string) string { return strings.ReplaceAll(k, SymbolPlaceholder, t.symbol) }
+
+	for _, k := range q.RequiredKPIs {
+		if _, ok := snap.KPIs[resolve(k)]; !ok {
+			return fmt.Sprintf("required KPI %q missing", resolve(k))
+		}
+	}
+	return e.jumpReason(t, snap)
+}
+
+// jumpReason returns why a KPI of t moved too far from the last accepted
+// snapshot, or "". A jump the previous, rejected snapshot already made is a
+// confirmed move rather than an outlier. Callers hold e.mu.
+func (e *Engine) jumpReason(t ruleTarget, snap *Snapshot) string {
+	q := t.rule.DataQuality
+	if q == nil || e.prevSnapshot == nil {
+		return ""
+	}
+	for k, maxPct := range q.MaxJumpPct {
+		kpi := strings.ReplaceAll(k, SymbolPlaceholder, t.symbol)
+		cur, ok1 := snap.KPIs[kpi]
+		prev, ok2 := e.prevSnapshot.KPIs[kpi]
+		if !ok1 || !ok2 || prev == 0 {
+			continue
+		}
+		jump := math.Abs(cur-prev) / math.Abs(prev) * 100
+		if jump <= maxPct {
+			continue
+		}
+		if e.outlier != nil {
+			if held, ok := e.outlier.KPIs[kpi]; ok && held != 0 && math.Abs(cur-held)/math.Abs(held)*100 <= maxPct {
+				continue
+			}
+		}
+		return fmt.Sprintf("KPI %q jumped %.2f%% (max %g%%)", kpi, jump, maxPct)
+	}
+	return ""
+}
+
+// isOutlier reports whether any active target's jump guard rejects snap.
+// Callers hold e.mu.
+func (e *Engine) isOutlier(snap *Snapshot) bool {
+	for _, t := range e.targets {
+		if t.rule.Status == "active" && e.jumpReason(t, snap) != "" {
+			return true
+		}
+	}
+	return false
+}
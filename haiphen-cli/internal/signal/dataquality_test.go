@@ -0,0 +1,165 @@
+package signal
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func qualityRule() *Rule {
+	return &Rule{
+		RuleID:   "dq",
+		Name:     "dq",
+		Status:   "active",
+		Symbols:  []string{"SPY"},
+		Entry:    &ConditionGroup{AllOf: []ConditionOrGroup{{KPI: "Go", Operator: ">", Value: 0}}},
+		Order:    OrderParams{Side: "buy", Type: "market", Qty: 1, TIF: "day"},
+		Cooldown: 60,
+	}
+}
+
+func blockedReasons(evs []Event) []string {
+	var out []string
+	for _, ev := range evs {
+		if ev.EventType == "data_quality_blocked" {
+			out = append(out, ev.Reason)
+		}
+	}
+	return out
+}
+
+func TestEngine_StaleSnapshotBlocked(t *testing.T) {
+	b := &mockBroker{}
+	events := make(chan Event, 16)
+	cfg := DefaultEngineConfig()
+	cfg.DataQuality.MaxSnapshotAge = time.Minute
+	engine := NewEngine(b, cfg, events)
+	now := time.Date(2026, 2, 10, 15, 0, 0, 0, time.UTC)
+	engine.SetClock(func() time.Time { return now })
+	engine.SetRules([]*Rule{qualityRule()})
+
+	engine.Evaluate(context.Background(), &Snapshot{
+		UpdatedAt: now.Add(-5 * time.Minute).Format(time.RFC3339),
+		KPIs:      map[string]float64{"Go": 1},
+	})
+	if len(b.orders) != 0 {
+		t.Fatalf("stale snapshot placed %d orders", len(b.orders))
+	}
+	if r := blockedReasons(drainEvents(events)); len(r) != 1 || !strings.Contains(r[0], "old") {
+		t.Fatalf("reasons = %q", r)
+	}
+
+	engine.Evaluate(context.Background(), &Snapshot{
+		UpdatedAt: now.Add(-10 * time.Second).Format(time.RFC3339),
+		KPIs:      map[string]float64{"Go": 1},
+	})
+	if len(b.orders) != 1 {
+		t.Fatalf("fresh snapshot should trigger, orders = %d", len(b.orders))
+	}
+}
+
+func TestEngine_DuplicateSnapshotIgnored(t *testing.T) {
+	events := make(chan Event, 16)
+	engine := NewEngine(nil, DefaultEngineConfig(), events)
+	r := qualityRule()
+	r.Entry = &ConditionGroup{AllOf: []ConditionOrGroup{{Expr: "sma(Go, 3)", Operator: ">", Value: 100}}}
+	engine.SetRules([]*Rule{r})
+
+	snap := &Snapshot{UpdatedAt: "2026-02-10T15:00:00Z", KPIs: map[string]float64{"Go": 1}}
+	engine.Evaluate(context.Background(), snap)
+	engine.Evaluate(context.Background(), &Snapshot{UpdatedAt: snap.UpdatedAt, KPIs: map[string]float64{"Go": 1}})
+	if n := len(engine.history["Go"]); n != 1 {
+		t.Fatalf("duplicate entered history: %d values", n)
+	}
+}
+
+func TestEngine_RequiredKPIsAndJumps(t *testing.T) {
+	b := &mockBroker{}
+	events := make(chan Event, 16)
+	engine := NewEngine(b, DefaultEngineConfig(), events)
+	now := time.Date(2026, 2, 10, 15, 0, 0, 0, time.UTC)
+	engine.SetClock(func() time.Time { return now })
+	r := qualityRule()
+	r.DataQuality = &RuleDataQuality{
+		RequiredKPIs: []string{"{symbol}.price"},
+		MaxJumpPct:   map[string]float64{"{symbol}.price": 5},
+	}
+	engine.SetRules([]*Rule{r})
+
+	engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"Go": 1}})
+	now = now.Add(2 * time.Minute)
+	engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"Go": 0, "SPY.price": 100}})
+	now = now.Add(2 * time.Minute)
+	engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"Go": 1, "SPY.price": 150}})
+	if len(b.orders) != 0 {
+		t.Fatalf("orders = %d, want 0", len(b.orders))
+	}
+	reasons := blockedReasons(drainEvents(events))
+	if len(reasons) != 2 || !strings.Contains(reasons[0], `"SPY.price" missing`) || !strings.Contains(reasons[1], "jumped 50.00%") {
+		t.Fatalf("reasons = %q", reasons)
+	}
+
+	now = now.Add(2 * time.Minute)
+	engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"Go": 1, "SPY.price": 151}})
+	if len(b.orders) != 1 {
+		t.Fatalf("clean snapshot should trigger, orders = %d", len(b.orders))
+	}
+}
+
+func TestEngine_OutlierLeavesHistory(t *testing.T) {
+	b := &mockBroker{}
+	events := make(chan Event, 16)
+	engine := NewEngine(b, DefaultEngineConfig(), events)
+	r := qualityRule()
+	r.Cooldown = 0
+	r.Entry.AllOf = append(r.Entry.AllOf, ConditionOrGroup{Expr: "sma(SPY.price, 2)", Operator: ">", Value: 0})
+	r.DataQuality = &RuleDataQuality{MaxJumpPct: map[string]float64{"{symbol}.price": 5}}
+	engine.SetRules([]*Rule{r})
+	ctx := context.Background()
+
+	engine.Evaluate(ctx, &Snapshot{KPIs: map[string]float64{"Go": 0, "SPY.price": 100}})
+	engine.Evaluate(ctx, &Snapshot{KPIs: map[string]float64{"Go": 0, "SPY.price": 1000}}) // bad tick
+	if got := engine.history["SPY.price"]; len(got) != 1 || engine.prevSnapshot.KPIs["SPY.price"] != 100 {
+		t.Fatalf("outlier recorded: history %v, prev %v", got, engine.prevSnapshot.KPIs)
+	}
+
+	// The next good value is measured against 100, not the outlier.
+	engine.Evaluate(ctx, &Snapshot{KPIs: map[string]float64{"Go": 1, "SPY.price": 101}})
+	if len(b.orders) != 1 {
+		t.Fatalf("orders = %d, want 1; blocked: %q", len(b.orders), blockedReasons(drainEvents(events)))
+	}
+	if got := engine.history["SPY.price"]; len(got) != 2 || got[1] != 101 {
+		t.Errorf("history = %v, want [100 101]", got)
+	}
+}
+
+func TestEngine_StaleSnapshotLeavesHistory(t *testing.T) {
+	cfg := DefaultEngineConfig()
+	cfg.DataQuality.MaxSnapshotAge = time.Minute
+	engine := NewEngine(nil, cfg, make(chan Event, 16))
+	now := time.Date(2026, 2, 10, 15, 0, 0, 0, time.UTC)
+	engine.SetClock(func() time.Time { return now })
+	engine.SetRules([]*Rule{qualityRule()})
+
+	engine.Evaluate(context.Background(), &Snapshot{
+		UpdatedAt: now.Add(-5 * time.Minute).Format(time.RFC3339),
+		KPIs:      map[string]float64{"Go": 0},
+	})
+	if engine.prevSnapshot != nil || len(engine.history["Go"]) != 0 {
+		t.Fatalf("stale snapshot recorded: prev %v, history %v", engine.prevSnapshot, engine.history["Go"])
+	}
+}
+
+func TestValidateRule_DataQuality(t *testing.T) {
+	r := qualityRule()
+	r.Symbols = nil
+	r.DataQuality = &RuleDataQuality{MaxJumpPct: map[string]float64{"Price": 0}}
+	if err := ValidateRule(r, 1000); err == nil {
+		t.Error("expected error for non-positive max_jump_pct")
+	}
+	r.DataQuality = &RuleDataQuality{RequiredKPIs: []string{"{symbol}.price"}}
+	if err := ValidateRule(r, 1000); err == nil || !strings.Contains(err.Error(), "no symbols") {
+		t.Errorf("expected missing symbols error, got %v", err)
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"log"
 	"math"
 	"sync"
-	"sync/atomic"
 	"time"
 
 	"github.com/haiphen/haiphen-cli/internal/broker"
@@ -19,22 +18,27 @@ type Snapshot struct {
 	UpdatedAt string             `json:"updated_at"`
 	KPIs      map[string]float64 `json:"kpis"`
 	Source    string             `json:"source,omitempty"`
+
+	// receivedAt is when the daemon read the snapshot off the feed, for
+	// snapshot-to-order latency.
+	receivedAt time.Time
 }
 
 // Event represents a signal event to be logged.
 type Event struct {
-	EventID       string  `json:"event_id"`
-	RuleID        string  `json:"rule_id"`
-	EventType     string  `json:"event_type"`
-	TriggerJSON   string  `json:"trigger_snapshot_json,omitempty"`
-	MatchedJSON   string  `json:"matched_conditions_json,omitempty"`
-	Symbol        string  `json:"symbol,omitempty"`
-	OrderID       string  `json:"order_id,omitempty"`
-	OrderSide     string  `json:"order_side,omitempty"`
-	OrderQty      float64 `json:"order_qty,omitempty"`
-	OrderPrice    float64 `json:"order_price,omitempty"`
-	DaemonID      string  `json:"daemon_id,omitempty"`
-	CreatedAt     string  `json:"created_at"`
+	EventID     string  `json:"event_id"`
+	RuleID      string  `json:"rule_id"`
+	EventType   string  `json:"event_type"`
+	TriggerJSON string  `json:"trigger_snapshot_json,omitempty"`
+	MatchedJSON string  `json:"matched_conditions_json,omitempty"`
+	Symbol      string  `json:"symbol,omitempty"`
+	OrderID     string  `json:"order_id,omitempty"`
+	OrderSide   string  `json:"order_side,omitempty"`
+	OrderQty    float64 `json:"order_qty,omitempty"`
+	OrderPrice  float64 `json:"order_price,omitempty"`
+	Reason      string  `json:"reason,omitempty"` // why a trigger was blocked
+	DaemonID    string  `json:"daemon_id,omitempty"`
+	CreatedAt   string  `json:"created_at"`
 }
 
 // EngineConfig holds engine-level safety limits.
@@ -42,8 +46,10 @@ type EngineConfig struct {
 	DryRun          bool
 	MaxTriggersPerRulePerHour int
 	MaxOrdersPerSession       int
-	DaemonID        string
-	Safety          broker.SafetyConfig
+	DaemonID                  string
+	Safety                    broker.SafetyConfig
+	DataQuality               DataQualityConfig
+	Breaker                   BreakerConfig
 }
 
 // DefaultEngineConfig returns safe defaults.
@@ -51,9 +57,11 @@ func DefaultEngineConfig() EngineConfig {
 	return EngineConfig{
 		DryRun:                    false,
 		MaxTriggersPerRulePerHour: 10,
-		MaxOrdersPerSession:      50,
-		DaemonID:                 "",
-		Safety:                   broker.DefaultSafetyConfig(),
+		MaxOrdersPerSession:       50,
+		DaemonID:                  "",
+		Safety:                    broker.DefaultSafetyConfig(),
+		DataQuality:               DataQualityConfig{RejectDuplicates: true},
+		Breaker:                   DefaultBreakerConfig(),
 	}
 }
 
@@ -61,17 +69,50 @@ func DefaultEngineConfig() EngineConfig {
 type Engine struct {
 	mu           sync.RWMutex
 	rules        []*Rule
+	targets      []ruleTarget // rules fanned out per symbol
 	prevSnapshot *Snapshot
-	cooldowns    map[string]time.Time // rule_id → earliest next trigger
-	triggerCount map[string][]time.Time // rule_id → trigger timestamps (for hourly cap)
+	outlier      *Snapshot            // last snapshot a jump guard rejected since prevSnapshot
+	history      map[string][]float64 // kpi → recent values for indicators, oldest first
+	historyLen   map[string]int       // kpi → values to retain (from the rules' indicators)
+	cooldowns    map[string]time.Time // rule_id[:symbol] → earliest next trigger
+	triggerCount map[string][]time.Time // rule_id[:symbol] → trigger timestamps (for hourly cap)
 	sessionOrders int
-	broker       broker.Broker
-	config       EngineConfig
-	events       chan<- Event
+	broker        broker.Broker
+	config        EngineConfig
+	events        chan<- Event
+	outbox        *Outbox // durable copy of every event, when set
+	metrics       *Metrics
+	baseline      *broker.Baseline // day-start equity for the daily loss limit
+
+	// Runtime pauses set over the control socket (see control.go): no new
+	// entries engine-wide, or for the paused rule IDs. dryRunSwitch is dry-run
+	// switched on over the socket, as opposed to the --dry-run flag.
+	paused       bool
+	pausedRules  map[string]bool
+	dryRunSwitch bool
+
+	// Circuit breaker (see breaker.go): when open, no orders are placed.
+	breaker BreakerState
+
+	// Checkpointing (see state.go); savedState is the last state written.
+	statePath  string
+	savedState []byte
+
+	// Entry/exit lifecycle per rule+symbol (rules with exit conditions only)
+	ruleStates map[string]*RuleState
+
+	// Orders placed by the engine, for attributing trade updates (see reconcile.go)
+	orders map[string]*OrderRef
 
 	// Position copy-trade tracking
-	trackedPositions map[string]string // position_id → order_id (dedup)
+	trackedPositions map[string]string         // position_id → order_id (dedup)
+	copyAllocs       map[string]CopyAllocation // position_id → size and premium deployed
 	posFilter        *PositionFilter
+
+	// now is the engine clock; replaced by backtests and replays so that
+	// cooldowns, hourly caps, event timestamps and event IDs follow feed time.
+	now      func() time.Time
+	eventSeq int64
 }
 
 // NewEngine creates a new evaluation engine.
@@ -79,16 +120,56 @@ func NewEngine(b broker.Broker, cfg EngineConfig, events chan<- Event) *Engine {
 	return &Engine{
 		rules:            nil,
 		prevSnapshot:     nil,
+		history:          make(map[string][]float64),
+		historyLen:       make(map[string]int),
 		cooldowns:        make(map[string]time.Time),
 		triggerCount:     make(map[string][]time.Time),
 		broker:           b,
 		config:           cfg,
 		events:           events,
+		ruleStates:       make(map[string]*RuleState),
+		orders:           make(map[string]*OrderRef),
+		pausedRules:      make(map[string]bool),
 		trackedPositions: make(map[string]string),
+		copyAllocs:       make(map[string]CopyAllocation),
 		posFilter:        DefaultPositionFilter(),
+		baseline:         broker.OpenBaseline(""),
+		now:              time.Now,
 	}
 }
 
+// SetClock replaces the clock used for cooldowns, hourly caps and event timestamps.
+func (e *Engine) SetClock(now func() time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.now = now
+}
+
+// SetOutbox makes every emitted event durable: it is appended to o before
+// being sent on the events channel, which may drop it when full.
+func (e *Engine) SetOutbox(o *Outbox) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.outbox = o
+}
+
+// SetMetrics records engine metrics (evaluations, events, orders, safety
+// blocks and latencies) in m.
+func (e *Engine) SetMetrics(m *Metrics) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.metrics = m
+}
+
+// SetBaseline sets the day-start equity baseline the daily loss limit is
+// measured from. The daemon passes the profile's persisted baseline so the
+// limit survives restarts and counts losses from the CLI too.
+func (e *Engine) SetBaseline(b *broker.Baseline) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.baseline = b
+}
+
 // SetPositionFilter sets the position filter for copy-trade processing.
 func (e *Engine) SetPositionFilter(f *PositionFilter) {
 	e.mu.Lock()
@@ -111,46 +192,98 @@ func (e *Engine) TrackedPositions() map[string]string {
 func (e *Engine) ProcessPositionEvents(ctx context.Context, events []PositionEvent) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
+	defer e.checkpoint()
 
-	now := time.Now()
+	now := e.now()
 
 	for _, ev := range events {
-		// Apply filter
-		if !e.posFilter.Match(ev) {
+		// Apply filter. Entry criteria are checked when a position opens;
+		// a copy already open follows its position even if its greeks or
+		// quotes have since drifted outside them.
+		_, tracked := e.trackedPositions[ev.ID]
+		if !e.posFilter.Enabled || (!tracked && !e.posFilter.MatchAt(ev, now)) {
 			continue
 		}
 
 		switch ev.TradeStatus {
 		case "active":
 			// Already tracked? Skip (dedup)
-			if _, tracked := e.trackedPositions[ev.ID]; tracked {
+			if tracked {
+				continue
+			}
+
+			// Paused engine: copy no new entries (closes still go through)
+			if e.paused || e.halted() {
 				continue
 			}
 
 			// Session order cap
 			if e.sessionOrders >= e.config.MaxOrdersPerSession {
+				e.metrics.inc(metricSafetyBlocks, blockSessionCap)
+				e.emitEvent(Event{
+					EventID:   e.nextEventID(),
+					RuleID:    "position:" + ev.ID,
+					EventType: "order_failed",
+					Symbol:    ev.ContractName,
+					OrderSide: ev.EntrySide,
+					DaemonID:  e.config.DaemonID,
+					CreatedAt: now.UTC().Format(time.RFC3339),
+				})
+				continue
+			}
+
+			// Caps on open copied positions
+			if reason := e.concurrencyBlock(ev); reason != "" {
+				e.metrics.inc(metricSafetyBlocks, blockCopyCap)
 				e.emitEvent(Event{
-					EventID:   generateEventID(),
+					EventID:   e.nextEventID(),
 					RuleID:    "position:" + ev.ID,
 					EventType: "order_failed",
 					Symbol:    ev.ContractName,
 					OrderSide: ev.EntrySide,
+					Reason:    reason,
 					DaemonID:  e.config.DaemonID,
 					CreatedAt: now.UTC().Format(time.RFC3339),
 				})
+				log.Printf("[engine] position entry blocked: %s", reason)
+				continue
+			}
+
+			// Size the copy and fit it within the premium budgets
+			req := ev.ToEntryOrder(e.posFilter)
+			block, reason := blockOrderPrep, "sizing yields no contracts"
+			if req.Qty > 0 {
+				block = blockCopyBudget
+				req.Qty, reason = e.budgetQty(ev, req.Qty)
+			}
+			if req.Qty <= 0 {
+				e.metrics.inc(metricSafetyBlocks, block)
+				e.recordSafetyBlock(ctx, now)
+				e.emitEvent(Event{
+					EventID:   e.nextEventID(),
+					RuleID:    "position:" + ev.ID,
+					EventType: "order_failed",
+					Symbol:    ev.ContractName,
+					OrderSide: req.Side,
+					Reason:    reason,
+					DaemonID:  e.config.DaemonID,
+					CreatedAt: now.UTC().Format(time.RFC3339),
+				})
+				log.Printf("[engine] position entry blocked: %s", reason)
 				continue
 			}
 
 			if e.config.DryRun {
 				log.Printf("[dry-run] copy-trade entry: %s %s %s", ev.EntrySide, ev.ContractName, ev.Underlying)
 				e.trackedPositions[ev.ID] = "dry-run"
+				e.allocate(ev, req.Qty)
 				e.emitEvent(Event{
-					EventID:   generateEventID(),
+					EventID:   e.nextEventID(),
 					RuleID:    "position:" + ev.ID,
 					EventType: "entry_triggered",
 					Symbol:    ev.ContractName,
 					OrderSide: ev.EntrySide,
-					OrderQty:  e.posFilter.ScaleFactor,
+					OrderQty:  req.Qty,
 					DaemonID:  e.config.DaemonID,
 					CreatedAt: now.UTC().Format(time.RFC3339),
 				})
@@ -161,13 +294,12 @@ func (e *Engine) ProcessPositionEvents(ctx context.Context, events []PositionEve
 				continue
 			}
 
-			// Build entry order
-			req := ev.ToEntryOrder(e.posFilter)
-
 			// Safety validation
 			if err := broker.ValidateOrderLimits(req, e.config.Safety); err != nil {
+				e.metrics.inc(metricSafetyBlocks, blockOrderLimits)
+				e.recordSafetyBlock(ctx, now)
 				e.emitEvent(Event{
-					EventID:   generateEventID(),
+					EventID:   e.nextEventID(),
 					RuleID:    "position:" + ev.ID,
 					EventType: "order_failed",
 					Symbol:    ev.ContractName,
@@ -182,15 +314,13 @@ func (e *Engine) ProcessPositionEvents(ctx context.Context, events []PositionEve
 
 			// Daily loss check for buy orders
 			if req.Side == "buy" {
-				positions, pErr := e.broker.GetPositions(ctx)
-				if pErr == nil {
-					var totalPL float64
-					for _, pos := range positions {
-						totalPL += pos.UnrealizedPL
-					}
-					if dlErr := broker.ValidateDailyLoss(totalPL, e.config.Safety); dlErr != nil {
+				dayPL, plErr := e.dailyPL(ctx, now)
+				if plErr == nil {
+					if dlErr := broker.ValidateDailyLoss(dayPL, e.config.Safety); dlErr != nil {
+						e.metrics.inc(metricSafetyBlocks, blockDailyLoss)
+						e.recordSafetyBlock(ctx, now)
 						e.emitEvent(Event{
-							EventID:   generateEventID(),
+							EventID:   e.nextEventID(),
 							RuleID:    "position:" + ev.ID,
 							EventType: "order_failed",
 							Symbol:    ev.ContractName,
@@ -208,8 +338,10 @@ func (e *Engine) ProcessPositionEvents(ctx context.Context, events []PositionEve
 			// Place entry order
 			order, oErr := e.broker.CreateOrder(ctx, req)
 			if oErr != nil {
+				e.metrics.inc(metricOrders, "failed")
+				e.recordOrderResult(ctx, true, now)
 				e.emitEvent(Event{
-					EventID:   generateEventID(),
+					EventID:   e.nextEventID(),
 					RuleID:    "position:" + ev.ID,
 					EventType: "order_failed",
 					Symbol:    ev.ContractName,
@@ -223,10 +355,17 @@ func (e *Engine) ProcessPositionEvents(ctx context.Context, events []PositionEve
 			}
 
 			e.trackedPositions[ev.ID] = order.OrderID
+			e.allocate(ev, req.Qty)
 			e.sessionOrders++
+			e.metrics.inc(metricOrders, "placed")
+			e.recordOrderResult(ctx, false, now)
+			e.trackOrder(order, OrderRef{
+				RuleID: "position:" + ev.ID, Symbol: req.Symbol, Kind: orderCopyEntry,
+				Side: req.Side, PositionID: ev.ID, PlacedAt: now,
+			})
 
 			e.emitEvent(Event{
-				EventID:   generateEventID(),
+				EventID:   e.nextEventID(),
 				RuleID:    "position:" + ev.ID,
 				EventType: "order_placed",
 				Symbol:    ev.ContractName,
@@ -241,15 +380,20 @@ func (e *Engine) ProcessPositionEvents(ctx context.Context, events []PositionEve
 
 		case "closing":
 			// Not tracked? Nothing to close
-			if _, tracked := e.trackedPositions[ev.ID]; !tracked {
+			if !tracked {
+				continue
+			}
+			if e.halted() {
+				log.Printf("[engine] circuit breaker open, not closing copied position %s", ev.ID)
 				continue
 			}
 
 			if e.config.DryRun {
 				log.Printf("[dry-run] copy-trade exit: %s %s", ev.ContractName, ev.Underlying)
 				delete(e.trackedPositions, ev.ID)
+				delete(e.copyAllocs, ev.ID)
 				e.emitEvent(Event{
-					EventID:   generateEventID(),
+					EventID:   e.nextEventID(),
 					RuleID:    "position:" + ev.ID,
 					EventType: "exit_triggered",
 					Symbol:    ev.ContractName,
@@ -263,25 +407,39 @@ func (e *Engine) ProcessPositionEvents(ctx context.Context, events []PositionEve
 				continue
 			}
 
-			// Build exit order (reverse side)
+			// Build exit order (reverse side), closing what was entered
 			req := ev.ToExitOrder(e.posFilter)
+			if a, ok := e.copyAllocs[ev.ID]; ok {
+				req.Qty = a.Qty
+			}
 
 			if err := broker.ValidateOrderLimits(req, e.config.Safety); err != nil {
+				e.metrics.inc(metricSafetyBlocks, blockOrderLimits)
+				e.recordSafetyBlock(ctx, now)
 				log.Printf("[engine] position exit blocked by safety: %v", err)
 				continue
 			}
 
 			order, oErr := e.broker.CreateOrder(ctx, req)
 			if oErr != nil {
+				e.metrics.inc(metricOrders, "failed")
+				e.recordOrderResult(ctx, true, now)
 				log.Printf("[engine] position exit order failed: %v", oErr)
 				continue
 			}
 
 			delete(e.trackedPositions, ev.ID)
+			delete(e.copyAllocs, ev.ID)
 			e.sessionOrders++
+			e.metrics.inc(metricOrders, "placed")
+			e.recordOrderResult(ctx, false, now)
+			e.trackOrder(order, OrderRef{
+				RuleID: "position:" + ev.ID, Symbol: req.Symbol, Kind: orderCopyExit,
+				Side: req.Side, PositionID: ev.ID, PlacedAt: now,
+			})
 
 			e.emitEvent(Event{
-				EventID:   generateEventID(),
+				EventID:   e.nextEventID(),
 				RuleID:    "position:" + ev.ID,
 				EventType: "order_placed",
 				Symbol:    ev.ContractName,
@@ -297,6 +455,7 @@ func (e *Engine) ProcessPositionEvents(ctx context.Context, events []PositionEve
 		case "closed", "deprecated":
 			// Cleanup tracking
 			delete(e.trackedPositions, ev.ID)
+			delete(e.copyAllocs, ev.ID)
 		}
 	}
 }
@@ -305,7 +464,55 @@ func (e *Engine) ProcessPositionEvents(ctx context.Context, events []PositionEve
 func (e *Engine) SetRules(rules []*Rule) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
+	defer e.checkpoint()
 	e.rules = rules
+	e.targets = expandRules(rules)
+
+	// Keep lifecycle state for targets that still exist.
+	live := make(map[string]bool, len(e.targets))
+	for _, t := range e.targets {
+		if t.rule.tracksPosition() {
+			live[t.key] = true
+		}
+	}
+	for key := range e.ruleStates {
+		if !live[key] {
+			delete(e.ruleStates, key)
+		}
+	}
+
+	// Keep cooldowns and hourly caps for targets that still exist.
+	keys := make(map[string]bool, len(e.targets))
+	for _, t := range e.targets {
+		keys[t.key] = true
+	}
+	for key := range e.cooldowns {
+		if !keys[key] {
+			delete(e.cooldowns, key)
+		}
+	}
+	for key := range e.triggerCount {
+		if !keys[key] {
+			delete(e.triggerCount, key)
+		}
+	}
+	loaded := make(map[string]bool, len(rules))
+	for _, r := range rules {
+		loaded[r.RuleID] = true
+	}
+	for id := range e.pausedRules {
+		if !loaded[id] {
+			delete(e.pausedRules, id)
+		}
+	}
+
+	// Keep history for KPIs still used by an indicator; drop the rest.
+	e.historyLen = historyRequirements(e.targets)
+	for kpi := range e.history {
+		if _, ok := e.historyLen[kpi]; !ok {
+			delete(e.history, kpi)
+		}
+	}
 }
 
 // Rules returns the current ruleset.
@@ -328,84 +535,219 @@ func (e *Engine) SessionOrders() int {
 func (e *Engine) Evaluate(ctx context.Context, snap *Snapshot) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
+	defer e.checkpoint()
 
-	now := time.Now()
+	if e.metrics != nil {
+		start := time.Now()
+		defer func() { e.metrics.observe(metricEvalDuration, time.Since(start).Seconds()) }()
+	}
 
-	for _, r := range e.rules {
+	now := e.now()
+	snapReason := e.snapshotQuality(snap, now)
+	e.checkDrawdown(ctx, now)
+
+	for _, t := range e.targets {
+		r := t.rule
 		if r.Status != "active" {
 			continue
 		}
+		e.metrics.inc(metricEvaluations, r.Name)
+
+		// Position-tracking rules wait out their in-flight orders before
+		// evaluating anything else.
+		var st *RuleState
+		if r.tracksPosition() {
+			st = e.stateFor(t)
+			if st.State == StatePending || st.State == StateExiting {
+				e.refreshState(ctx, st, now)
+				if st.State == StatePending || st.State == StateExiting {
+					continue
+				}
+			}
+		}
 
-		// Check cooldown
-		if earliest, ok := e.cooldowns[r.RuleID]; ok && now.Before(earliest) {
+		// Tripped circuit breaker: nothing trades until resumed.
+		if e.halted() {
 			continue
 		}
 
-		// Check hourly trigger cap
-		if e.isHourlyCapReached(r.RuleID, now) {
-			e.emitEvent(Event{
-				EventID:   generateEventID(),
-				RuleID:    r.RuleID,
-				EventType: "cooldown_blocked",
-				DaemonID:  e.config.DaemonID,
-				CreatedAt: now.UTC().Format(time.RFC3339),
-			})
+		// Trading window: past flatten time, close whatever the rule holds,
+		// cooldown or not.
+		win := r.Temporal.window(now)
+		if win.flatten && st != nil && (st.State == StateLong || st.State == StateShort) {
+			e.handleTrigger(ctx, t, st, snap, "flatten_triggered", now)
 			continue
 		}
 
-		// Check session order cap
-		if e.sessionOrders >= e.config.MaxOrdersPerSession {
+		// Check cooldown
+		if earliest, ok := e.cooldowns[t.key]; ok && now.Before(earliest) {
 			continue
 		}
+		if !win.open {
+			continue
+		}
+
+		// Hourly and session caps limit new entries; closing a position the
+		// engine opened is never held back by them.
+		if st == nil || st.State == StateFlat {
+			// Check hourly trigger cap
+			if e.isHourlyCapReached(t.key, now) {
+				e.metrics.inc(metricSafetyBlocks, blockHourlyCap)
+				e.emitEvent(Event{
+					EventID:   e.nextEventID(),
+					RuleID:    r.RuleID,
+					EventType: "cooldown_blocked",
+					Symbol:    t.symbol,
+					DaemonID:  e.config.DaemonID,
+					CreatedAt: now.UTC().Format(time.RFC3339),
+				})
+				continue
+			}
+
+			// Check session order cap
+			if e.sessionOrders >= e.config.MaxOrdersPerSession {
+				e.metrics.inc(metricSafetyBlocks, blockSessionCap)
+				continue
+			}
+		}
 
-		// Evaluate entry conditions
-		if r.Entry != nil && e.evaluateGroup(r.Entry, snap, e.prevSnapshot) {
-			e.handleTrigger(ctx, r, snap, "entry_triggered", now)
+		// Evaluate entry conditions (suppressed while the rule holds a
+		// position or is paused)
+		entries := win.entries && !e.paused && !e.pausedRules[r.RuleID]
+		if entries && (st == nil || st.State == StateFlat) && t.entry != nil && e.evaluateGroup(t.entry, snap, e.prevSnapshot) {
+			if reason := e.targetQuality(t, snap, snapReason); reason != "" {
+				e.blockDataQuality(t, "entry", reason, now)
+				continue
+			}
+			e.handleTrigger(ctx, t, st, snap, "entry_triggered", now)
 			continue
 		}
 
-		// Evaluate exit conditions
-		if r.Exit != nil && e.evaluateGroup(r.Exit, snap, e.prevSnapshot) {
-			e.handleTrigger(ctx, r, snap, "exit_triggered", now)
+		// Evaluate exit conditions (only once the rule holds a position)
+		if st != nil && st.State == StateFlat {
+			continue
+		}
+		if t.exit != nil && e.evaluateGroup(t.exit, snap, e.prevSnapshot) {
+			// The position may have been closed outside the engine.
+			if st != nil && !e.config.DryRun && e.broker != nil && t.symbol != "" && !e.brokerHolds(ctx, t.symbol) {
+				log.Printf("[engine] rule %q: no %s position at broker, resetting to flat", r.Name, t.symbol)
+				*st = RuleState{RuleID: st.RuleID, Symbol: st.Symbol, State: StateFlat, UpdatedAt: now}
+				continue
+			}
+			if reason := e.targetQuality(t, snap, snapReason); reason != "" {
+				e.blockDataQuality(t, "exit", reason, now)
+				continue
+			}
+			e.handleTrigger(ctx, t, st, snap, "exit_triggered", now)
 		}
 	}
 
-	// Update previous snapshot for stateful operators
+	// Update indicator history and previous snapshot for stateful operators.
+	// Rejected snapshots are left out: a duplicate would double-count, and a
+	// stale or outlying value would skew indicators and make the next good
+	// snapshot look like a jump.
+	if snapReason != "" {
+		return
+	}
+	if e.isOutlier(snap) {
+		e.outlier = snap
+		return
+	}
+	e.outlier = nil
+	e.recordHistory(snap)
 	e.prevSnapshot = snap
 }
 
-func (e *Engine) handleTrigger(ctx context.Context, r *Rule, snap *Snapshot, eventType string, now time.Time) {
+// blockDataQuality reports a trigger suppressed by a data-quality guard.
+func (e *Engine) blockDataQuality(t ruleTarget, leg, reason string, now time.Time) {
+	e.metrics.inc(metricSafetyBlocks, blockDataQuality)
+	e.emitEvent(Event{
+		EventID:   e.nextEventID(),
+		RuleID:    t.rule.RuleID,
+		EventType: "data_quality_blocked",
+		Symbol:    t.symbol,
+		Reason:    reason,
+		DaemonID:  e.config.DaemonID,
+		CreatedAt: now.UTC().Format(time.RFC3339),
+	})
+	log.Printf("[engine] rule %q %s blocked by data quality: %s", t.rule.Name, leg, reason)
+}
+
+// dailyPL returns the account's realized plus unrealized P&L for the trading
+// day, measured from the baseline. Callers hold e.mu.
+func (e *Engine) dailyPL(ctx context.Context, now time.Time) (float64, error) {
+	acct, err := e.broker.GetAccount(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return e.baseline.DailyPL(acct, now)
+}
+
+func (e *Engine) handleTrigger(ctx context.Context, t ruleTarget, st *RuleState, snap *Snapshot, eventType string, now time.Time) {
+	r, symbol := t.rule, t.symbol
+
+	// Tracked exits close exactly what the rule opened.
+	side, qty := r.Order.Side, r.Order.Qty
+	closing := st != nil && (eventType == "exit_triggered" || eventType == "flatten_triggered")
+	if closing {
+		side, qty = closingSide(st.Side), st.Qty
+	}
+
+	// Dynamic sizing is resolved against the account at trigger time.
+	var prepErr error
+	if !closing && r.Order.Sizing != nil {
+		qty, prepErr = e.sizeOrder(ctx, r.Order.Sizing, symbol, snap)
+	}
+
 	// Set cooldown
-	e.cooldowns[r.RuleID] = now.Add(time.Duration(r.Cooldown) * time.Second)
+	e.cooldowns[t.key] = now.Add(time.Duration(r.Cooldown) * time.Second)
 
 	// Record trigger
-	e.triggerCount[r.RuleID] = append(e.triggerCount[r.RuleID], now)
+	e.triggerCount[t.key] = append(e.triggerCount[t.key], now)
 
 	triggerJSON, _ := json.Marshal(snap.KPIs)
 
-	// Determine symbol (first from rule's symbols list, or empty)
-	symbol := ""
-	if len(r.Symbols) > 0 {
-		symbol = r.Symbols[0]
+	// The evaluation tree shows which conditions matched.
+	var matchedJSON string
+	switch eventType {
+	case "entry_triggered":
+		matchedJSON = e.traceJSON(t.entry, snap)
+	case "exit_triggered":
+		matchedJSON = e.traceJSON(t.exit, snap)
 	}
 
 	// Emit trigger event
 	e.emitEvent(Event{
-		EventID:     generateEventID(),
+		EventID:     e.nextEventID(),
 		RuleID:      r.RuleID,
 		EventType:   eventType,
 		TriggerJSON: string(triggerJSON),
+		MatchedJSON: matchedJSON,
 		Symbol:      symbol,
-		OrderSide:   r.Order.Side,
-		OrderQty:    r.Order.Qty,
+		OrderSide:   side,
+		OrderQty:    qty,
 		DaemonID:    e.config.DaemonID,
 		CreatedAt:   now.UTC().Format(time.RFC3339),
 	})
 
-	// Dry-run: log but don't order
+	// Dry-run: log but don't order. An unsized order still advances the
+	// lifecycle, so the rule's exits are evaluated as they would be live.
 	if e.config.DryRun {
-		log.Printf("[dry-run] rule %q triggered (%s), would %s %.0f %s",
-			r.Name, eventType, r.Order.Side, r.Order.Qty, symbol)
+		if prepErr != nil {
+			log.Printf("[dry-run] rule %q triggered (%s), order not sized: %v", r.Name, eventType, prepErr)
+		} else {
+			log.Printf("[dry-run] rule %q triggered (%s), would %s %.0f %s",
+				r.Name, eventType, side, qty, symbol)
+		}
+		if st != nil {
+			// No orders to wait on: move straight to the held or flat state.
+			if closing {
+				*st = RuleState{RuleID: st.RuleID, Symbol: st.Symbol, State: StateFlat}
+			} else {
+				st.State, st.Side, st.Qty = openState(side), side, qty
+			}
+			st.UpdatedAt = now
+		}
 		return
 	}
 
@@ -417,21 +759,48 @@ func (e *Engine) handleTrigger(ctx context.Context, r *Rule, snap *Snapshot, eve
 	// Build order request
 	req := broker.OrderRequest{
 		Symbol: symbol,
-		Qty:    r.Order.Qty,
-		Side:   r.Order.Side,
+		Qty:    qty,
+		Side:   side,
 		Type:   r.Order.Type,
 		TIF:    r.Order.TIF,
 	}
 
+	// Entries carry the rule's take-profit/stop-loss legs.
+	if prepErr == nil && !closing {
+		prepErr = r.Order.attachExits(&req, symbol, snap)
+	}
+
+	if prepErr != nil {
+		e.metrics.inc(metricSafetyBlocks, blockOrderPrep)
+		e.recordSafetyBlock(ctx, now)
+		e.emitEvent(Event{
+			EventID:   e.nextEventID(),
+			RuleID:    r.RuleID,
+			EventType: "order_failed",
+			Symbol:    symbol,
+			OrderSide: side,
+			DaemonID:  e.config.DaemonID,
+			CreatedAt: now.UTC().Format(time.RFC3339),
+		})
+		log.Printf("[engine] order not prepared for rule %q: %v", r.Name, prepErr)
+		return
+	}
+
 	// Safety validation
-	if err := broker.ValidateOrderLimits(req, e.config.Safety); err != nil {
+	err := broker.ValidateOrderLimits(req, e.config.Safety)
+	if err == nil {
+		err = broker.ValidateOrderClass(req)
+	}
+	if err != nil {
+		e.metrics.inc(metricSafetyBlocks, blockOrderLimits)
+		e.recordSafetyBlock(ctx, now)
 		e.emitEvent(Event{
-			EventID:   generateEventID(),
+			EventID:   e.nextEventID(),
 			RuleID:    r.RuleID,
 			EventType: "order_failed",
 			Symbol:    symbol,
-			OrderSide: r.Order.Side,
-			OrderQty:  r.Order.Qty,
+			OrderSide: side,
+			OrderQty:  qty,
 			DaemonID:  e.config.DaemonID,
 			CreatedAt: now.UTC().Format(time.RFC3339),
 		})
@@ -439,22 +808,20 @@ func (e *Engine) handleTrigger(ctx context.Context, r *Rule, snap *Snapshot, eve
 		return
 	}
 
-	// Check daily loss before buy orders
-	if r.Order.Side == "buy" && e.broker != nil {
-		positions, err := e.broker.GetPositions(ctx)
+	// Check daily loss before buy orders (closing a short is always allowed)
+	if side == "buy" && !closing && e.broker != nil {
+		dayPL, err := e.dailyPL(ctx, now)
 		if err == nil {
-			var totalPL float64
-			for _, p := range positions {
-				totalPL += p.UnrealizedPL
-			}
-			if err := broker.ValidateDailyLoss(totalPL, e.config.Safety); err != nil {
+			if err := broker.ValidateDailyLoss(dayPL, e.config.Safety); err != nil {
+				e.metrics.inc(metricSafetyBlocks, blockDailyLoss)
+				e.recordSafetyBlock(ctx, now)
 				e.emitEvent(Event{
-					EventID:   generateEventID(),
+					EventID:   e.nextEventID(),
 					RuleID:    r.RuleID,
 					EventType: "order_failed",
 					Symbol:    symbol,
-					OrderSide: r.Order.Side,
-					OrderQty:  r.Order.Qty,
+					OrderSide: side,
+					OrderQty:  qty,
 					DaemonID:  e.config.DaemonID,
 					CreatedAt: now.UTC().Format(time.RFC3339),
 				})
@@ -467,13 +834,15 @@ func (e *Engine) handleTrigger(ctx context.Context, r *Rule, snap *Snapshot, eve
 	// Place order
 	order, err := e.broker.CreateOrder(ctx, req)
 	if err != nil {
+		e.metrics.inc(metricOrders, "failed")
+		e.recordOrderResult(ctx, true, now)
 		e.emitEvent(Event{
-			EventID:   generateEventID(),
+			EventID:   e.nextEventID(),
 			RuleID:    r.RuleID,
 			EventType: "order_failed",
 			Symbol:    symbol,
-			OrderSide: r.Order.Side,
-			OrderQty:  r.Order.Qty,
+			OrderSide: side,
+			OrderQty:  qty,
 			DaemonID:  e.config.DaemonID,
 			CreatedAt: now.UTC().Format(time.RFC3339),
 		})
@@ -482,28 +851,57 @@ func (e *Engine) handleTrigger(ctx context.Context, r *Rule, snap *Snapshot, eve
 	}
 
 	e.sessionOrders++
+	e.metrics.inc(metricOrders, "placed")
+	e.recordOrderResult(ctx, false, now)
+	kind := orderEntry
+	if closing {
+		kind = orderExit
+	}
+	e.trackOrder(order, OrderRef{
+		RuleID: r.RuleID, Symbol: symbol, Target: t.key, Kind: kind, Side: side, PlacedAt: now,
+	})
+	if !snap.receivedAt.IsZero() {
+		e.metrics.observe(metricOrderLatency, time.Since(snap.receivedAt).Seconds())
+	}
 
 	e.emitEvent(Event{
-		EventID:   generateEventID(),
+		EventID:   e.nextEventID(),
 		RuleID:    r.RuleID,
 		EventType: "order_placed",
 		Symbol:    symbol,
 		OrderID:   order.OrderID,
-		OrderSide: r.Order.Side,
-		OrderQty:  r.Order.Qty,
+		OrderSide: side,
+		OrderQty:  qty,
 		DaemonID:  e.config.DaemonID,
 		CreatedAt: now.UTC().Format(time.RFC3339),
 	})
 	log.Printf("[engine] order placed: %s %s %.0f %s (id=%s)",
-		r.Order.Side, symbol, r.Order.Qty, order.Status, order.OrderID)
+		side, symbol, qty, order.Status, order.OrderID)
+
+	if st != nil {
+		if closing {
+			st.State, st.ExitOrderID = StateExiting, order.OrderID
+		} else {
+			st.State, st.Side, st.Qty, st.EntryOrderID = StatePending, side, qty, order.OrderID
+		}
+		st.UpdatedAt = now
+		// Brokers that fill synchronously report it on the returned order.
+		e.applyOrderStatus(st, order, now)
+	}
 }
 
 func (e *Engine) emitEvent(ev Event) {
+	e.metrics.inc(metricEvents, ev.EventType)
+	if e.outbox != nil {
+		if err := e.outbox.Append(ev); err != nil {
+			log.Printf("[engine] outbox append failed for event %s: %v", ev.EventID, err)
+		}
+	}
 	if e.events != nil {
 		select {
 		case e.events <- ev:
 		default:
-			// Channel full, drop event
+			// Channel full: drop from the log stream (the outbox keeps it)
 		}
 	}
 }
@@ -549,72 +947,108 @@ func (e *Engine) evaluateCondition(c *ConditionOrGroup, snap, prev *Snapshot) bo
 		return false
 	}
 
-	// Leaf condition
-	if c.KPI == "" {
-		return false
-	}
+	return e.evalLeaf(c, snap, prev).passed
+}
 
-	val, ok := snap.KPIs[c.KPI]
-	if !ok {
-		// Missing KPI evaluates to false (fail-safe)
-		return false
+// leafResult is the outcome of one leaf condition, with the values it
+// compared. has* report which values could be computed.
+type leafResult struct {
+	passed              bool
+	val, target         float64
+	prevVal, prevTarget float64
+	hasVal, hasTarget   bool
+	hasPrev             bool
+}
+
+// evalLeaf evaluates a leaf condition. Indicators see the current snapshot
+// appended to the history; for crosses_* the previous snapshot sees the
+// history as it was.
+func (e *Engine) evalLeaf(c *ConditionOrGroup, snap, prev *Snapshot) leafResult {
+	var res leafResult
+	cur := &exprEnv{kpis: snap.KPIs, history: e.history, live: true}
+	res.val, res.hasVal = leafValue(c, cur)
+	res.target, res.hasTarget = leafTarget(c, cur)
+	if !res.hasVal || !res.hasTarget {
+		// Missing KPI or indicator still warming up evaluates to false (fail-safe)
+		return res
 	}
+	val, target := res.val, res.target
 
 	switch c.Operator {
 	case ">":
-		return val > c.Value
+		res.passed = val > target
 	case "<":
-		return val < c.Value
+		res.passed = val < target
 	case ">=":
-		return val >= c.Value
+		res.passed = val >= target
 	case "<=":
-		return val <= c.Value
+		res.passed = val <= target
 	case "==":
-		return math.Abs(val-c.Value) < 1e-9
+		res.passed = math.Abs(val-target) < 1e-9
 	case "!=":
-		return math.Abs(val-c.Value) >= 1e-9
-	case "crosses_above":
+		res.passed = math.Abs(val-target) >= 1e-9
+	case "crosses_above", "crosses_below":
 		if prev == nil {
-			return false
+			return res
 		}
-		prevVal, ok := prev.KPIs[c.KPI]
-		if !ok {
-			return false
-		}
-		return prevVal <= c.Value && val > c.Value
-	case "crosses_below":
-		if prev == nil {
-			return false
+		prevEnv := &exprEnv{kpis: prev.KPIs, history: e.history}
+		var okVal, okTarget bool
+		res.prevVal, okVal = leafValue(c, prevEnv)
+		res.prevTarget, okTarget = leafTarget(c, prevEnv)
+		res.hasPrev = okVal && okTarget
+		if !res.hasPrev {
+			return res
 		}
-		prevVal, ok := prev.KPIs[c.KPI]
-		if !ok {
-			return false
+		if c.Operator == "crosses_above" {
+			res.passed = res.prevVal <= res.prevTarget && val > target
+		} else {
+			res.passed = res.prevVal >= res.prevTarget && val < target
 		}
-		return prevVal >= c.Value && val < c.Value
-	default:
-		return false
 	}
+	return res
+}
+
+// leafValue resolves the left-hand side of a leaf condition: the KPI value or
+// the result of its expression. ok is false if it cannot be computed.
+func leafValue(c *ConditionOrGroup, env *exprEnv) (float64, bool) {
+	if c.Expr != "" {
+		return evalExpr(c.Expr, env)
+	}
+	if c.KPI == "" {
+		return 0, false
+	}
+	val, ok := env.kpis[c.KPI]
+	return val, ok
 }
 
-// isHourlyCapReached checks if a rule has been triggered too many times in the last hour.
-func (e *Engine) isHourlyCapReached(ruleID string, now time.Time) bool {
+// leafTarget resolves the right-hand side of a leaf condition: ValueExpr when
+// set, otherwise the constant Value.
+func leafTarget(c *ConditionOrGroup, env *exprEnv) (float64, bool) {
+	if c.ValueExpr != "" {
+		return evalExpr(c.ValueExpr, env)
+	}
+	return c.Value, true
+}
+
+// isHourlyCapReached checks if a rule (or rule+symbol) has been triggered too many times in the last hour.
+func (e *Engine) isHourlyCapReached(key string, now time.Time) bool {
 	cutoff := now.Add(-1 * time.Hour)
 	var recent []time.Time
-	for _, t := range e.triggerCount[ruleID] {
+	for _, t := range e.triggerCount[key] {
 		if t.After(cutoff) {
 			recent = append(recent, t)
 		}
 	}
-	e.triggerCount[ruleID] = recent
+	e.triggerCount[key] = recent
 	return len(recent) >= e.config.MaxTriggersPerRulePerHour
 }
 
-var eventSeq atomic.Int64
-
-// generateEventID creates a short unique ID for events.
-func generateEventID() string {
-	seq := eventSeq.Add(1)
-	return fmt.Sprintf("evt_%d_%d", time.Now().UnixNano(), seq)
+// nextEventID creates a short unique ID for events. IDs derive from the
+// engine clock and a per-engine sequence, so a replay with the same clock
+// produces the same IDs. Callers must hold e.mu.
+func (e *Engine) nextEventID() string {
+	e.eventSeq++
+	return fmt.Sprintf("evt_%d_%d", e.now().UnixNano(), e.eventSeq)
 }
 
 // ParseSnapshot converts a raw WebSocket JSON message to a Snapshot.
@@ -3,6 +3,9 @@ package signal
 import (
 	"context"
 	"testing"
+	"time"
+
+	"github.com/haiphen/haiphen-cli/internal/broker"
 )
 
 func TestEvaluateGroup_AllOf(t *testing.T) {
@@ -144,6 +147,36 @@ func TestEvaluateCondition_MissingKPI(t *testing.T) {
 	}
 }
 
+func TestEvaluateCondition_Expr(t *testing.T) {
+	engine := NewEngine(nil, DefaultEngineConfig(), nil)
+	snap := &Snapshot{KPIs: map[string]float64{"Unrealized P&L": -300, "Portfolio Value": 10000}}
+
+	c := &ConditionOrGroup{Expr: `"Unrealized P&L" / "Portfolio Value"`, Operator: "<", Value: -0.02}
+	if !engine.evaluateCondition(c, snap, nil) {
+		t.Fatal("expected expr condition to match")
+	}
+
+	snap.KPIs["Portfolio Value"] = 0
+	if engine.evaluateCondition(c, snap, nil) {
+		t.Fatal("division by zero should evaluate to false")
+	}
+}
+
+func TestEvaluateCondition_ExprCrossesAbove(t *testing.T) {
+	engine := NewEngine(nil, DefaultEngineConfig(), nil)
+
+	prev := &Snapshot{KPIs: map[string]float64{"A": 1, "B": 2}}
+	curr := &Snapshot{KPIs: map[string]float64{"A": 3, "B": 2}}
+
+	c := &ConditionOrGroup{Expr: "A - B", Operator: "crosses_above", Value: 0}
+	if !engine.evaluateCondition(c, curr, prev) {
+		t.Fatal("expected A - B to cross above 0")
+	}
+	if engine.evaluateCondition(c, curr, curr) {
+		t.Fatal("should not trigger when already above")
+	}
+}
+
 func TestEvaluateGroup_NestedConditions(t *testing.T) {
 	engine := NewEngine(nil, DefaultEngineConfig(), nil)
 
@@ -329,3 +362,27 @@ func TestParseSnapshot_WrongType(t *testing.T) {
 		t.Fatal("expected error for non-snapshot type")
 	}
 }
+
+func TestEngine_DailyLossCountsRealizedLosses(t *testing.T) {
+	// No open positions, but the account closed the day's trades $11k down.
+	b := &sizingBroker{acct: broker.Account{Equity: 89000, LastEquity: 100000, IsPaper: true}}
+	events := make(chan Event, 16)
+	engine := NewEngine(b, DefaultEngineConfig(), events)
+	engine.SetRules([]*Rule{qualityRule()})
+
+	engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"Go": 1}})
+	if len(b.orders) != 0 {
+		t.Fatalf("expected daily loss limit to block the order, got %+v", b.orders)
+	}
+	if failed := eventsOfType(drainEvents(events), "order_failed"); len(failed) != 1 {
+		t.Fatalf("order_failed events = %d, want 1", len(failed))
+	}
+
+	// Recovering above the limit lets entries through again.
+	b.acct.Equity = 95000
+	engine.cooldowns = map[string]time.Time{}
+	engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"Go": 1}})
+	if len(b.orders) != 1 {
+		t.Fatalf("orders = %d, want 1 once the daily P&L recovers", len(b.orders))
+	}
+}
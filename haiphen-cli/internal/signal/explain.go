@@ -0,0 +1,167 @@
+package signal
+
+import "encoding/json"
+
+// TraceNode is one node of a rule's evaluation tree: a group (all_of/any_of)
+// with children, or a leaf with the values it compared. Children after the
+// node that decided a group are marked Skipped, mirroring the engine's
+// short-circuit evaluation.
+type TraceNode struct {
+	Kind    string `json:"kind"` // all_of, any_of or leaf
+	Passed  bool   `json:"passed"`
+	Skipped bool   `json:"skipped,omitempty"`
+
+	// Leaf fields
+	KPI        string   `json:"kpi,omitempty"`
+	Expr       string   `json:"expr,omitempty"`
+	Operator   string   `json:"operator,omitempty"`
+	Value      *float64 `json:"value,omitempty"`
+	Threshold  *float64 `json:"threshold,omitempty"`
+	ValueExpr  string   `json:"value_expr,omitempty"`
+	PrevValue  *float64 `json:"prev_value,omitempty"`
+	PrevThresh *float64 `json:"prev_threshold,omitempty"`
+	Reason     string   `json:"reason,omitempty"` // why a leaf could not be evaluated
+
+	Children []*TraceNode `json:"children,omitempty"`
+}
+
+// RuleTrace is the evaluation tree of one rule target against a snapshot.
+type RuleTrace struct {
+	RuleID string     `json:"rule_id"`
+	Name   string     `json:"name"`
+	Symbol string     `json:"symbol,omitempty"`
+	Entry  *TraceNode `json:"entry,omitempty"`
+	Exit   *TraceNode `json:"exit,omitempty"`
+}
+
+// Explain evaluates every active rule against snap without triggering
+// anything and returns the full evaluation trees. Cooldowns, lifecycle state
+// and trading windows are not applied.
+func (e *Engine) Explain(snap *Snapshot) []RuleTrace {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	var out []RuleTrace
+	for _, t := range e.targets {
+		if t.rule.Status != "active" {
+			continue
+		}
+		out = append(out, RuleTrace{
+			RuleID: t.rule.RuleID,
+			Name:   t.rule.Name,
+			Symbol: t.symbol,
+			Entry:  e.traceGroup(t.entry, snap, e.prevSnapshot),
+			Exit:   e.traceGroup(t.exit, snap, e.prevSnapshot),
+		})
+	}
+	return out
+}
+
+// traceJSON is the JSON evaluation tree of g, for Event.MatchedJSON.
+// Callers hold e.mu.
+func (e *Engine) traceJSON(g *ConditionGroup, snap *Snapshot) string {
+	n := e.traceGroup(g, snap, e.prevSnapshot)
+	if n == nil {
+		return ""
+	}
+	data, _ := json.Marshal(n)
+	return string(data)
+}
+
+// traceGroup builds the evaluation tree of a condition group.
+func (e *Engine) traceGroup(g *ConditionGroup, snap, prev *Snapshot) *TraceNode {
+	if g == nil {
+		return nil
+	}
+	if len(g.AllOf) > 0 {
+		return e.traceItems("all_of", g.AllOf, snap, prev)
+	}
+	return e.traceItems("any_of", g.AnyOf, snap, prev)
+}
+
+// traceItems evaluates a group's items in order. all_of stops at the first
+// failure, any_of at the first pass; later items are recorded as skipped.
+func (e *Engine) traceItems(kind string, items []ConditionOrGroup, snap, prev *Snapshot) *TraceNode {
+	node := &TraceNode{Kind: kind, Passed: kind == "all_of" && len(items) > 0}
+	decided := false
+	for i := range items {
+		if decided {
+			node.Children = append(node.Children, skippedNode(&items[i]))
+			continue
+		}
+		child := e.traceCondition(&items[i], snap, prev)
+		node.Children = append(node.Children, child)
+		if kind == "all_of" && !child.Passed {
+			node.Passed, decided = false, true
+		}
+		if kind == "any_of" && child.Passed {
+			node.Passed, decided = true, true
+		}
+	}
+	return node
+}
+
+func (e *Engine) traceCondition(c *ConditionOrGroup, snap, prev *Snapshot) *TraceNode {
+	if len(c.AllOf) > 0 {
+		return e.traceItems("all_of", c.AllOf, snap, prev)
+	}
+	if len(c.AnyOf) > 0 {
+		return e.traceItems("any_of", c.AnyOf, snap, prev)
+	}
+
+	node := leafNode(c)
+	res := e.evalLeaf(c, snap, prev)
+	node.Passed = res.passed
+	if res.hasVal {
+		node.Value = floatPtr(res.val)
+	}
+	if res.hasTarget {
+		node.Threshold = floatPtr(res.target)
+	}
+	if res.hasPrev {
+		node.PrevValue, node.PrevThresh = floatPtr(res.prevVal), floatPtr(res.prevTarget)
+	}
+	switch {
+	case !res.hasVal && c.Expr != "":
+		node.Reason = "expression not computable (missing KPI or indicator warming up)"
+	case !res.hasVal:
+		node.Reason = "KPI missing from snapshot"
+	case !res.hasTarget:
+		node.Reason = "value_expr not computable"
+	case (c.Operator == "crosses_above" || c.Operator == "crosses_below") && prev == nil:
+		node.Reason = "no previous snapshot"
+	case (c.Operator == "crosses_above" || c.Operator == "crosses_below") && !res.hasPrev:
+		node.Reason = "previous value not computable"
+	}
+	return node
+}
+
+// skippedNode records a condition that short-circuiting never evaluated.
+func skippedNode(c *ConditionOrGroup) *TraceNode {
+	if len(c.AllOf) > 0 || len(c.AnyOf) > 0 {
+		kind, items := "all_of", c.AllOf
+		if len(c.AllOf) == 0 {
+			kind, items = "any_of", c.AnyOf
+		}
+		node := &TraceNode{Kind: kind, Skipped: true}
+		for i := range items {
+			node.Children = append(node.Children, skippedNode(&items[i]))
+		}
+		return node
+	}
+	node := leafNode(c)
+	node.Skipped = true
+	return node
+}
+
+func leafNode(c *ConditionOrGroup) *TraceNode {
+	return &TraceNode{
+		Kind:      "leaf",
+		KPI:       c.KPI,
+		Expr:      c.Expr,
+		Operator:  c.Operator,
+		ValueExpr: c.ValueExpr,
+	}
+}
+
+func floatPtr(v float64) *float64 { return &v }
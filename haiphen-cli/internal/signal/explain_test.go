@@ -0,0 +1,80 @@
+package signal
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestEngine_Explain(t *testing.T) {
+	engine := NewEngine(nil, DefaultEngineConfig(), make(chan Event, 16))
+	engine.SetRules([]*Rule{{
+		RuleID: "r1",
+		Name:   "explain",
+		Status: "active",
+		Entry: &ConditionGroup{AllOf: []ConditionOrGroup{
+			{KPI: "A", Operator: ">", Value: 1},
+			{KPI: "B", Operator: "<", Value: 0},
+			{AnyOf: []ConditionOrGroup{{KPI: "C", Operator: ">", Value: 0}}},
+		}},
+		Exit: &ConditionGroup{AnyOf: []ConditionOrGroup{
+			{KPI: "Missing", Operator: ">", Value: 0},
+			{KPI: "A", Operator: "crosses_above", Value: 1},
+		}},
+		Order:    OrderParams{Side: "buy", Type: "market", Qty: 1, TIF: "day"},
+		Cooldown: 60,
+	}})
+
+	traces := engine.Explain(&Snapshot{KPIs: map[string]float64{"A": 2, "B": 5, "C": 1}})
+	if len(traces) != 1 {
+		t.Fatalf("traces = %d", len(traces))
+	}
+	entry := traces[0].Entry
+	if entry.Kind != "all_of" || entry.Passed || len(entry.Children) != 3 {
+		t.Fatalf("entry = %+v", entry)
+	}
+	a, b, nested := entry.Children[0], entry.Children[1], entry.Children[2]
+	if !a.Passed || a.Value == nil || *a.Value != 2 || *a.Threshold != 1 {
+		t.Errorf("leaf A = %+v", a)
+	}
+	if b.Passed || b.Skipped {
+		t.Errorf("leaf B should fail: %+v", b)
+	}
+	if !nested.Skipped || !nested.Children[0].Skipped {
+		t.Errorf("items after the failing leaf should be skipped: %+v", nested)
+	}
+
+	exit := traces[0].Exit
+	if exit.Passed || exit.Children[0].Reason != "KPI missing from snapshot" || exit.Children[1].Reason != "no previous snapshot" {
+		t.Fatalf("exit = %+v %+v", exit.Children[0], exit.Children[1])
+	}
+}
+
+func TestEngine_EntryEventCarriesTrace(t *testing.T) {
+	events := make(chan Event, 16)
+	engine := NewEngine(nil, EngineConfig{DryRun: true, MaxTriggersPerRulePerHour: 10, MaxOrdersPerSession: 10}, events)
+	engine.SetRules([]*Rule{{
+		RuleID:   "r1",
+		Name:     "cross",
+		Status:   "active",
+		Entry:    &ConditionGroup{AllOf: []ConditionOrGroup{{KPI: "A", Operator: "crosses_above", Value: 10}}},
+		Order:    OrderParams{Side: "buy", Type: "market", Qty: 1, TIF: "day"},
+		Cooldown: 60,
+	}})
+
+	engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"A": 9}})
+	engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"A": 11}})
+
+	evs := drainEvents(events)
+	if len(evs) != 1 || evs[0].EventType != "entry_triggered" {
+		t.Fatalf("events = %+v", evs)
+	}
+	var tree TraceNode
+	if err := json.Unmarshal([]byte(evs[0].MatchedJSON), &tree); err != nil {
+		t.Fatalf("MatchedJSON: %v (%q)", err, evs[0].MatchedJSON)
+	}
+	leaf := tree.Children[0]
+	if !tree.Passed || !leaf.Passed || *leaf.PrevValue != 9 || *leaf.Value != 11 {
+		t.Fatalf("trace = %+v, leaf = %+v", tree, leaf)
+	}
+}
@@ -0,0 +1,454 @@
+package signal
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Expressions let a condition leaf compare a computed value instead of a
+// single KPI, e.g.
+//
+//	expr: '"Unrealized P&L" / "Portfolio Value"'
+//	operator: "<"
+//	value: -0.02
+//
+// Supported: + - * /, unary minus, parentheses, numeric literals, abs(x),
+// min(a, b, ...), max(a, b, ...). KPI names are double-quoted; names made only
+// of letters, digits, '_' and '.' may be written bare.
+//
+// Rolling-window indicators take a KPI and a window length N and read the
+// per-KPI history kept by the Engine (indicators.go): sma, ema, stddev,
+// zscore, pct_change, highest and lowest. Until a KPI has enough history the
+// indicator cannot be computed, so the condition evaluates to false.
+
+const maxExprLen = 500
+
+// exprEnv is what an expression is evaluated against: a snapshot's KPI values
+// plus the rolling history of earlier values.
+type exprEnv struct {
+	kpis    map[string]float64
+	history map[string][]float64 // oldest first; excludes kpis unless live is false
+	live    bool                 // kpis is the snapshot being evaluated and not yet in history
+}
+
+// series returns the values of kpi up to and including this snapshot, oldest first.
+func (env *exprEnv) series(kpi string) []float64 {
+	h := env.history[kpi]
+	if env.live {
+		if v, ok := env.kpis[kpi]; ok {
+			return append(h[:len(h):len(h)], v)
+		}
+	}
+	return h
+}
+
+// exprNode is a parsed expression.
+type exprNode interface {
+	// eval returns false when a referenced KPI is missing, an indicator is
+	// still warming up, or the result is not a finite number (e.g. division by zero).
+	eval(env *exprEnv) (float64, bool)
+	walkKPIs(fn func(string))
+	// walkWindows reports every rolling window with the history it needs.
+	walkWindows(fn func(kpi string, lookback int))
+}
+
+type numNode float64
+
+func (n numNode) eval(*exprEnv) (float64, bool) { return float64(n), true }
+func (n numNode) walkKPIs(func(string))         {}
+func (n numNode) walkWindows(func(string, int)) {}
+
+type kpiNode string
+
+func (n kpiNode) eval(env *exprEnv) (float64, bool) {
+	v, ok := env.kpis[string(n)]
+	return v, ok
+}
+func (n kpiNode) walkKPIs(fn func(string))      { fn(string(n)) }
+func (n kpiNode) walkWindows(func(string, int)) {}
+
+type negNode struct{ x exprNode }
+
+func (n negNode) eval(env *exprEnv) (float64, bool) {
+	v, ok := n.x.eval(env)
+	return -v, ok
+}
+func (n negNode) walkKPIs(fn func(string))         { n.x.walkKPIs(fn) }
+func (n negNode) walkWindows(fn func(string, int)) { n.x.walkWindows(fn) }
+
+type binNode struct {
+	op   byte
+	l, r exprNode
+}
+
+func (n binNode) eval(env *exprEnv) (float64, bool) {
+	l, ok := n.l.eval(env)
+	if !ok {
+		return 0, false
+	}
+	r, ok := n.r.eval(env)
+	if !ok {
+		return 0, false
+	}
+	var v float64
+	switch n.op {
+	case '+':
+		v = l + r
+	case '-':
+		v = l - r
+	case '*':
+		v = l * r
+	case '/':
+		if r == 0 {
+			return 0, false
+		}
+		v = l / r
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, false
+	}
+	return v, true
+}
+func (n binNode) walkKPIs(fn func(string)) { n.l.walkKPIs(fn); n.r.walkKPIs(fn) }
+func (n binNode) walkWindows(fn func(string, int)) {
+	n.l.walkWindows(fn)
+	n.r.walkWindows(fn)
+}
+
+type callNode struct {
+	fn   string
+	args []exprNode
+}
+
+func (n callNode) eval(env *exprEnv) (float64, bool) {
+	vals := make([]float64, len(n.args))
+	for i, a := range n.args {
+		v, ok := a.eval(env)
+		if !ok {
+			return 0, false
+		}
+		vals[i] = v
+	}
+	switch n.fn {
+	case "abs":
+		return math.Abs(vals[0]), true
+	case "min":
+		m := vals[0]
+		for _, v := range vals[1:] {
+			m = math.Min(m, v)
+		}
+		return m, true
+	case "max":
+		m := vals[0]
+		for _, v := range vals[1:] {
+			m = math.Max(m, v)
+		}
+		return m, true
+	}
+	return 0, false
+}
+func (n callNode) walkKPIs(fn func(string)) {
+	for _, a := range n.args {
+		a.walkKPIs(fn)
+	}
+}
+func (n callNode) walkWindows(fn func(string, int)) {
+	for _, a := range n.args {
+		a.walkWindows(fn)
+	}
+}
+
+// exprCache holds parsed expressions keyed by source; rules are evaluated on
+// every snapshot, so each expression is parsed once.
+var exprCache sync.Map
+
+// compileExpr parses src, reusing a cached parse when available.
+func compileExpr(src string) (exprNode, error) {
+	if n, ok := exprCache.Load(src); ok {
+		return n.(exprNode), nil
+	}
+	n, err := parseExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	exprCache.Store(src, n)
+	return n, nil
+}
+
+// EvalExpr evaluates an expression against a set of KPI values. ok is false if
+// the expression is invalid, references a missing KPI or divides by zero.
+// Without history, rolling-window indicators are never computable.
+func EvalExpr(src string, kpis map[string]float64) (float64, bool) {
+	return evalExpr(src, &exprEnv{kpis: kpis})
+}
+
+func evalExpr(src string, env *exprEnv) (float64, bool) {
+	n, err := compileExpr(src)
+	if err != nil {
+		return 0, false
+	}
+	return n.eval(env)
+}
+
+// ExprKPIs returns the KPI names referenced by an expression, in order of
+// first appearance.
+func ExprKPIs(src string) ([]string, error) {
+	n, err := compileExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	seen := make(map[string]bool)
+	n.walkKPIs(func(k string) {
+		if !seen[k] {
+			seen[k] = true
+			out = append(out, k)
+		}
+	})
+	return out, nil
+}
+
+func parseExpr(src string) (exprNode, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, fmt.Errorf("empty expression")
+	}
+	if len(src) > maxExprLen {
+		return nil, fmt.Errorf("expression exceeds %d characters", maxExprLen)
+	}
+	p := &exprParser{src: src}
+	n, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected %q", p.src[p.pos])
+	}
+	return n, nil
+}
+
+// exprParser is a recursive-descent parser over:
+//
+//	sum     = product { ("+" | "-") product }
+//	product = unary { ("*" | "/") unary }
+//	unary   = "-" unary | primary
+//	primary = number | kpi | func "(" sum { "," sum } ")" | window "(" kpi "," int ")" | "(" sum ")"
+type exprParser struct {
+	src string
+	pos int
+}
+
+func (p *exprParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("at position %d: %s", p.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// peek returns the next non-space byte, or 0 at end of input.
+func (p *exprParser) peek() byte {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *exprParser) parseSum() (exprNode, error) {
+	l, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek()
+		if op != '+' && op != '-' {
+			return l, nil
+		}
+		p.pos++
+		r, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		l = binNode{op: op, l: l, r: r}
+	}
+}
+
+func (p *exprParser) parseProduct() (exprNode, error) {
+	l, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek()
+		if op != '*' && op != '/' {
+			return l, nil
+		}
+		p.pos++
+		r, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l = binNode{op: op, l: l, r: r}
+	}
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if p.peek() == '-' {
+		p.pos++
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return negNode{x: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	c := p.peek()
+	switch {
+	case c == 0:
+		return nil, p.errorf("unexpected end of expression")
+
+	case c == '(':
+		p.pos++
+		n, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, p.errorf("expected ')'")
+		}
+		p.pos++
+		return n, nil
+
+	case c == '"':
+		start := p.pos
+		end := strings.IndexByte(p.src[p.pos+1:], '"')
+		if end < 0 {
+			return nil, p.errorf("unterminated KPI name")
+		}
+		name := p.src[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		if name == "" || len(name) > 100 {
+			p.pos = start
+			return nil, p.errorf("KPI name must be 1-100 characters")
+		}
+		return kpiNode(name), nil
+
+	case c == '.' || (c >= '0' && c <= '9'):
+		start := p.pos
+		for p.pos < len(p.src) && (p.src[p.pos] == '.' || (p.src[p.pos] >= '0' && p.src[p.pos] <= '9')) {
+			p.pos++
+		}
+		lit := p.src[start:p.pos]
+		v, err := strconv.ParseFloat(lit, 64)
+		if err != nil {
+			p.pos = start
+			return nil, p.errorf("invalid number %q", lit)
+		}
+		return numNode(v), nil
+
+	case isIdentByte(c):
+		start := p.pos
+		for p.pos < len(p.src) && isIdentByte(p.src[p.pos]) {
+			p.pos++
+		}
+		name := p.src[start:p.pos]
+		if p.peek() != '(' {
+			return kpiNode(name), nil
+		}
+		return p.parseCall(name)
+	}
+	return nil, p.errorf("unexpected %q", c)
+}
+
+func (p *exprParser) parseCall(name string) (exprNode, error) {
+	fn := strings.ToLower(name)
+	if windowFuncs[fn] {
+		return p.parseWindow(fn)
+	}
+	if fn != "abs" && fn != "min" && fn != "max" {
+		return nil, p.errorf("unknown function %q (supported: abs, min, max, %s)", name, windowFuncList)
+	}
+	p.pos++ // '('
+
+	var args []exprNode
+	for {
+		a, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, a)
+		c := p.peek()
+		if c == ',' {
+			p.pos++
+			continue
+		}
+		if c != ')' {
+			return nil, p.errorf("expected ',' or ')'")
+		}
+		p.pos++
+		break
+	}
+
+	if fn == "abs" && len(args) != 1 {
+		return nil, p.errorf("abs takes exactly one argument")
+	}
+	if fn != "abs" && len(args) < 2 {
+		return nil, p.errorf("%s takes at least two arguments", fn)
+	}
+	return callNode{fn: fn, args: args}, nil
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '.' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// parseWindow parses the arguments of a rolling-window indicator:
+// a KPI reference and an integer window length.
+func (p *exprParser) parseWindow(fn string) (exprNode, error) {
+	p.pos++ // '('
+
+	arg, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	kpi, ok := arg.(kpiNode)
+	if !ok {
+		return nil, p.errorf("%s: first argument must be a KPI name", fn)
+	}
+	if p.peek() != ',' {
+		return nil, p.errorf("%s takes a KPI and a window length", fn)
+	}
+	p.pos++
+
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+		p.pos++
+	}
+	n, err := strconv.Atoi(p.src[start:p.pos])
+	if err != nil || n < 1 || n > maxWindow {
+		p.pos = start
+		return nil, p.errorf("%s: window length must be an integer from 1 to %d", fn, maxWindow)
+	}
+	if fn == "stddev" || fn == "zscore" {
+		if n < 2 {
+			p.pos = start
+			return nil, p.errorf("%s: window length must be at least 2", fn)
+		}
+	}
+
+	if p.peek() != ')' {
+		return nil, p.errorf("expected ')'")
+	}
+	p.pos++
+	return windowNode{fn: fn, kpi: string(kpi), n: n}, nil
+}
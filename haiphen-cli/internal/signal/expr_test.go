@@ -0,0 +1,81 @@
+package signal
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEvalExpr(t *testing.T) {
+	kpis := map[string]float64{
+		"Unrealized P&L":  -300,
+		"Portfolio Value": 10000,
+		"A":               5,
+		"B":               2,
+		"Delta":           -0.4,
+	}
+
+	tests := []struct {
+		expr string
+		want float64
+	}{
+		{`"Unrealized P&L" / "Portfolio Value"`, -0.03},
+		{`A - B`, 3},
+		{`A + B * 2`, 9},
+		{`(A + B) * 2`, 14},
+		{`-A + 1`, -4},
+		{`A / B / 2`, 1.25},
+		{`abs(Delta)`, 0.4},
+		{`min(A, B, 1.5)`, 1.5},
+		{`max(A, "B" * 3)`, 6},
+		{`.5 * A`, 2.5},
+	}
+	for _, tt := range tests {
+		got, ok := EvalExpr(tt.expr, kpis)
+		if !ok {
+			t.Errorf("EvalExpr(%s) not ok", tt.expr)
+			continue
+		}
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("EvalExpr(%s) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestEvalExpr_NotComputable(t *testing.T) {
+	kpis := map[string]float64{"A": 1, "Zero": 0}
+	for _, expr := range []string{`A / Zero`, `A + Missing`, `abs(Missing)`, `A +`} {
+		if _, ok := EvalExpr(expr, kpis); ok {
+			t.Errorf("EvalExpr(%s) should not be ok", expr)
+		}
+	}
+}
+
+func TestParseExpr_Errors(t *testing.T) {
+	for _, expr := range []string{
+		``,
+		`A +`,
+		`(A + B`,
+		`"unterminated`,
+		`""`,
+		`sqrt(A)`,
+		`abs(A, B)`,
+		`min(A)`,
+		`A B`,
+		`1.2.3`,
+		`A ^ 2`,
+	} {
+		if _, err := parseExpr(expr); err == nil {
+			t.Errorf("parseExpr(%q) should fail", expr)
+		}
+	}
+}
+
+func TestExprKPIs(t *testing.T) {
+	got, err := ExprKPIs(`max("Unrealized P&L", A) - A / 2`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != "Unrealized P&L" || got[1] != "A" {
+		t.Fatalf("ExprKPIs = %v", got)
+	}
+}
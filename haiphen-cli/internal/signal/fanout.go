@@ -0,0 +1,102 @@
+package signal
+
+import "strings"
+
+// SymbolPlaceholder is replaced with each of a rule's symbols in KPI names and
+// expressions, so one rule can read symbol-scoped KPIs such as "{symbol}.rsi".
+const SymbolPlaceholder = "{symbol}"
+
+// ruleTarget is one (rule, symbol) pair the engine evaluates. A rule listing
+// several symbols fans out into one target per symbol, each with its own
+// resolved conditions, cooldown and hourly cap.
+type ruleTarget struct {
+	rule   *Rule
+	symbol string // "" for rules without symbols
+	key    string // cooldown / hourly-cap key
+	entry  *ConditionGroup
+	exit   *ConditionGroup
+}
+
+// expandRules returns the evaluation targets for a ruleset, in rule order.
+func expandRules(rules []*Rule) []ruleTarget {
+	var out []ruleTarget
+	for _, r := range rules {
+		if len(r.Symbols) == 0 {
+			out = append(out, ruleTarget{rule: r, key: r.RuleID, entry: r.Entry, exit: r.Exit})
+			continue
+		}
+		for _, sym := range r.Symbols {
+			out = append(out, ruleTarget{
+				rule:   r,
+				symbol: sym,
+				key:    r.RuleID + ":" + sym,
+				entry:  r.Entry.ForSymbol(sym),
+				exit:   r.Exit.ForSymbol(sym),
+			})
+		}
+	}
+	return out
+}
+
+// UsesSymbolPlaceholder reports whether any condition references {symbol}.
+func (r *Rule) UsesSymbolPlaceholder() bool {
+	found := false
+	check := func(c *ConditionOrGroup) {
+		for _, s := range []string{c.KPI, c.Expr, c.ValueExpr} {
+			if strings.Contains(s, SymbolPlaceholder) {
+				found = true
+			}
+		}
+	}
+	walkLeaves(r.Entry, check)
+	walkLeaves(r.Exit, check)
+	return found
+}
+
+// ForSymbol returns a copy of the group with {symbol} replaced by sym in
+// every KPI name and expression.
+func (g *ConditionGroup) ForSymbol(sym string) *ConditionGroup {
+	if g == nil {
+		return nil
+	}
+	return &ConditionGroup{
+		AllOf: conditionsForSymbol(g.AllOf, sym),
+		AnyOf: conditionsForSymbol(g.AnyOf, sym),
+	}
+}
+
+func conditionsForSymbol(items []ConditionOrGroup, sym string) []ConditionOrGroup {
+	if items == nil {
+		return nil
+	}
+	out := make([]ConditionOrGroup, len(items))
+	for i, c := range items {
+		c.KPI = strings.ReplaceAll(c.KPI, SymbolPlaceholder, sym)
+		c.Expr = strings.ReplaceAll(c.Expr, SymbolPlaceholder, sym)
+		c.ValueExpr = strings.ReplaceAll(c.ValueExpr, SymbolPlaceholder, sym)
+		c.AllOf = conditionsForSymbol(c.AllOf, sym)
+		c.AnyOf = conditionsForSymbol(c.AnyOf, sym)
+		out[i] = c
+	}
+	return out
+}
+
+// walkLeaves calls fn for every leaf condition in a group.
+func walkLeaves(g *ConditionGroup, fn func(*ConditionOrGroup)) {
+	if g == nil {
+		return
+	}
+	var walk func(items []ConditionOrGroup)
+	walk = func(items []ConditionOrGroup) {
+		for i := range items {
+			c := &items[i]
+			if c.IsLeaf() {
+				fn(c)
+			}
+			walk(c.AllOf)
+			walk(c.AnyOf)
+		}
+	}
+	walk(g.AllOf)
+	walk(g.AnyOf)
+}
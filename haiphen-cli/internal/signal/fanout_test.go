@@ -0,0 +1,96 @@
+package signal
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func fanoutRule() *Rule {
+	return &Rule{
+		RuleID:   "rsi-dip",
+		Name:     "rsi dip",
+		Status:   "active",
+		Symbols:  []string{"AAPL", "MSFT", "NVDA"},
+		Entry:    &ConditionGroup{AllOf: []ConditionOrGroup{{KPI: "{symbol}.rsi", Operator: "<", Value: 30}}},
+		Order:    OrderParams{Side: "buy", Type: "market", Qty: 1, TIF: "day"},
+		Cooldown: 300,
+	}
+}
+
+func TestEngine_FanOutPerSymbol(t *testing.T) {
+	mb := &mockBroker{}
+	events := make(chan Event, 32)
+	engine := NewEngine(mb, DefaultEngineConfig(), events)
+	now := time.Date(2026, 2, 10, 14, 30, 0, 0, time.UTC)
+	engine.SetClock(func() time.Time { return now })
+	engine.SetRules([]*Rule{fanoutRule()})
+
+	snap := &Snapshot{KPIs: map[string]float64{"AAPL.rsi": 25, "MSFT.rsi": 45, "NVDA.rsi": 28}}
+	engine.Evaluate(context.Background(), snap)
+
+	if len(mb.orders) != 2 || mb.orders[0].Symbol != "AAPL" || mb.orders[1].Symbol != "NVDA" {
+		t.Fatalf("orders = %+v, want AAPL and NVDA", mb.orders)
+	}
+	var triggered []string
+	for _, ev := range drainEvents(events) {
+		if ev.EventType == "entry_triggered" {
+			triggered = append(triggered, ev.Symbol)
+		}
+	}
+	if strings.Join(triggered, ",") != "AAPL,NVDA" {
+		t.Fatalf("triggered symbols = %v", triggered)
+	}
+
+	// AAPL and NVDA are cooling down; MSFT has its own cooldown and can fire.
+	now = now.Add(time.Minute)
+	snap = &Snapshot{KPIs: map[string]float64{"AAPL.rsi": 20, "MSFT.rsi": 25, "NVDA.rsi": 20}}
+	engine.Evaluate(context.Background(), snap)
+	if len(mb.orders) != 3 || mb.orders[2].Symbol != "MSFT" {
+		t.Fatalf("orders after cooldown = %+v, want MSFT only", mb.orders)
+	}
+}
+
+func TestExpandRules(t *testing.T) {
+	plain := &Rule{RuleID: "p", Entry: &ConditionGroup{AllOf: []ConditionOrGroup{{KPI: "X", Operator: ">", Value: 1}}}}
+	targets := expandRules([]*Rule{plain, fanoutRule()})
+	if len(targets) != 4 {
+		t.Fatalf("got %d targets, want 4", len(targets))
+	}
+	if targets[0].key != "p" || targets[0].symbol != "" {
+		t.Fatalf("plain target = %+v", targets[0])
+	}
+	if targets[2].key != "rsi-dip:MSFT" || targets[2].entry.AllOf[0].KPI != "MSFT.rsi" {
+		t.Fatalf("MSFT target = %+v", targets[2])
+	}
+	// The rule itself is left untouched.
+	if fanoutRule().Entry.AllOf[0].KPI != "{symbol}.rsi" {
+		t.Fatal("template was modified")
+	}
+}
+
+func TestValidateRule_SymbolPlaceholder(t *testing.T) {
+	r := fanoutRule()
+	r.Entry.AllOf[0] = ConditionOrGroup{Expr: "{symbol}.close - sma({symbol}.close, 20)", Operator: ">", Value: 0}
+	if err := ValidateRule(r, 1000); err != nil {
+		t.Fatalf("expected valid templated rule, got: %v", err)
+	}
+
+	r.Symbols = nil
+	if err := ValidateRule(r, 1000); err == nil || !strings.Contains(err.Error(), "no symbols") {
+		t.Fatalf("expected missing symbols error, got: %v", err)
+	}
+
+	r.Symbols = []string{"AAPL", "AAPL"}
+	if err := ValidateRule(r, 1000); err == nil || !strings.Contains(err.Error(), "duplicate symbol") {
+		t.Fatalf("expected duplicate symbol error, got: %v", err)
+	}
+}
+
+func TestReferencedKPIs_ResolvesSymbols(t *testing.T) {
+	got := fanoutRule().ReferencedKPIs()
+	if strings.Join(got, ",") != "AAPL.rsi,MSFT.rsi,NVDA.rsi" {
+		t.Fatalf("ReferencedKPIs = %v", got)
+	}
+}
@@ -0,0 +1,247 @@
+package signal
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// historyDirName holds saved rule revisions under the signals directory.
+// LoadRulesFromDir skips directories, so revisions are never loaded as rules.
+const historyDirName = ".history"
+
+// RuleRevision is one saved version of a rule.
+type RuleRevision struct {
+	Version int       `yaml:"version"  json:"version"`
+	SavedAt time.Time `yaml:"saved_at" json:"saved_at"`
+	Author  string    `yaml:"author,omitempty" json:"author,omitempty"`
+	Rule    *Rule     `yaml:"rule"     json:"rule"`
+}
+
+// ContentHash identifies a rule's content independent of its version number,
+// so the API can tell a saved revision from a hand-edited file.
+func (r *Rule) ContentHash() string {
+	h := sha256.Sum256([]byte(ruleContent(r)))
+	return fmt.Sprintf("%x", h[:8])
+}
+
+func ruleContent(r *Rule) string {
+	c := *r
+	c.Version = 0
+	data, _ := json.Marshal(&c)
+	return string(data)
+}
+
+func ruleHistoryDir(dir, name string) string {
+	return filepath.Join(dir, historyDirName, sanitizeFilename(name))
+}
+
+// RuleHistory returns a rule's saved revisions, oldest first.
+func RuleHistory(dir, name string) ([]RuleRevision, error) {
+	hdir := ruleHistoryDir(dir, name)
+	entries, err := os.ReadDir(hdir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var revs []RuleRevision
+	for _, e := range entries {
+		n := strings.TrimSuffix(strings.TrimPrefix(e.Name(), "v"), ".yaml")
+		if _, err := strconv.Atoi(n); err != nil || e.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(hdir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var rev RuleRevision
+		if err := yaml.Unmarshal(data, &rev); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", e.Name(), err)
+		}
+		revs = append(revs, rev)
+	}
+	sort.Slice(revs, func(i, j int) bool { return revs[i].Version < revs[j].Version })
+	return revs, nil
+}
+
+// LoadRuleRevision returns one saved revision of a rule.
+func LoadRuleRevision(dir, name string, version int) (*RuleRevision, error) {
+	revs, err := RuleHistory(dir, name)
+	if err != nil {
+		return nil, err
+	}
+	for i := range revs {
+		if revs[i].Version == version {
+			return &revs[i], nil
+		}
+	}
+	return nil, fmt.Errorf("rule %q has no version %d", name, version)
+}
+
+// recordRevision assigns r its next version and stores a copy in the rule's
+// history. Saving unchanged content keeps the current version. A live file
+// saved before history existed is recorded first so it can be rolled back to.
+func recordRevision(dir string, r *Rule, author string) error {
+	revs, err := RuleHistory(dir, r.Name)
+	if err != nil {
+		return err
+	}
+
+	if len(revs) == 0 {
+		path := filepath.Join(dir, sanitizeFilename(r.Name)+".yaml")
+		if prev, err := LoadRuleFile(path); err == nil {
+			info, _ := os.Stat(path)
+			rev := RuleRevision{Version: prev.Version, Rule: prev}
+			if info != nil {
+				rev.SavedAt = info.ModTime().UTC()
+			}
+			if err := writeRevision(dir, rev); err != nil {
+				return err
+			}
+			revs = append(revs, rev)
+		}
+	}
+
+	last := 0
+	if len(revs) > 0 {
+		prev := revs[len(revs)-1]
+		if ruleContent(prev.Rule) == ruleContent(r) {
+			r.Version = prev.Version
+			return nil
+		}
+		last = prev.Version
+	}
+	if r.Version <= last {
+		r.Version = last + 1
+	}
+
+	c := *r
+	return writeRevision(dir, RuleRevision{
+		Version: r.Version,
+		SavedAt: time.Now().UTC(),
+		Author:  author,
+		Rule:    &c,
+	})
+}
+
+func writeRevision(dir string, rev RuleRevision) error {
+	hdir := ruleHistoryDir(dir, rev.Rule.Name)
+	if err := os.MkdirAll(hdir, 0o700); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(rev)
+	if err != nil {
+		return fmt.Errorf("marshal revision: %w", err)
+	}
+	path := filepath.Join(hdir, fmt.Sprintf("v%d.yaml", rev.Version))
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// RollbackRule restores a saved revision as the live rule. The restored
+// content is saved as a new version, so history only ever grows.
+func RollbackRule(dir, name string, version int, author string) (*Rule, error) {
+	rev, err := LoadRuleRevision(dir, name, version)
+	if err != nil {
+		return nil, err
+	}
+	r := *rev.Rule
+	if err := SaveRuleAs(dir, &r, author); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// ParseVersion accepts "3" or "v3".
+func ParseVersion(s string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimPrefix(strings.ToLower(s), "v"))
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid version %q: use e.g. v3", s)
+	}
+	return n, nil
+}
+
+// currentAuthor names the local user recorded on saved revisions.
+func currentAuthor() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// DiffLine is one line of a line diff: Op is ' ', '-' or '+'.
+type DiffLine struct {
+	Op   byte
+	Text string
+}
+
+// DiffRuleRevisions returns a line diff of two revisions' YAML.
+func DiffRuleRevisions(a, b *RuleRevision) ([]DiffLine, error) {
+	ay, err := yaml.Marshal(a.Rule)
+	if err != nil {
+		return nil, err
+	}
+	by, err := yaml.Marshal(b.Rule)
+	if err != nil {
+		return nil, err
+	}
+	return lineDiff(strings.Split(strings.TrimRight(string(ay), "\n"), "\n"),
+		strings.Split(strings.TrimRight(string(by), "\n"), "\n")), nil
+}
+
+// lineDiff computes a minimal line diff via longest common subsequence.
+// Rule files are small, so the quadratic table is fine.
+func lineDiff(a, b []string) []DiffLine {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out []DiffLine
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			out = append(out, DiffLine{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, DiffLine{'-', a[i]})
+			i++
+		default:
+			out = append(out, DiffLine{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		out = append(out, DiffLine{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		out = append(out, DiffLine{'+', b[j]})
+	}
+	return out
+}
@@ -0,0 +1,122 @@
+package signal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveRule_Versions(t *testing.T) {
+	dir := t.TempDir()
+	r := reloadRule("momentum", 5)
+	if err := SaveRuleAs(dir, r, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if r.Version != 1 {
+		t.Fatalf("first save: version = %d", r.Version)
+	}
+
+	// Saving unchanged content does not create a version.
+	if err := SaveRuleAs(dir, r, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	r.Cooldown = 300
+	if err := SaveRuleAs(dir, r, "bob"); err != nil {
+		t.Fatal(err)
+	}
+	r.Status = "paused"
+	if err := SaveRuleAs(dir, r, "bob"); err != nil {
+		t.Fatal(err)
+	}
+
+	revs, err := RuleHistory(dir, "momentum")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revs) != 3 || revs[2].Version != 3 || revs[1].Author != "bob" || revs[0].Rule.Cooldown != 600 {
+		t.Fatalf("history = %+v", revs)
+	}
+	live, err := LoadRuleFile(filepath.Join(dir, "momentum.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if live.Version != 3 {
+		t.Fatalf("live version = %d", live.Version)
+	}
+
+	// History never shows up as rules.
+	rules, err := LoadRulesFromDir(dir)
+	if err != nil || len(rules) != 1 {
+		t.Fatalf("rules = %d, err = %v", len(rules), err)
+	}
+
+	// Rollback restores v1's content as v4.
+	back, err := RollbackRule(dir, "momentum", 1, "carol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back.Version != 4 || back.Cooldown != 600 || back.Status != "active" {
+		t.Fatalf("rollback = %+v", back)
+	}
+	if back.ContentHash() != revs[0].Rule.ContentHash() {
+		t.Error("rolled-back content should hash like v1")
+	}
+	if _, err := RollbackRule(dir, "momentum", 9, ""); err == nil {
+		t.Error("expected error for missing version")
+	}
+}
+
+func TestSaveRule_RecordsPreHistoryFile(t *testing.T) {
+	dir := t.TempDir()
+	r := reloadRule("legacy", 5)
+	r.Version = 1
+	data := []byte("version: 1\nname: legacy\nstatus: active\nentry:\n  all_of:\n    - kpi: X\n      operator: \">\"\n      value: 5\norder:\n  side: buy\n  type: market\n  qty: 1\n  tif: day\ncooldown: 600\n")
+	if err := os.WriteFile(filepath.Join(dir, "legacy.yaml"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	r.Cooldown = 120
+	if err := SaveRuleAs(dir, r, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	revs, err := RuleHistory(dir, "legacy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revs) != 2 || revs[0].Version != 1 || revs[0].Rule.Cooldown != 600 || revs[1].Version != 2 {
+		t.Fatalf("history = %+v", revs)
+	}
+}
+
+func TestDiffRuleRevisions(t *testing.T) {
+	a, b := reloadRule("d", 5), reloadRule("d", 7)
+	lines, err := DiffRuleRevisions(&RuleRevision{Rule: a}, &RuleRevision{Rule: b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var removed, added []string
+	for _, l := range lines {
+		switch l.Op {
+		case '-':
+			removed = append(removed, l.Text)
+		case '+':
+			added = append(added, l.Text)
+		}
+	}
+	if len(removed) != 1 || len(added) != 1 || removed[0] != "          value: 5" || added[0] != "          value: 7" {
+		t.Fatalf("removed = %q, added = %q", removed, added)
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	for in, want := range map[string]int{"3": 3, "v5": 5, "V12": 12} {
+		if got, err := ParseVersion(in); err != nil || got != want {
+			t.Errorf("ParseVersion(%q) = %d, %v", in, got, err)
+		}
+	}
+	for _, in := range []string{"", "v0", "latest"} {
+		if _, err := ParseVersion(in); err == nil {
+			t.Errorf("ParseVersion(%q) should fail", in)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package signal
+
+import "math"
+
+// maxWindow bounds indicator windows so per-KPI history stays small.
+const maxWindow = 1000
+
+// emaLookbackFactor sets how much history ema keeps beyond its window: the
+// average is seeded with the SMA of the oldest N retained values and then
+// smoothed over the rest, so more history brings it closer to a true EMA.
+const emaLookbackFactor = 3
+
+var windowFuncs = map[string]bool{
+	"sma": true, "ema": true, "stddev": true, "zscore": true,
+	"pct_change": true, "highest": true, "lowest": true,
+}
+
+const windowFuncList = "sma, ema, stddev, zscore, pct_change, highest, lowest"
+
+// windowNode is a rolling-window indicator over one KPI's history.
+type windowNode struct {
+	fn  string
+	kpi string
+	n   int
+}
+
+// warmup returns how many values (including the current one) are needed
+// before the indicator can be computed.
+func (w windowNode) warmup() int {
+	if w.fn == "pct_change" {
+		return w.n + 1
+	}
+	return w.n
+}
+
+// lookback returns how many values the engine must retain for this indicator.
+func (w windowNode) lookback() int {
+	if w.fn == "ema" {
+		return w.n * emaLookbackFactor
+	}
+	return w.warmup()
+}
+
+func (w windowNode) walkKPIs(fn func(string))         { fn(w.kpi) }
+func (w windowNode) walkWindows(fn func(string, int)) { fn(w.kpi, w.lookback()) }
+
+func (w windowNode) eval(env *exprEnv) (float64, bool) {
+	series := env.series(w.kpi)
+	if len(series) < w.warmup() {
+		return 0, false
+	}
+	last := series[len(series)-w.n:]
+
+	var v float64
+	switch w.fn {
+	case "sma":
+		v = mean(last)
+	case "ema":
+		if len(series) > w.lookback() {
+			series = series[len(series)-w.lookback():]
+		}
+		alpha := 2 / float64(w.n+1)
+		v = mean(series[:w.n])
+		for _, x := range series[w.n:] {
+			v = alpha*x + (1-alpha)*v
+		}
+	case "stddev":
+		v = stddev(last)
+	case "zscore":
+		sd := stddev(last)
+		if sd == 0 {
+			return 0, false
+		}
+		v = (series[len(series)-1] - mean(last)) / sd
+	case "pct_change":
+		base := series[len(series)-1-w.n]
+		if base == 0 {
+			return 0, false
+		}
+		v = (series[len(series)-1] - base) / math.Abs(base) * 100
+	case "highest":
+		v = last[0]
+		for _, x := range last[1:] {
+			v = math.Max(v, x)
+		}
+	case "lowest":
+		v = last[0]
+		for _, x := range last[1:] {
+			v = math.Min(v, x)
+		}
+	default:
+		return 0, false
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, false
+	}
+	return v, true
+}
+
+func mean(xs []float64) float64 {
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
+
+// stddev is the population standard deviation.
+func stddev(xs []float64) float64 {
+	m := mean(xs)
+	var ss float64
+	for _, x := range xs {
+		ss += (x - m) * (x - m)
+	}
+	return math.Sqrt(ss / float64(len(xs)))
+}
+
+// historyRequirements returns, per KPI, the longest history any target's
+// indicators need. KPIs without indicators are not retained.
+func historyRequirements(targets []ruleTarget) map[string]int {
+	req := make(map[string]int)
+	add := func(src string) {
+		if src == "" {
+			return
+		}
+		n, err := compileExpr(src)
+		if err != nil {
+			return
+		}
+		n.walkWindows(func(kpi string, lookback int) {
+			if lookback > req[kpi] {
+				req[kpi] = lookback
+			}
+		})
+	}
+
+	for _, t := range targets {
+		for _, g := range []*ConditionGroup{t.entry, t.exit} {
+			walkLeaves(g, func(c *ConditionOrGroup) {
+				add(c.Expr)
+				add(c.ValueExpr)
+			})
+		}
+	}
+	return req
+}
+
+// recordHistory appends a snapshot's values for every KPI an indicator
+// needs, trimming each series to its required length. Callers hold e.mu.
+func (e *Engine) recordHistory(snap *Snapshot) {
+	for kpi, keep := range e.historyLen {
+		v, ok := snap.KPIs[kpi]
+		if !ok {
+			continue
+		}
+		h := append(e.history[kpi], v)
+		if len(h) > keep {
+			h = append(h[:0:0], h[len(h)-keep:]...)
+		}
+		e.history[kpi] = h
+	}
+}
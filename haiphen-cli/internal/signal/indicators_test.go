@@ -0,0 +1,115 @@
+package signal
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestWindowIndicators(t *testing.T) {
+	env := &exprEnv{
+		kpis:    map[string]float64{"P": 14},
+		history: map[string][]float64{"P": {10, 11, 12, 13}},
+		live:    true,
+	}
+
+	tests := []struct {
+		expr string
+		want float64
+	}{
+		{"sma(P, 3)", 13},
+		{"sma(P, 5)", 12},
+		{"highest(P, 3)", 14},
+		{"lowest(P, 3)", 12},
+		{"pct_change(P, 4)", 40},
+		{"stddev(P, 5)", math.Sqrt(2)},
+		{"zscore(P, 5)", 2 / math.Sqrt(2)},
+		{"ema(P, 2)", 13.5},
+		{"P - sma(P, 3)", 1},
+	}
+	for _, tt := range tests {
+		got, ok := evalExpr(tt.expr, env)
+		if !ok {
+			t.Errorf("%s not computable", tt.expr)
+			continue
+		}
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestWindowIndicators_WarmUp(t *testing.T) {
+	env := &exprEnv{
+		kpis:    map[string]float64{"P": 12},
+		history: map[string][]float64{"P": {10, 11}},
+		live:    true,
+	}
+	for _, expr := range []string{"sma(P, 4)", "pct_change(P, 3)", "ema(P, 4)", "sma(Missing, 1)"} {
+		if _, ok := evalExpr(expr, env); ok {
+			t.Errorf("%s should not be computable during warm-up", expr)
+		}
+	}
+	if _, ok := evalExpr("pct_change(P, 2)", env); !ok {
+		t.Error("pct_change(P, 2) should be computable with 3 values")
+	}
+}
+
+func TestParseWindow_Errors(t *testing.T) {
+	for _, expr := range []string{
+		"sma(P)",
+		"sma(P, 0)",
+		"sma(P, 1.5)",
+		"sma(P, 5000)",
+		"sma(1, 5)",
+		"stddev(P, 1)",
+		"sma(P + 1, 5)",
+	} {
+		if _, err := parseExpr(expr); err == nil {
+			t.Errorf("parseExpr(%q) should fail", expr)
+		}
+	}
+}
+
+func TestHistoryRequirements(t *testing.T) {
+	rules := []*Rule{{
+		Entry: &ConditionGroup{AllOf: []ConditionOrGroup{
+			{KPI: "P", Operator: ">", ValueExpr: "sma(P, 20)"},
+			{Expr: "pct_change(Q, 5)", Operator: ">", Value: 1},
+		}},
+		Exit: &ConditionGroup{AnyOf: []ConditionOrGroup{{Expr: "ema(P, 10)", Operator: "<", Value: 1}}},
+	}}
+	got := historyRequirements(expandRules(rules))
+	if got["P"] != 30 || got["Q"] != 6 || len(got) != 2 {
+		t.Fatalf("historyRequirements = %v", got)
+	}
+}
+
+func TestEngine_IndicatorRuleWaitsForHistory(t *testing.T) {
+	events := make(chan Event, 16)
+	cfg := DefaultEngineConfig()
+	cfg.DryRun = true
+	engine := NewEngine(nil, cfg, events)
+	engine.SetRules([]*Rule{{
+		RuleID:   "sma-cross",
+		Name:     "price above sma",
+		Status:   "active",
+		Entry:    &ConditionGroup{AllOf: []ConditionOrGroup{{KPI: "P", Operator: "crosses_above", ValueExpr: "sma(P, 3)"}}},
+		Order:    OrderParams{Side: "buy", Type: "market", Qty: 1, TIF: "day"},
+		Cooldown: 60,
+	}})
+
+	// 12 > 11 would cross above a 2-value average, but sma(P, 3) is still
+	// warming up; the only real cross is 8 → 11.
+	for _, p := range []float64{10, 12, 9, 8, 11} {
+		engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"P": p}})
+	}
+
+	evs := drainEvents(events)
+	if len(evs) != 1 || evs[0].EventType != "entry_triggered" {
+		t.Fatalf("expected exactly one trigger, got %+v", evs)
+	}
+	if got := len(engine.history["P"]); got != 3 {
+		t.Fatalf("history retained %d values, want 3", got)
+	}
+}
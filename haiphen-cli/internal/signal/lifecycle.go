@@ -0,0 +1,161 @@
+package signal
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/haiphen/haiphen-cli/internal/broker"
+)
+
+// PositionState is where a rule (per symbol) is in its entry/exit lifecycle.
+type PositionState string
+
+const (
+	StateFlat    PositionState = "flat"    // no position; only entry conditions are evaluated
+	StatePending PositionState = "pending" // entry order submitted, waiting for a fill
+	StateLong    PositionState = "long"    // holding a long position; only exit conditions are evaluated
+	StateShort   PositionState = "short"   // holding a short position; only exit conditions are evaluated
+	StateExiting PositionState = "exiting" // exit order submitted, waiting for a fill
+)
+
+// RuleState tracks the position a rule opened for one symbol. Only rules with
+// exit conditions are tracked: entry-only rules keep firing on every cooldown.
+type RuleState struct {
+	RuleID       string        `json:"rule_id"`
+	Symbol       string        `json:"symbol,omitempty"`
+	State        PositionState `json:"state"`
+	Side         string        `json:"side,omitempty"` // entry side: buy opens long, sell opens short
+	Qty          float64       `json:"qty,omitempty"`  // position size the rule holds (or is entering)
+	EntryOrderID string        `json:"entry_order_id,omitempty"`
+	ExitOrderID  string        `json:"exit_order_id,omitempty"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+}
+
+// tracksPosition reports whether a rule runs the entry/exit state machine.
+func (r *Rule) tracksPosition() bool {
+	return r.Exit != nil
+}
+
+// RuleStates returns a snapshot of every tracked rule state, ordered by rule and symbol.
+func (e *Engine) RuleStates() []RuleState {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	out := make([]RuleState, 0, len(e.ruleStates))
+	for _, st := range e.ruleStates {
+		out = append(out, *st)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].RuleID != out[j].RuleID {
+			return out[i].RuleID < out[j].RuleID
+		}
+		return out[i].Symbol < out[j].Symbol
+	})
+	return out
+}
+
+// stateFor returns the lifecycle state of a target, creating a flat one.
+// Callers hold e.mu.
+func (e *Engine) stateFor(t ruleTarget) *RuleState {
+	st, ok := e.ruleStates[t.key]
+	if !ok {
+		st = &RuleState{RuleID: t.rule.RuleID, Symbol: t.symbol, State: StateFlat}
+		e.ruleStates[t.key] = st
+	}
+	return st
+}
+
+// refreshState polls the broker for the order a pending or exiting state is
+// waiting on. Callers hold e.mu.
+func (e *Engine) refreshState(ctx context.Context, st *RuleState, now time.Time) {
+	if e.broker == nil {
+		return
+	}
+	orderID := st.EntryOrderID
+	if st.State == StateExiting {
+		orderID = st.ExitOrderID
+	}
+	order, err := e.broker.GetOrderByID(ctx, orderID)
+	if err != nil || order == nil {
+		log.Printf("[engine] order %s status unavailable: %v", orderID, err)
+		return
+	}
+	e.applyOrderStatus(st, order, now)
+}
+
+// applyOrderStatus advances a pending or exiting state from its order's status.
+func (e *Engine) applyOrderStatus(st *RuleState, order *broker.Order, now time.Time) {
+	filled := order.FilledQty
+	switch order.Status {
+	case "filled":
+		if filled <= 0 {
+			filled = order.Qty
+		}
+	case "canceled", "cancelled", "expired", "rejected", "done_for_day":
+		// Terminal without a full fill; keep whatever did fill.
+	default:
+		return // still working
+	}
+
+	prev := st.State
+	switch st.State {
+	case StatePending:
+		if filled > 0 {
+			st.State = openState(st.Side)
+			st.Qty = filled
+		} else {
+			st.State = StateFlat
+			st.Qty = 0
+			st.EntryOrderID = ""
+		}
+	case StateExiting:
+		if order.Status == "filled" || filled >= st.Qty {
+			st.State = StateFlat
+			st.Qty = 0
+			st.EntryOrderID, st.ExitOrderID = "", ""
+		} else {
+			// Exit did not complete: still holding the remainder.
+			st.State = openState(st.Side)
+			st.Qty -= filled
+			st.ExitOrderID = ""
+		}
+	default:
+		return
+	}
+	st.UpdatedAt = now
+	log.Printf("[engine] rule %s %s: %s → %s (order %s %s)",
+		st.RuleID, st.Symbol, prev, st.State, order.OrderID, order.Status)
+}
+
+// brokerHolds reports whether the broker still shows a position in symbol.
+// Errors are treated as holding, so a flaky positions call never drops state.
+func (e *Engine) brokerHolds(ctx context.Context, symbol string) bool {
+	positions, err := e.broker.GetPositions(ctx)
+	if err != nil {
+		return true
+	}
+	for _, p := range positions {
+		if p.Symbol == symbol && p.Qty != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// openState is the held state after an entry on side fills.
+func openState(side string) PositionState {
+	if strings.EqualFold(side, "sell") {
+		return StateShort
+	}
+	return StateLong
+}
+
+// closingSide is the order side that closes a position opened on side.
+func closingSide(side string) string {
+	if strings.EqualFold(side, "sell") {
+		return "buy"
+	}
+	return "sell"
+}
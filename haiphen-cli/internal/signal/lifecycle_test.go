@@ -0,0 +1,200 @@
+package signal
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/haiphen/haiphen-cli/internal/broker"
+)
+
+// lifecycleBroker leaves orders "accepted" until the test sets their status,
+// and reports whatever positions the test configures.
+type lifecycleBroker struct {
+	mockBroker
+	status    map[string]string
+	reqs      map[string]broker.OrderRequest
+	positions []broker.Position
+	n         int
+}
+
+func newLifecycleBroker() *lifecycleBroker {
+	return &lifecycleBroker{status: make(map[string]string), reqs: make(map[string]broker.OrderRequest)}
+}
+
+func (b *lifecycleBroker) CreateOrder(_ context.Context, req broker.OrderRequest) (*broker.Order, error) {
+	b.n++
+	id := fmt.Sprintf("o%d", b.n)
+	b.status[id] = "accepted"
+	b.reqs[id] = req
+	b.orders = append(b.orders, req)
+	return &broker.Order{OrderID: id, Symbol: req.Symbol, Qty: req.Qty, Side: req.Side, Status: "accepted"}, nil
+}
+
+func (b *lifecycleBroker) GetOrderByID(_ context.Context, id string) (*broker.Order, error) {
+	req := b.reqs[id]
+	o := &broker.Order{OrderID: id, Symbol: req.Symbol, Qty: req.Qty, Side: req.Side, Status: b.status[id]}
+	if o.Status == "filled" {
+		o.FilledQty = req.Qty
+	}
+	return o, nil
+}
+
+func (b *lifecycleBroker) GetPositions(context.Context) ([]broker.Position, error) {
+	return b.positions, nil
+}
+
+func lifecycleRule() *Rule {
+	return &Rule{
+		RuleID:   "swing",
+		Name:     "swing",
+		Status:   "active",
+		Symbols:  []string{"SPY"},
+		Entry:    &ConditionGroup{AllOf: []ConditionOrGroup{{KPI: "In", Operator: ">", Value: 0}}},
+		Exit:     &ConditionGroup{AllOf: []ConditionOrGroup{{KPI: "Out", Operator: ">", Value: 0}}},
+		Order:    OrderParams{Side: "buy", Type: "market", Qty: 5, TIF: "day"},
+		Cooldown: 60,
+	}
+}
+
+func TestEngine_Lifecycle(t *testing.T) {
+	b := newLifecycleBroker()
+	engine := NewEngine(b, DefaultEngineConfig(), make(chan Event, 64))
+	now := time.Date(2026, 2, 10, 14, 30, 0, 0, time.UTC)
+	engine.SetClock(func() time.Time { return now })
+	engine.SetRules([]*Rule{lifecycleRule()})
+
+	step := func(in, out float64) RuleState {
+		now = now.Add(2 * time.Minute) // past the cooldown every time
+		engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"In": in, "Out": out}})
+		return engine.RuleStates()[0]
+	}
+
+	// Exit conditions are ignored while flat: nothing to close.
+	if st := step(0, 1); st.State != StateFlat || len(b.orders) != 0 {
+		t.Fatalf("exit while flat: state=%s orders=%d", st.State, len(b.orders))
+	}
+
+	if st := step(1, 0); st.State != StatePending || st.EntryOrderID != "o1" {
+		t.Fatalf("after entry: %+v", st)
+	}
+	// Still pending: entry is not re-evaluated.
+	if st := step(1, 0); st.State != StatePending || len(b.orders) != 1 {
+		t.Fatalf("pending re-entry: state=%s orders=%d", st.State, len(b.orders))
+	}
+
+	b.status["o1"] = "filled"
+	b.positions = []broker.Position{{Symbol: "SPY", Qty: 5}}
+	if st := step(1, 0); st.State != StateLong || st.Qty != 5 || len(b.orders) != 1 {
+		t.Fatalf("after fill: %+v (orders=%d)", st, len(b.orders))
+	}
+
+	// Exit sells exactly what the rule bought.
+	if st := step(1, 1); st.State != StateExiting {
+		t.Fatalf("after exit: %+v", st)
+	}
+	if exit := b.orders[1]; exit.Side != "sell" || exit.Qty != 5 || exit.Symbol != "SPY" {
+		t.Fatalf("exit order = %+v", exit)
+	}
+
+	b.status["o2"] = "filled"
+	if st := step(0, 0); st.State != StateFlat || st.Qty != 0 {
+		t.Fatalf("after exit fill: %+v", st)
+	}
+}
+
+func TestEngine_LifecycleCanceledEntryReturnsFlat(t *testing.T) {
+	b := newLifecycleBroker()
+	engine := NewEngine(b, DefaultEngineConfig(), make(chan Event, 64))
+	engine.SetRules([]*Rule{lifecycleRule()})
+
+	engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"In": 1}})
+	b.status["o1"] = "canceled"
+	engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"In": 0}})
+
+	if st := engine.RuleStates()[0]; st.State != StateFlat || st.EntryOrderID != "" {
+		t.Fatalf("canceled entry: %+v", st)
+	}
+}
+
+func TestEngine_LifecycleExternallyClosedPosition(t *testing.T) {
+	b := newLifecycleBroker()
+	engine := NewEngine(b, DefaultEngineConfig(), make(chan Event, 64))
+	now := time.Date(2026, 2, 10, 14, 30, 0, 0, time.UTC)
+	engine.SetClock(func() time.Time { return now })
+	engine.SetRules([]*Rule{lifecycleRule()})
+
+	engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"In": 1}})
+	b.status["o1"] = "filled"
+
+	// The position was closed by hand, so the exit must not sell anything.
+	now = now.Add(2 * time.Minute)
+	engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"Out": 1}})
+
+	if len(b.orders) != 1 {
+		t.Fatalf("expected no exit order, got %+v", b.orders)
+	}
+	if st := engine.RuleStates()[0]; st.State != StateFlat {
+		t.Fatalf("state = %s, want flat", st.State)
+	}
+}
+
+func TestEngine_LifecycleShortAndDryRun(t *testing.T) {
+	events := make(chan Event, 64)
+	cfg := DefaultEngineConfig()
+	cfg.DryRun = true
+	engine := NewEngine(nil, cfg, events)
+	now := time.Date(2026, 2, 10, 14, 30, 0, 0, time.UTC)
+	engine.SetClock(func() time.Time { return now })
+	r := lifecycleRule()
+	r.Order.Side = "sell"
+	engine.SetRules([]*Rule{r})
+
+	engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"In": 1}})
+	if st := engine.RuleStates()[0]; st.State != StateShort {
+		t.Fatalf("dry-run short entry: %+v", st)
+	}
+
+	now = now.Add(2 * time.Minute)
+	engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"Out": 1}})
+	evs := drainEvents(events)
+	last := evs[len(evs)-1]
+	if last.EventType != "exit_triggered" || last.OrderSide != "buy" || last.OrderQty != 5 {
+		t.Fatalf("exit event = %+v", last)
+	}
+	if st := engine.RuleStates()[0]; st.State != StateFlat {
+		t.Fatalf("dry-run exit: %+v", st)
+	}
+}
+
+func TestEngine_LifecycleExitIgnoresCaps(t *testing.T) {
+	for _, capped := range []string{"hourly", "session"} {
+		b := newLifecycleBroker()
+		events := make(chan Event, 64)
+		cfg := DefaultEngineConfig()
+		if capped == "hourly" {
+			cfg.MaxTriggersPerRulePerHour = 1
+		} else {
+			cfg.MaxOrdersPerSession = 1
+		}
+		engine := NewEngine(b, cfg, events)
+		now := time.Date(2026, 2, 10, 14, 30, 0, 0, time.UTC)
+		engine.SetClock(func() time.Time { return now })
+		engine.SetRules([]*Rule{lifecycleRule()})
+
+		engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"In": 1}})
+		b.status["o1"] = "filled"
+		b.positions = []broker.Position{{Symbol: "SPY", Qty: 5}}
+
+		// The entry used up the cap; the exit still goes out.
+		now = now.Add(2 * time.Minute)
+		engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"In": 1, "Out": 1}})
+		if len(b.orders) != 2 || b.orders[1].Side != "sell" {
+			t.Fatalf("%s cap: orders = %+v, want entry and exit", capped, b.orders)
+		}
+		if blocked := eventsOfType(drainEvents(events), "cooldown_blocked"); len(blocked) != 0 {
+			t.Errorf("%s cap: cooldown_blocked = %+v, want none", capped, blocked)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package signal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metric names exposed on the daemon's /metrics endpoint.
+const (
+	metricSnapshots     = "haiphen_signal_snapshots_received_total"
+	metricParseFailures = "haiphen_signal_parse_failures_total"
+	metricReconnects    = "haiphen_signal_ws_reconnects_total"
+	metricBackoff       = "haiphen_signal_ws_backoff_seconds"
+	metricConnected     = "haiphen_signal_ws_connected"
+	metricEvaluations   = "haiphen_signal_rule_evaluations_total"
+	metricEvents        = "haiphen_signal_events_total"
+	metricOrders        = "haiphen_signal_orders_total"
+	metricSafetyBlocks  = "haiphen_signal_safety_blocks_total"
+	metricPostFailures  = "haiphen_signal_event_post_failures_total"
+	metricOutboxPending = "haiphen_signal_outbox_pending"
+	metricBreakerOpen   = "haiphen_signal_circuit_open"
+	metricOrderLatency  = "haiphen_signal_snapshot_to_order_seconds"
+	metricEvalDuration  = "haiphen_signal_evaluation_duration_seconds"
+	metricSnapshotDelay = "haiphen_signal_snapshot_delay_seconds"
+)
+
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Safety block reasons, the label of metricSafetyBlocks.
+const (
+	blockHourlyCap   = "hourly_cap"
+	blockSessionCap  = "session_cap"
+	blockOrderPrep   = "order_prep" // sizing or exit legs could not be computed
+	blockOrderLimits = "order_limits"
+	blockDailyLoss   = "daily_loss"
+	blockDataQuality = "data_quality"
+	blockCopyBudget  = "copy_budget"
+	blockCopyCap     = "copy_concurrency"
+)
+
+// Metrics collects daemon counters, gauges and histograms and renders them in
+// the Prometheus text format. A nil *Metrics discards everything, so the
+// engine and backtests run without one.
+type Metrics struct {
+	mu    sync.Mutex
+	order []string // registration order, for stable output
+	vecs  map[string]*metricVec
+	hists map[string]*histogram
+}
+
+// metricVec is a counter or gauge with at most one label.
+type metricVec struct {
+	help   string
+	kind   string // "counter" or "gauge"
+	label  string // label name; "" for an unlabeled metric
+	values map[string]float64
+}
+
+type histogram struct {
+	help    string
+	buckets []float64 // upper bounds, ascending
+	counts  []uint64  // per bucket, not cumulative
+	sum     float64
+	count   uint64
+}
+
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// NewMetrics returns a registry with every daemon metric registered.
+func NewMetrics() *Metrics {
+	m := &Metrics{vecs: make(map[string]*metricVec), hists: make(map[string]*histogram)}
+	m.vec(metricSnapshots, "counter", "", "Snapshots received from the signal feed.")
+	m.vec(metricParseFailures, "counter", "message", "Feed messages that failed to parse, by message type.")
+	m.vec(metricReconnects, "counter", "", "WebSocket reconnect attempts.")
+	m.vec(metricBackoff, "gauge", "", "Current WebSocket reconnect backoff.")
+	m.vec(metricConnected, "gauge", "", "Whether the WebSocket feed is connected (1) or not (0).")
+	m.vec(metricEvaluations, "counter", "rule", "Rule evaluations, per rule (one per symbol per snapshot).")
+	m.vec(metricEvents, "counter", "event_type", "Signal events emitted, by event type.")
+	m.vec(metricOrders, "counter", "result", "Orders submitted to the broker, by result (placed, failed).")
+	m.vec(metricSafetyBlocks, "counter", "reason", "Triggers blocked before reaching the broker, by reason.")
+	m.vec(metricPostFailures, "counter", "kind", "Failed event deliveries to the API (retryable, rejected).")
+	m.vec(metricOutboxPending, "gauge", "", "Events waiting in the outbox for delivery.")
+	m.vec(metricBreakerOpen, "gauge", "", "Whether the circuit breaker has halted the engine (1) or not (0).")
+	m.hist(metricOrderLatency, latencyBuckets, "Time from receiving a snapshot to the broker accepting the order it triggered.")
+	m.hist(metricEvalDuration, []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1},
+		"Time to evaluate all rules against one snapshot.")
+	m.hist(metricSnapshotDelay, []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 300},
+		"Age of snapshots on arrival, from their updated_at.")
+	return m
+}
+
+func (m *Metrics) vec(name, kind, label, help string) {
+	m.order = append(m.order, name)
+	m.vecs[name] = &metricVec{help: help, kind: kind, label: label, values: make(map[string]float64)}
+}
+
+func (m *Metrics) hist(name string, buckets []float64, help string) {
+	m.order = append(m.order, name)
+	m.hists[name] = &histogram{help: help, buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+func (m *Metrics) inc(name, label string) {
+	m.add(name, label, 1)
+}
+
+func (m *Metrics) add(name, label string, v float64) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.vecs[name].values[label] += v
+}
+
+func (m *Metrics) set(name string, v float64) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.vecs[name].values[""] = v
+}
+
+func (m *Metrics) observe(name string, v float64) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h := m.hists[name]
+	for i, le := range h.buckets {
+		if v <= le {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// WriteTo renders every metric in the Prometheus text exposition format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+	for _, name := range m.order {
+		if v, ok := m.vecs[name]; ok {
+			fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, v.help, name, v.kind)
+			if v.label == "" {
+				fmt.Fprintf(&b, "%s %s\n", name, formatMetric(v.values[""]))
+				continue
+			}
+			labels := make([]string, 0, len(v.values))
+			for l := range v.values {
+				labels = append(labels, l)
+			}
+			sort.Strings(labels)
+			for _, l := range labels {
+				fmt.Fprintf(&b, "%s{%s=\"%s\"} %s\n", name, v.label, escapeLabel(l), formatMetric(v.values[l]))
+			}
+			continue
+		}
+
+		h := m.hists[name]
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s histogram\n", name, h.help, name)
+		var cum uint64
+		for i, le := range h.buckets {
+			cum += h.counts[i]
+			fmt.Fprintf(&b, "%s_bucket{le=%q} %d\n", name, formatMetric(le), cum)
+		}
+		fmt.Fprintf(&b, "%s_bucket{le=\"+Inf\"} %d\n", name, h.count)
+		fmt.Fprintf(&b, "%s_sum %s\n%s_count %d\n", name, formatMetric(h.sum), name, h.count)
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func formatMetric(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabel escapes a label value for the text format.
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+// ServeMetrics serves m on http://addr/metrics until ctx is done. The
+// endpoint is unauthenticated, so only loopback addresses are accepted; a
+// bare ":port" binds to 127.0.0.1.
+func ServeMetrics(ctx context.Context, addr string, m *Metrics) error {
+	addr, err := MetricsListenAddr(addr)
+	if err != nil {
+		return err
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", metricsContentType)
+		_, _ = m.WriteTo(w)
+	})
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+
+	LogJSON("info", "metrics endpoint listening", map[string]interface{}{
+		"url": "http://" + ln.Addr().String() + "/metrics",
+	})
+	err = srv.Serve(ln)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// MetricsListenAddr validates a --metrics-addr value and returns the
+// address to listen on.
+func MetricsListenAddr(addr string) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid metrics address %q: %w", addr, err)
+	}
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	if host != "localhost" {
+		ip := net.ParseIP(host)
+		if ip == nil || !ip.IsLoopback() {
+			return "", fmt.Errorf("metrics address %q must be on localhost", addr)
+		}
+	}
+	return net.JoinHostPort(host, port), nil
+}
@@ -0,0 +1,99 @@
+package signal
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics_Exposition(t *testing.T) {
+	m := NewMetrics()
+	m.inc(metricSnapshots, "")
+	m.inc(metricSnapshots, "")
+	m.inc(metricEvents, "entry_triggered")
+	m.inc(metricEvaluations, `odd "name"`)
+	m.set(metricBackoff, 4)
+	m.observe(metricOrderLatency, 0.02)
+	m.observe(metricOrderLatency, 3)
+
+	var b strings.Builder
+	if _, err := m.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"# TYPE haiphen_signal_snapshots_received_total counter\nhaiphen_signal_snapshots_received_total 2\n",
+		`haiphen_signal_events_total{event_type="entry_triggered"} 1`,
+		`haiphen_signal_rule_evaluations_total{rule="odd \"name\""} 1`,
+		"haiphen_signal_ws_backoff_seconds 4\n",
+		`haiphen_signal_snapshot_to_order_seconds_bucket{le="0.01"} 0`,
+		`haiphen_signal_snapshot_to_order_seconds_bucket{le="0.025"} 1`,
+		`haiphen_signal_snapshot_to_order_seconds_bucket{le="5"} 2`,
+		`haiphen_signal_snapshot_to_order_seconds_bucket{le="+Inf"} 2`,
+		"haiphen_signal_snapshot_to_order_seconds_sum 3.02\nhaiphen_signal_snapshot_to_order_seconds_count 2\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestMetrics_EngineCounters(t *testing.T) {
+	b := &mockBroker{}
+	cfg := DefaultEngineConfig()
+	cfg.MaxOrdersPerSession = 1
+	engine := NewEngine(b, cfg, make(chan Event, 16))
+	now := time.Date(2026, 2, 10, 15, 0, 0, 0, time.UTC)
+	engine.SetClock(func() time.Time { now = now.Add(2 * time.Minute); return now })
+	m := NewMetrics()
+	engine.SetMetrics(m)
+	engine.SetRules([]*Rule{qualityRule()})
+
+	snap := &Snapshot{KPIs: map[string]float64{"Go": 1}, receivedAt: time.Now()}
+	engine.Evaluate(context.Background(), snap)
+	engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"Go": 1}})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if got := m.vecs[metricEvaluations].values["dq"]; got != 2 {
+		t.Errorf("evaluations = %v, want 2", got)
+	}
+	if got := m.vecs[metricOrders].values["placed"]; got != 1 {
+		t.Errorf("orders placed = %v, want 1", got)
+	}
+	if got := m.vecs[metricEvents].values["entry_triggered"]; got != 1 {
+		t.Errorf("entry events = %v, want 1", got)
+	}
+	if got := m.vecs[metricSafetyBlocks].values[blockSessionCap]; got != 1 {
+		t.Errorf("session cap blocks = %v, want 1", got)
+	}
+	if h := m.hists[metricOrderLatency]; h.count != 1 {
+		t.Errorf("latency observations = %d, want 1", h.count)
+	}
+	if h := m.hists[metricEvalDuration]; h.count != 2 {
+		t.Errorf("evaluation durations = %d, want 2", h.count)
+	}
+}
+
+func TestMetricsListenAddr(t *testing.T) {
+	for addr, want := range map[string]string{
+		":9464":          "127.0.0.1:9464",
+		"localhost:9464": "localhost:9464",
+		"[::1]:9464":     "[::1]:9464",
+		"0.0.0.0:9464":   "",
+		"10.0.0.5:9464":  "",
+		"9464":           "",
+	} {
+		got, err := MetricsListenAddr(addr)
+		if want == "" {
+			if err == nil {
+				t.Errorf("%s: expected error, got %s", addr, got)
+			}
+			continue
+		}
+		if err != nil || got != want {
+			t.Errorf("%s = %q, %v; want %q", addr, got, err, want)
+		}
+	}
+}
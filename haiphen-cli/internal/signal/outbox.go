@@ -0,0 +1,338 @@
+package signal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Outbox is a durable, append-only queue of signal events awaiting delivery
+// to the API. Each event is written (and synced) before it is reported, and
+// acknowledged once the API accepts it, so events survive full channels,
+// network failures and daemon restarts.
+//
+// The file holds one JSON record per line: {"op":"add","event":{...}} or
+// {"op":"ack","event_id":"..."}. It is truncated once everything is acked.
+type Outbox struct {
+	mu      sync.Mutex
+	path    string
+	f       *os.File
+	pending []Event
+	acked   int // ack records since the last compaction
+	notify  chan struct{}
+}
+
+type outboxRecord struct {
+	Op      string `json:"op"`
+	Event   *Event `json:"event,omitempty"`
+	EventID string `json:"event_id,omitempty"`
+}
+
+// outboxCompactAfter bounds how many ack records accumulate before the file
+// is rewritten with only the pending events.
+const outboxCompactAfter = 1000
+
+// OutboxPath returns the outbox file path for a profile.
+func OutboxPath(profile string) (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "haiphen", fmt.Sprintf("signal.%s.outbox.jsonl", profile)), nil
+}
+
+// ReadOutbox returns the pending events in an outbox file without opening it
+// for writing.
+func ReadOutbox(path string) ([]Event, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	return replayOutbox(f)
+}
+
+// OpenOutbox opens (creating if needed) an outbox file and loads the events
+// still pending from previous runs.
+func OpenOutbox(path string) (*Outbox, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := replayOutbox(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("read outbox: %w", err)
+	}
+	o := &Outbox{path: path, f: f, pending: pending, notify: make(chan struct{}, 1)}
+	if err := o.compact(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return o, nil
+}
+
+// replayOutbox reads add/ack records and returns the unacked events in order.
+// A torn final line (crash mid-write) is ignored.
+func replayOutbox(r io.Reader) ([]Event, error) {
+	var pending []Event
+	index := make(map[string]int)
+	acked := make(map[string]bool)
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for sc.Scan() {
+		var rec outboxRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			continue
+		}
+		switch rec.Op {
+		case "add":
+			if rec.Event == nil {
+				continue
+			}
+			if _, dup := index[rec.Event.EventID]; dup {
+				continue
+			}
+			index[rec.Event.EventID] = len(pending)
+			pending = append(pending, *rec.Event)
+		case "ack":
+			acked[rec.EventID] = true
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	out := pending[:0]
+	for _, ev := range pending {
+		if !acked[ev.EventID] {
+			out = append(out, ev)
+		}
+	}
+	return out, nil
+}
+
+// Append durably records an event as pending.
+func (o *Outbox) Append(ev Event) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := o.write(outboxRecord{Op: "add", Event: &ev}); err != nil {
+		return err
+	}
+	o.pending = append(o.pending, ev)
+	select {
+	case o.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Pending returns a copy of the events not yet delivered, oldest first.
+func (o *Outbox) Pending() []Event {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	out := make([]Event, len(o.pending))
+	copy(out, o.pending)
+	return out
+}
+
+// Ack marks an event as delivered.
+func (o *Outbox) Ack(eventID string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i, ev := range o.pending {
+		if ev.EventID == eventID {
+			o.pending = append(o.pending[:i], o.pending[i+1:]...)
+			break
+		}
+	}
+	if err := o.write(outboxRecord{Op: "ack", EventID: eventID}); err != nil {
+		return err
+	}
+	o.acked++
+	if len(o.pending) == 0 || o.acked >= outboxCompactAfter {
+		return o.compact()
+	}
+	return nil
+}
+
+// Close closes the outbox file.
+func (o *Outbox) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.f.Close()
+}
+
+// write appends one record and syncs it. Callers hold o.mu.
+func (o *Outbox) write(rec outboxRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if _, err := o.f.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return o.f.Sync()
+}
+
+// compact rewrites the file with only the pending events. Callers hold o.mu.
+func (o *Outbox) compact() error {
+	var b strings.Builder
+	for i := range o.pending {
+		data, err := json.Marshal(outboxRecord{Op: "add", Event: &o.pending[i]})
+		if err != nil {
+			return err
+		}
+		b.Write(data)
+		b.WriteByte('\n')
+	}
+
+	tmp := o.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(b.String()), 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, o.path); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(o.path, os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	o.f.Close()
+	o.f = f
+	o.acked = 0
+	return nil
+}
+
+// errPermanent marks a delivery failure that retrying cannot fix.
+var errPermanent = errors.New("permanent delivery failure")
+
+// Flush delivers pending events in order, acknowledging each one the API
+// accepts. It stops at the first retryable failure. Events the API rejects
+// outright are dropped (and logged) so they cannot block the queue.
+func (o *Outbox) Flush(ctx context.Context, send func(context.Context, Event) error) (int, error) {
+	sent := 0
+	for _, ev := range o.Pending() {
+		if err := ctx.Err(); err != nil {
+			return sent, err
+		}
+		if err := send(ctx, ev); err != nil {
+			if !errors.Is(err, errPermanent) {
+				return sent, err
+			}
+			LogJSON("warn", "event rejected by API, dropping", map[string]interface{}{
+				"event_id": ev.EventID, "error": err.Error(),
+			})
+		} else {
+			sent++
+		}
+		if err := o.Ack(ev.EventID); err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
+
+// DeliverOutbox drains the outbox to the API until ctx is done, retrying
+// with exponential backoff while the API is unreachable. Failures and the
+// pending count are recorded in m, which may be nil.
+func DeliverOutbox(ctx context.Context, o *Outbox, apiOrigin, token string, m *Metrics) {
+	send := func(ctx context.Context, ev Event) error {
+		err := SendEvent(ctx, apiOrigin, token, ev)
+		switch {
+		case err == nil, ctx.Err() != nil:
+		case errors.Is(err, errPermanent):
+			m.inc(metricPostFailures, "rejected")
+		default:
+			m.inc(metricPostFailures, "retryable")
+		}
+		return err
+	}
+	const (
+		minBackoff = time.Second
+		maxBackoff = 5 * time.Minute
+		idlePoll   = 30 * time.Second
+	)
+	backoff := minBackoff
+
+	for {
+		_, err := o.Flush(ctx, send)
+		m.set(metricOutboxPending, float64(len(o.Pending())))
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			LogJSON("warn", "event delivery failed, will retry", map[string]interface{}{
+				"error":   err.Error(),
+				"pending": len(o.Pending()),
+				"backoff": backoff.String(),
+			})
+			// New events wait for the retry rather than hammering the API.
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, maxBackoff)
+			continue
+		}
+		backoff = minBackoff
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-o.notify:
+		case <-time.After(idlePoll):
+		}
+	}
+}
+
+// SendEvent posts one event to the API. The event ID doubles as the
+// idempotency key, so redelivery after a lost response is harmless.
+func SendEvent(ctx context.Context, apiOrigin, token string, ev Event) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errPermanent, err)
+	}
+
+	url := strings.TrimRight(apiOrigin, "/") + "/v1/signal/events"
+	req, err := newJSONRequest("POST", url, data)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Idempotency-Key", ev.EventID)
+
+	resp, err := newHTTPClient(10 * time.Second).Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode < 300, resp.StatusCode == http.StatusConflict: // conflict: already recorded
+		return nil
+	case resp.StatusCode == http.StatusBadRequest, resp.StatusCode == http.StatusUnprocessableEntity,
+		resp.StatusCode == http.StatusRequestEntityTooLarge:
+		return fmt.Errorf("%w: HTTP %d", errPermanent, resp.StatusCode)
+	default:
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,146 @@
+package signal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestOutbox_SurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.jsonl")
+	o, err := OpenOutbox(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"e1", "e2", "e3"} {
+		if err := o.Append(Event{EventID: id, EventType: "order_placed"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := o.Ack("e2"); err != nil {
+		t.Fatal(err)
+	}
+	o.Close()
+
+	// A crash mid-write leaves a torn line; it is ignored.
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	f.WriteString(`{"op":"add","event":{"event_id":"e4"`)
+	f.Close()
+
+	pending, err := ReadOutbox(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 2 || pending[0].EventID != "e1" || pending[1].EventID != "e3" {
+		t.Fatalf("pending = %+v", pending)
+	}
+
+	o, err = OpenOutbox(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer o.Close()
+	if got := o.Pending(); len(got) != 2 {
+		t.Fatalf("reopened pending = %+v", got)
+	}
+	o.Ack("e1")
+	o.Ack("e3")
+	if info, _ := os.Stat(path); info.Size() != 0 {
+		t.Errorf("fully acked outbox should be truncated, size = %d", info.Size())
+	}
+}
+
+func TestOutbox_Flush(t *testing.T) {
+	o, err := OpenOutbox(filepath.Join(t.TempDir(), "outbox.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer o.Close()
+	for _, id := range []string{"e1", "bad", "e2", "e3"} {
+		o.Append(Event{EventID: id})
+	}
+
+	fail := "e2"
+	send := func(_ context.Context, ev Event) error {
+		switch ev.EventID {
+		case "bad":
+			return errPermanent
+		case fail:
+			return errors.New("network down")
+		}
+		return nil
+	}
+
+	n, err := o.Flush(context.Background(), send)
+	if err == nil || n != 1 {
+		t.Fatalf("first flush: n=%d err=%v", n, err)
+	}
+	if p := o.Pending(); len(p) != 2 || p[0].EventID != "e2" {
+		t.Fatalf("pending after failure = %+v", p)
+	}
+
+	fail = ""
+	if n, err := o.Flush(context.Background(), send); err != nil || n != 2 || len(o.Pending()) != 0 {
+		t.Fatalf("second flush: n=%d err=%v pending=%d", n, err, len(o.Pending()))
+	}
+}
+
+func TestSendEvent(t *testing.T) {
+	var mu sync.Mutex
+	status := http.StatusOK
+	var keys []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	ev := Event{EventID: "evt_1", EventType: "order_placed"}
+	tests := []struct {
+		status    int
+		wantErr   bool
+		permanent bool
+	}{
+		{http.StatusOK, false, false},
+		{http.StatusConflict, false, false},
+		{http.StatusServiceUnavailable, true, false},
+		{http.StatusUnprocessableEntity, true, true},
+	}
+	for _, tt := range tests {
+		mu.Lock()
+		status = tt.status
+		mu.Unlock()
+		err := SendEvent(context.Background(), srv.URL, "tok", ev)
+		if (err != nil) != tt.wantErr || errors.Is(err, errPermanent) != tt.permanent {
+			t.Errorf("HTTP %d: err = %v", tt.status, err)
+		}
+	}
+	if keys[0] != "evt_1" {
+		t.Errorf("idempotency key = %q", keys[0])
+	}
+}
+
+func TestEngine_EventsReachOutboxWhenChannelFull(t *testing.T) {
+	o, err := OpenOutbox(filepath.Join(t.TempDir(), "outbox.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer o.Close()
+
+	engine := NewEngine(&mockBroker{}, DefaultEngineConfig(), make(chan Event)) // unbuffered: every send drops
+	engine.SetOutbox(o)
+	engine.SetRules([]*Rule{qualityRule()})
+	engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"Go": 1}})
+
+	pending := o.Pending()
+	if len(pending) != 2 || pending[0].EventType != "entry_triggered" || pending[1].EventType != "order_placed" {
+		t.Fatalf("outbox = %+v", pending)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/haiphen/haiphen-cli/internal/broker"
 	"gopkg.in/yaml.v3"
@@ -19,6 +20,7 @@ type PositionEvent struct {
 	BuySellID       int     `json:"buy_sell_id"`
 	Underlying      string  `json:"underlying"`
 	ContractName    string  `json:"contract_name"`
+	Qty             float64 `json:"qty,omitempty"` // upstream position size, in contracts
 	OptionType      string  `json:"option_type,omitempty"`
 	StrikePrice     float64 `json:"strike_price,omitempty"`
 	ExpirationDate  string  `json:"expiration_date,omitempty"`
@@ -62,6 +64,33 @@ type PositionFilter struct {
 	MaxQty            int      `yaml:"max_qty,omitempty"           json:"max_qty,omitempty"`
 	OrderTypeOverride string   `yaml:"order_type_override,omitempty" json:"order_type_override,omitempty"`
 	ScaleFactor       float64  `yaml:"scale_factor,omitempty"      json:"scale_factor,omitempty"`
+
+	// Sizing picks how ScaleFactor and Notional turn into a quantity (see
+	// copysizing.go); the budgets cap the premium open copies may deploy.
+	Sizing           string  `yaml:"sizing,omitempty"            json:"sizing,omitempty"`
+	Notional         float64 `yaml:"notional,omitempty"          json:"notional,omitempty"`
+	Multiplier       float64 `yaml:"multiplier,omitempty"        json:"multiplier,omitempty"`
+	UnderlyingBudget float64 `yaml:"underlying_budget,omitempty" json:"underlying_budget,omitempty"`
+	TotalBudget      float64 `yaml:"total_budget,omitempty"      json:"total_budget,omitempty"`
+
+	// Contract and market criteria (see copyfilter.go), checked when a
+	// position opens. The pointer bounds are optional because 0 is a
+	// meaningful value for them.
+	EntryConditions        []string `yaml:"entry_conditions,omitempty"         json:"entry_conditions,omitempty"`
+	ExcludeEntryConditions []string `yaml:"exclude_entry_conditions,omitempty" json:"exclude_entry_conditions,omitempty"`
+	MinDTE                 int      `yaml:"min_dte,omitempty"                  json:"min_dte,omitempty"`
+	MaxDTE                 *int     `yaml:"max_dte,omitempty"                  json:"max_dte,omitempty"`
+	MinIV                  float64  `yaml:"min_iv,omitempty"                   json:"min_iv,omitempty"`
+	MaxIV                  float64  `yaml:"max_iv,omitempty"                   json:"max_iv,omitempty"`
+	MinMoneyness           *float64 `yaml:"min_moneyness,omitempty"            json:"min_moneyness,omitempty"` // fraction of spot; > 0 is in the money
+	MaxMoneyness           *float64 `yaml:"max_moneyness,omitempty"            json:"max_moneyness,omitempty"`
+	MinBid                 float64  `yaml:"min_bid,omitempty"                  json:"min_bid,omitempty"`
+	MaxSpread              float64  `yaml:"max_spread,omitempty"               json:"max_spread,omitempty"`     // ask - bid, in dollars
+	MaxSpreadPct           float64  `yaml:"max_spread_pct,omitempty"           json:"max_spread_pct,omitempty"` // (ask - bid) / mid
+
+	// Caps on open copied positions, overall and per underlying.
+	MaxPositions              int `yaml:"max_positions,omitempty"                json:"max_positions,omitempty"`
+	MaxPositionsPerUnderlying int `yaml:"max_positions_per_underlying,omitempty" json:"max_positions_per_underlying,omitempty"`
 }
 
 // DefaultPositionFilter returns a disabled filter (passes nothing until configured).
@@ -74,6 +103,11 @@ func DefaultPositionFilter() *PositionFilter {
 
 // Match returns true if the position event passes all filter criteria.
 func (f *PositionFilter) Match(p PositionEvent) bool {
+	return f.MatchAt(p, time.Now())
+}
+
+// MatchAt is Match with days to expiration counted from now.
+func (f *PositionFilter) MatchAt(p PositionEvent, now time.Time) bool {
 	if !f.Enabled {
 		return false
 	}
@@ -129,17 +163,16 @@ func (f *PositionFilter) Match(p PositionEvent) bool {
 		return false
 	}
 
-	return true
+	return f.matchContract(p, now)
 }
 
-// ToEntryOrder constructs a broker OrderRequest for an entry (active) position.
+// ToEntryOrder constructs a broker OrderRequest for an entry (active)
+// position, sized by the filter's sizing mode. The quantity is 0 when the
+// mode cannot size it (e.g. notional sizing without a price).
 func (p *PositionEvent) ToEntryOrder(f *PositionFilter) broker.OrderRequest {
 	qty := 1.0
-	if f != nil && f.ScaleFactor > 0 {
-		qty = f.ScaleFactor
-	}
-	if f != nil && f.MaxQty > 0 && qty > float64(f.MaxQty) {
-		qty = float64(f.MaxQty)
+	if f != nil {
+		qty = f.entryQty(*p)
 	}
 
 	orderType := p.EntryOrderType
@@ -156,12 +189,15 @@ func (p *PositionEvent) ToEntryOrder(f *PositionFilter) broker.OrderRequest {
 	}
 
 	req := broker.OrderRequest{
-		Symbol: p.ContractName,
+		Symbol: p.contractSymbol(),
 		Qty:    qty,
 		Side:   side,
 		Type:   orderType,
 		TIF:    "day",
 	}
+	if p.isOption() {
+		req.AssetClass, req.PositionIntent = broker.AssetClassOption, broker.IntentOpen
+	}
 
 	if orderType == "limit" && p.EntryLimitPrice > 0 {
 		req.LimitPrice = p.EntryLimitPrice
@@ -170,14 +206,12 @@ func (p *PositionEvent) ToEntryOrder(f *PositionFilter) broker.OrderRequest {
 	return req
 }
 
-// ToExitOrder constructs a broker OrderRequest for an exit (closing) position.
+// ToExitOrder constructs a broker OrderRequest for an exit (closing)
+// position. The engine replaces the quantity with the one it entered.
 func (p *PositionEvent) ToExitOrder(f *PositionFilter) broker.OrderRequest {
 	qty := 1.0
-	if f != nil && f.ScaleFactor > 0 {
-		qty = f.ScaleFactor
-	}
-	if f != nil && f.MaxQty > 0 && qty > float64(f.MaxQty) {
-		qty = float64(f.MaxQty)
+	if f != nil {
+		qty = f.entryQty(*p)
 	}
 
 	orderType := p.ExitOrderType
@@ -195,12 +229,15 @@ func (p *PositionEvent) ToExitOrder(f *PositionFilter) broker.OrderRequest {
 	}
 
 	req := broker.OrderRequest{
-		Symbol: p.ContractName,
+		Symbol: p.contractSymbol(),
 		Qty:    qty,
 		Side:   side,
 		Type:   orderType,
 		TIF:    "day",
 	}
+	if p.isOption() {
+		req.AssetClass, req.PositionIntent = broker.AssetClassOption, broker.IntentClose
+	}
 
 	if orderType == "limit" && p.ExitLimitPrice > 0 {
 		req.LimitPrice = p.ExitLimitPrice
@@ -209,6 +246,33 @@ func (p *PositionEvent) ToExitOrder(f *PositionFilter) broker.OrderRequest {
 	return req
 }
 
+// isOption reports whether the position is an option leg.
+func (p *PositionEvent) isOption() bool {
+	return p.OptionType != "" || broker.IsOptionSymbol(p.ContractName)
+}
+
+// contractSymbol is the symbol to order the position with. Option legs use
+// the compact OCC symbol, built from the contract fields when upstream
+// sends no parseable ContractName.
+func (p *PositionEvent) contractSymbol() string {
+	if !p.isOption() {
+		return p.ContractName
+	}
+	if c, err := broker.ParseOCC(p.ContractName); err == nil {
+		return c.Symbol()
+	}
+	exp, ok := p.expiration()
+	if !ok || p.Underlying == "" || p.StrikePrice <= 0 {
+		return p.ContractName
+	}
+	return broker.OptionContract{
+		Underlying: p.Underlying,
+		Expiration: exp,
+		Type:       p.OptionType,
+		Strike:     p.StrikePrice,
+	}.Symbol()
+}
+
 // ParsePositionEvents extracts position events from a WebSocket message.
 func ParsePositionEvents(data []byte) ([]PositionEvent, error) {
 	var envelope struct {
@@ -260,6 +324,9 @@ func LoadPositionFilter(profile string) (*PositionFilter, error) {
 	if f.ScaleFactor <= 0 {
 		f.ScaleFactor = 1.0
 	}
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
 
 	return &f, nil
 }
@@ -275,6 +275,40 @@ func TestToExitOrder_ReverseSell(t *testing.T) {
 	}
 }
 
+func TestToOrder_OptionContract(t *testing.T) {
+	// No contract name: the OCC symbol is built from the contract fields.
+	ev := PositionEvent{
+		Underlying:     "SPY",
+		OptionType:     "put",
+		StrikePrice:    512.5,
+		ExpirationDate: "2026-03-20",
+		EntrySide:      "sell",
+	}
+	f := &PositionFilter{ScaleFactor: 1.0}
+
+	entry := ev.ToEntryOrder(f)
+	if entry.Symbol != "SPY260320P00512500" {
+		t.Errorf("Symbol = %q, want SPY260320P00512500", entry.Symbol)
+	}
+	if entry.AssetClass != broker.AssetClassOption || entry.PositionIntent != broker.IntentOpen {
+		t.Errorf("entry = %+v, want an opening option order", entry)
+	}
+	if err := broker.ValidateOrderLimits(entry, broker.DefaultSafetyConfig()); err != nil {
+		t.Errorf("entry fails validation: %v", err)
+	}
+
+	exit := ev.ToExitOrder(f)
+	if exit.Side != "buy" || exit.PositionIntent != broker.IntentClose {
+		t.Errorf("exit = %+v, want a closing buy", exit)
+	}
+
+	// Padded OCC symbols are sent compact.
+	ev = PositionEvent{ContractName: "SPY   260320P00512500", EntrySide: "buy"}
+	if got := ev.ToEntryOrder(f).Symbol; got != "SPY260320P00512500" {
+		t.Errorf("Symbol = %q, want SPY260320P00512500", got)
+	}
+}
+
 // mockBroker for testing position processing
 type mockBroker struct {
 	mu     sync.Mutex
@@ -0,0 +1,223 @@
+package signal
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/haiphen/haiphen-cli/internal/broker"
+)
+
+// Order kinds recorded in OrderRef.Kind.
+const (
+	orderEntry     = "entry"      // rule entry
+	orderExit      = "exit"       // rule exit or flatten
+	orderExitLeg   = "exit_leg"   // take-profit / stop-loss leg of a rule entry
+	orderCopyEntry = "copy_entry" // copy-trade entry
+	orderCopyExit  = "copy_exit"  // copy-trade exit
+)
+
+// orderRefTTL bounds how long an order without a terminal update is kept
+// for correlation (a GTC order can outlive a day; a lost update should not
+// be kept forever).
+const orderRefTTL = 7 * 24 * time.Hour
+
+// OrderRef correlates a broker order with the rule target or copied position
+// that placed it, so trade updates can be attributed.
+type OrderRef struct {
+	RuleID     string    `json:"rule_id"`
+	Symbol     string    `json:"symbol,omitempty"`
+	Target     string    `json:"target,omitempty"` // rule_id[:symbol] lifecycle key
+	Kind       string    `json:"kind"`
+	Side       string    `json:"side,omitempty"`
+	PositionID string    `json:"position_id,omitempty"` // copy-trade only
+	PlacedAt   time.Time `json:"placed_at"`
+}
+
+// trackOrder remembers an accepted order and its attached legs. Callers hold
+// e.mu.
+func (e *Engine) trackOrder(order *broker.Order, ref OrderRef) {
+	e.pruneOrders(ref.PlacedAt)
+	e.orders[order.OrderID] = &ref
+	for _, leg := range order.Legs {
+		if leg.OrderID == "" {
+			continue
+		}
+		legRef := ref
+		legRef.Kind, legRef.Side = orderExitLeg, leg.Side
+		e.orders[leg.OrderID] = &legRef
+	}
+}
+
+// pruneOrders drops correlations older than orderRefTTL. Callers hold e.mu.
+func (e *Engine) pruneOrders(now time.Time) {
+	for id, ref := range e.orders {
+		if now.Sub(ref.PlacedAt) > orderRefTTL {
+			delete(e.orders, id)
+		}
+	}
+}
+
+// tradeUpdateEvent maps a broker trade update to the event it reports, or ""
+// for updates that are not reported (new, accepted, replaced, ...).
+func tradeUpdateEvent(update string) string {
+	switch strings.ToLower(update) {
+	case "fill":
+		return "order_filled"
+	case "partial_fill":
+		return "order_partially_filled"
+	case "rejected":
+		return "order_rejected"
+	case "canceled", "cancelled", "expired", "done_for_day":
+		return "order_canceled"
+	}
+	return ""
+}
+
+// ProcessTradeUpdate reports a broker trade update for an order the engine
+// placed and advances the rule lifecycle it belongs to. Updates for orders
+// the engine did not place are ignored.
+func (e *Engine) ProcessTradeUpdate(ctx context.Context, ev broker.StreamEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	defer e.checkpoint()
+
+	ref, ok := e.orders[ev.OrderID]
+	if !ok {
+		return
+	}
+	eventType := tradeUpdateEvent(ev.Type)
+	if eventType == "" {
+		return
+	}
+	now := e.now()
+
+	// Partial fills report this execution; the final fill reports the
+	// order's totals.
+	qty, price := ev.FillQty, ev.Price
+	if eventType == "order_filled" {
+		if ev.FilledQty > 0 {
+			qty = ev.FilledQty
+		}
+		if ev.FilledAvgPrice > 0 {
+			price = ev.FilledAvgPrice
+		}
+	}
+	var reason string
+	if eventType == "order_canceled" || eventType == "order_rejected" {
+		reason = ev.Type
+	}
+
+	e.emitEvent(Event{
+		EventID:    e.nextEventID(),
+		RuleID:     ref.RuleID,
+		EventType:  eventType,
+		Symbol:     ev.Symbol,
+		OrderID:    ev.OrderID,
+		OrderSide:  ev.Side,
+		OrderQty:   qty,
+		OrderPrice: price,
+		Reason:     reason,
+		DaemonID:   e.config.DaemonID,
+		CreatedAt:  now.UTC().Format(time.RFC3339),
+	})
+	log.Printf("[engine] order %s (%s %s %s): %s qty=%g price=%.2f",
+		ev.OrderID, ref.Kind, ref.RuleID, ev.Symbol, ev.Type, qty, price)
+
+	status := ev.Status
+	if status == "" {
+		status = orderStatusFor(ev.Type)
+	}
+	if st, ok := e.ruleStates[ref.Target]; ok {
+		order := &broker.Order{OrderID: ev.OrderID, Qty: ev.Qty, FilledQty: ev.FilledQty, Status: status}
+		switch ref.Kind {
+		case orderEntry:
+			if st.State == StatePending && st.EntryOrderID == ev.OrderID {
+				e.applyOrderStatus(st, order, now)
+			}
+		case orderExit:
+			if st.State == StateExiting && st.ExitOrderID == ev.OrderID {
+				e.applyOrderStatus(st, order, now)
+			}
+		case orderExitLeg:
+			// A take-profit or stop-loss leg closed the position.
+			if status == "filled" && (st.State == StateLong || st.State == StateShort) {
+				log.Printf("[engine] rule %s %s: %s → flat (exit leg %s filled)",
+					st.RuleID, st.Symbol, st.State, ev.OrderID)
+				*st = RuleState{RuleID: st.RuleID, Symbol: st.Symbol, State: StateFlat, UpdatedAt: now}
+			}
+		}
+	}
+
+	// Rejections count toward the circuit breaker.
+	if eventType == "order_rejected" {
+		e.recordOrderResult(ctx, true, now)
+	}
+
+	if eventType != "order_partially_filled" {
+		delete(e.orders, ev.OrderID)
+	}
+}
+
+// orderStatusFor is the order status implied by a trade update event, for
+// brokers whose updates do not carry the status.
+func orderStatusFor(update string) string {
+	switch strings.ToLower(update) {
+	case "fill":
+		return "filled"
+	case "partial_fill":
+		return "partially_filled"
+	case "cancelled":
+		return "canceled"
+	}
+	return strings.ToLower(update)
+}
+
+// streamTradeUpdates feeds the broker's trade updates to the engine until
+// ctx is done, reconnecting with backoff. Updates missed while disconnected
+// are still picked up by the lifecycle's order polling.
+func streamTradeUpdates(ctx context.Context, engine *Engine, b broker.Broker) {
+	backoff := time.Second
+	const maxBackoff = 2 * time.Minute
+
+	for {
+		updates := make(chan broker.StreamEvent, 64)
+		done := make(chan error, 1)
+		go func() { done <- b.StreamUpdates(ctx, updates) }()
+
+		LogJSON("info", "streaming broker trade updates", map[string]interface{}{
+			"broker": b.Name(),
+		})
+		var err error
+	recv:
+		for {
+			select {
+			case ev := <-updates:
+				backoff = time.Second
+				engine.ProcessTradeUpdate(ctx, ev)
+			case err = <-done:
+				break recv
+			}
+		}
+		// The stream may have queued updates before it ended.
+		for len(updates) > 0 {
+			engine.ProcessTradeUpdate(ctx, <-updates)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		LogJSON("warn", "broker trade update stream disconnected", map[string]interface{}{
+			"error":   fmt.Sprintf("%v", err),
+			"backoff": backoff.String(),
+		})
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
@@ -0,0 +1,101 @@
+package signal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/haiphen/haiphen-cli/internal/broker"
+)
+
+func eventsOfType(evs []Event, typ string) []Event {
+	var out []Event
+	for _, ev := range evs {
+		if ev.EventType == typ {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
+
+func TestEngine_TradeUpdatesAdvanceLifecycle(t *testing.T) {
+	b := newLifecycleBroker()
+	events := make(chan Event, 64)
+	engine := NewEngine(b, DefaultEngineConfig(), events)
+	now := time.Date(2026, 2, 10, 14, 30, 0, 0, time.UTC)
+	engine.SetClock(func() time.Time { return now })
+	engine.SetRules([]*Rule{lifecycleRule()})
+
+	engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"In": 1}})
+	drainEvents(events)
+
+	engine.ProcessTradeUpdate(context.Background(), broker.StreamEvent{
+		Type: "partial_fill", OrderID: "o1", Symbol: "SPY", Side: "buy",
+		Qty: 5, FillQty: 2, FilledQty: 2, Price: 100.5, Status: "partially_filled",
+	})
+	if st := engine.RuleStates()[0]; st.State != StatePending {
+		t.Fatalf("partial fill moved state to %s", st.State)
+	}
+	engine.ProcessTradeUpdate(context.Background(), broker.StreamEvent{
+		Type: "fill", OrderID: "o1", Symbol: "SPY", Side: "buy",
+		Qty: 5, FillQty: 3, FilledQty: 5, Price: 101, FilledAvgPrice: 100.8, Status: "filled",
+	})
+	if st := engine.RuleStates()[0]; st.State != StateLong || st.Qty != 5 {
+		t.Fatalf("after fill: %+v", st)
+	}
+
+	evs := drainEvents(events)
+	partial := eventsOfType(evs, "order_partially_filled")
+	if len(partial) != 1 || partial[0].OrderQty != 2 || partial[0].OrderPrice != 100.5 || partial[0].RuleID != "swing" {
+		t.Fatalf("partial events = %+v", partial)
+	}
+	filled := eventsOfType(evs, "order_filled")
+	if len(filled) != 1 || filled[0].OrderQty != 5 || filled[0].OrderPrice != 100.8 {
+		t.Fatalf("filled events = %+v", filled)
+	}
+
+	// A repeated or unknown update is not reported again.
+	engine.ProcessTradeUpdate(context.Background(), broker.StreamEvent{Type: "fill", OrderID: "o1", Status: "filled"})
+	engine.ProcessTradeUpdate(context.Background(), broker.StreamEvent{Type: "fill", OrderID: "manual", Status: "filled"})
+	if evs := drainEvents(events); len(evs) != 0 {
+		t.Fatalf("unexpected events: %+v", evs)
+	}
+}
+
+func TestEngine_TradeUpdateRejectedEntry(t *testing.T) {
+	b := newLifecycleBroker()
+	events := make(chan Event, 64)
+	engine := NewEngine(b, DefaultEngineConfig(), events)
+	engine.SetRules([]*Rule{lifecycleRule()})
+
+	engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"In": 1}})
+	drainEvents(events)
+	engine.ProcessTradeUpdate(context.Background(), broker.StreamEvent{Type: "rejected", OrderID: "o1", Symbol: "SPY", Status: "rejected"})
+
+	if st := engine.RuleStates()[0]; st.State != StateFlat || st.EntryOrderID != "" {
+		t.Fatalf("after reject: %+v", st)
+	}
+	rej := eventsOfType(drainEvents(events), "order_rejected")
+	if len(rej) != 1 || rej[0].Reason != "rejected" {
+		t.Fatalf("rejected events = %+v", rej)
+	}
+}
+
+func TestEngine_TradeUpdateExitLeg(t *testing.T) {
+	engine := NewEngine(nil, DefaultEngineConfig(), make(chan Event, 64))
+	engine.SetRules([]*Rule{lifecycleRule()})
+
+	engine.mu.Lock()
+	st := engine.stateFor(engine.targets[0])
+	st.State, st.Side, st.Qty = StateLong, "buy", 5
+	engine.trackOrder(&broker.Order{
+		OrderID: "parent",
+		Legs:    []broker.Order{{OrderID: "tp", Side: "sell"}, {OrderID: "sl", Side: "sell"}},
+	}, OrderRef{RuleID: "swing", Symbol: "SPY", Target: engine.targets[0].key, Kind: orderEntry, PlacedAt: engine.now()})
+	engine.mu.Unlock()
+
+	engine.ProcessTradeUpdate(context.Background(), broker.StreamEvent{Type: "fill", OrderID: "tp", Symbol: "SPY", Side: "sell", Status: "filled"})
+	if got := engine.RuleStates()[0]; got.State != StateFlat {
+		t.Fatalf("take-profit fill left state %s", got.State)
+	}
+}
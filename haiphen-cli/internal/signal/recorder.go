@@ -0,0 +1,295 @@
+package signal
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default recorder limits.
+const (
+	DefaultRecordingMaxBytes = 64 << 20 // rotate after 64 MiB of uncompressed feed data
+	DefaultRecordingMaxFiles = 30       // keep this many journal files per profile
+)
+
+const recordingExt = ".jsonl.gz"
+
+// RecordedMessage is one line of a feed journal: the raw WebSocket message
+// plus the local time the daemon received it.
+type RecordedMessage struct {
+	RecvAt string          `json:"recv_at"`
+	Msg    json.RawMessage `json:"msg"`
+}
+
+// RecordingInfo describes a journal file on disk.
+type RecordingInfo struct {
+	Name    string    `json:"name"`
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// RecordingsDir returns the feed journal directory for a profile.
+func RecordingsDir(profile string) (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(configDir, "haiphen", "recordings", profile)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// Recorder appends raw feed messages to gzip-compressed JSONL journals,
+// starting a new file each UTC day or once MaxBytes have been written.
+type Recorder struct {
+	mu       sync.Mutex
+	dir      string
+	maxBytes int64
+	maxFiles int
+	now      func() time.Time
+
+	f       *os.File
+	gz      *gzip.Writer
+	day     string
+	written int64
+}
+
+// NewRecorder creates a recorder writing into dir. Zero limits use the defaults.
+func NewRecorder(dir string, maxBytes int64, maxFiles int) *Recorder {
+	if maxBytes <= 0 {
+		maxBytes = DefaultRecordingMaxBytes
+	}
+	if maxFiles <= 0 {
+		maxFiles = DefaultRecordingMaxFiles
+	}
+	return &Recorder{
+		dir:      dir,
+		maxBytes: maxBytes,
+		maxFiles: maxFiles,
+		now:      time.Now,
+	}
+}
+
+// Write appends one raw feed message. Each write is flushed through gzip so
+// a crash loses at most the message being written.
+func (r *Recorder) Write(msg []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now().UTC()
+	day := now.Format("2006-01-02")
+	if r.gz == nil || day != r.day || r.written >= r.maxBytes {
+		if err := r.rotate(now); err != nil {
+			return err
+		}
+	}
+
+	// Keep malformed frames too (as a JSON string) so the journal is a
+	// faithful record of everything the daemon received.
+	if !json.Valid(msg) {
+		msg, _ = json.Marshal(string(msg))
+	}
+
+	line, err := json.Marshal(RecordedMessage{
+		RecvAt: now.Format(time.RFC3339Nano),
+		Msg:    json.RawMessage(msg),
+	})
+	if err != nil {
+		return fmt.Errorf("encode recording: %w", err)
+	}
+	line = append(line, '\n')
+
+	n, err := r.gz.Write(line)
+	r.written += int64(n)
+	if err != nil {
+		return err
+	}
+	return r.gz.Flush()
+}
+
+// Close finalizes the current journal file.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.closeCurrent()
+}
+
+func (r *Recorder) closeCurrent() error {
+	if r.gz == nil {
+		return nil
+	}
+	gzErr := r.gz.Close()
+	fErr := r.f.Close()
+	r.gz, r.f = nil, nil
+	if gzErr != nil {
+		return gzErr
+	}
+	return fErr
+}
+
+func (r *Recorder) rotate(now time.Time) error {
+	if err := r.closeCurrent(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(r.dir, 0o700); err != nil {
+		return err
+	}
+
+	name := "feed-" + now.Format("20060102T150405.000Z") + recordingExt
+	f, err := os.OpenFile(filepath.Join(r.dir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+
+	r.f = f
+	r.gz = gzip.NewWriter(f)
+	r.day = now.Format("2006-01-02")
+	r.written = 0
+
+	return r.prune()
+}
+
+// prune removes the oldest journals beyond maxFiles.
+func (r *Recorder) prune() error {
+	recs, err := listRecordingsIn(r.dir)
+	if err != nil {
+		return err
+	}
+	for len(recs) > r.maxFiles {
+		if err := os.Remove(recs[0].Path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		recs = recs[1:]
+	}
+	return nil
+}
+
+// ListRecordings returns a profile's journal files, oldest first.
+func ListRecordings(profile string) ([]RecordingInfo, error) {
+	dir, err := RecordingsDir(profile)
+	if err != nil {
+		return nil, err
+	}
+	return listRecordingsIn(dir)
+}
+
+func listRecordingsIn(dir string) ([]RecordingInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var recs []RecordingInfo
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), recordingExt) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		recs = append(recs, RecordingInfo{
+			Name:    strings.TrimSuffix(e.Name(), recordingExt),
+			Path:    filepath.Join(dir, e.Name()),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+	}
+	// Names embed the UTC start time, so lexical order is chronological.
+	sort.Slice(recs, func(i, j int) bool { return recs[i].Name < recs[j].Name })
+	return recs, nil
+}
+
+// ResolveRecording maps a recording name (with or without extension) or a
+// filesystem path to a journal file path.
+func ResolveRecording(profile, nameOrPath string) (string, error) {
+	if _, err := os.Stat(nameOrPath); err == nil {
+		return nameOrPath, nil
+	}
+	dir, err := RecordingsDir(profile)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, strings.TrimSuffix(filepath.Base(nameOrPath), recordingExt)+recordingExt)
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("recording %q not found", nameOrPath)
+	}
+	return path, nil
+}
+
+// ReadRecording calls fn for every message in a journal, in order.
+// A truncated final line (e.g. after a crash) ends the read without error.
+func ReadRecording(path string, fn func(RecordedMessage) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", filepath.Base(path), err)
+	}
+	defer gz.Close()
+
+	br := bufio.NewReader(gz)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			var m RecordedMessage
+			if jErr := json.Unmarshal(line, &m); jErr != nil {
+				return fmt.Errorf("decode %s: %w", filepath.Base(path), jErr)
+			}
+			if fnErr := fn(m); fnErr != nil {
+				return fnErr
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// ExportRecording writes a journal as plain JSONL. With raw set, only the
+// original feed messages are written, which `signal backtest --file` accepts.
+func ExportRecording(path string, w io.Writer, raw bool) (int, error) {
+	bw := bufio.NewWriter(w)
+	n := 0
+	err := ReadRecording(path, func(m RecordedMessage) error {
+		var line []byte
+		if raw {
+			line = m.Msg
+		} else {
+			var err error
+			if line, err = json.Marshal(m); err != nil {
+				return err
+			}
+		}
+		if _, err := bw.Write(line); err != nil {
+			return err
+		}
+		n++
+		return bw.WriteByte('\n')
+	})
+	if err != nil {
+		return n, err
+	}
+	return n, bw.Flush()
+}
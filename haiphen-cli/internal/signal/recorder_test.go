@@ -0,0 +1,158 @@
+package signal
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecorder_WriteAndRead(t *testing.T) {
+	dir := t.TempDir()
+	rec := NewRecorder(dir, 0, 0)
+	rec.now = func() time.Time { return time.Date(2026, 2, 10, 14, 30, 0, 0, time.UTC) }
+
+	msgs := []string{
+		`{"type":"hello"}`,
+		`{"type":"snapshot","date":"2026-02-10","rows":[{"kpi":"X","value":"1"}]}`,
+	}
+	for _, m := range msgs {
+		if err := rec.Write([]byte(m)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	// Readable before Close: every write is flushed.
+	recs, err := listRecordingsIn(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 1 {
+		t.Fatalf("expected 1 recording, got %d", len(recs))
+	}
+	var got []string
+	if err := ReadRecording(recs[0].Path, func(m RecordedMessage) error {
+		got = append(got, string(m.Msg))
+		if m.RecvAt != "2026-02-10T14:30:00Z" {
+			t.Errorf("RecvAt = %q", m.RecvAt)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("ReadRecording: %v", err)
+	}
+	if len(got) != 2 || got[0] != msgs[0] || got[1] != msgs[1] {
+		t.Fatalf("unexpected messages: %v", got)
+	}
+
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	snaps, err := LoadSnapshotsFile(recs[0].Path)
+	if err != nil {
+		t.Fatalf("LoadSnapshotsFile: %v", err)
+	}
+	if len(snaps) != 1 || snaps[0].KPIs["X"] != 1 {
+		t.Fatalf("unexpected snapshots from recording: %+v", snaps)
+	}
+}
+
+func TestRecorder_RotatesAndPrunes(t *testing.T) {
+	dir := t.TempDir()
+	rec := NewRecorder(dir, 10, 2) // tiny limit: every write rotates
+	tick := time.Date(2026, 2, 10, 14, 30, 0, 0, time.UTC)
+	rec.now = func() time.Time {
+		tick = tick.Add(time.Second)
+		return tick
+	}
+
+	for i := 0; i < 4; i++ {
+		if err := rec.Write([]byte(`{"type":"hello"}`)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	rec.Close()
+
+	recs, err := listRecordingsIn(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 2 {
+		t.Fatalf("expected 2 recordings after pruning, got %d", len(recs))
+	}
+	if recs[0].Name >= recs[1].Name {
+		t.Fatalf("recordings not ordered oldest first: %s, %s", recs[0].Name, recs[1].Name)
+	}
+}
+
+func TestRecorder_RotatesOnNewDay(t *testing.T) {
+	dir := t.TempDir()
+	rec := NewRecorder(dir, 0, 0)
+	now := time.Date(2026, 2, 10, 23, 59, 0, 0, time.UTC)
+	rec.now = func() time.Time { return now }
+
+	rec.Write([]byte(`{"type":"hello"}`))
+	now = now.Add(2 * time.Minute)
+	rec.Write([]byte(`{"type":"hello"}`))
+	rec.Close()
+
+	recs, _ := listRecordingsIn(dir)
+	if len(recs) != 2 {
+		t.Fatalf("expected a new journal after midnight, got %d files", len(recs))
+	}
+}
+
+func TestReadRecording_TruncatedTail(t *testing.T) {
+	dir := t.TempDir()
+	rec := NewRecorder(dir, 0, 0)
+	rec.Write([]byte(`{"type":"hello"}`))
+	// Simulate a crash: the gzip stream is flushed but never closed.
+	recs, _ := listRecordingsIn(dir)
+
+	n := 0
+	if err := ReadRecording(recs[0].Path, func(RecordedMessage) error { n++; return nil }); err != nil {
+		t.Fatalf("ReadRecording on unterminated journal: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("read %d messages, want 1", n)
+	}
+	rec.Close()
+}
+
+func TestExportRecording_Raw(t *testing.T) {
+	dir := t.TempDir()
+	rec := NewRecorder(dir, 0, 0)
+	rec.Write([]byte(`{"type":"hello"}`))
+	rec.Close()
+	recs, _ := listRecordingsIn(dir)
+
+	var buf bytes.Buffer
+	n, err := ExportRecording(recs[0].Path, &buf, true)
+	if err != nil {
+		t.Fatalf("ExportRecording: %v", err)
+	}
+	if n != 1 || strings.TrimSpace(buf.String()) != `{"type":"hello"}` {
+		t.Fatalf("raw export = %q (%d)", buf.String(), n)
+	}
+
+	buf.Reset()
+	if _, err := ExportRecording(recs[0].Path, &buf, false); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"recv_at"`) {
+		t.Fatalf("full export missing recv_at: %q", buf.String())
+	}
+}
+
+func TestResolveRecording_Path(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "x*.jsonl.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	got, err := ResolveRecording("unused", f.Name())
+	if err != nil || got != f.Name() {
+		t.Fatalf("ResolveRecording(path) = %q, %v", got, err)
+	}
+}
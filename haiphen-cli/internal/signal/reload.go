@@ -0,0 +1,199 @@
+package signal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// defaultReloadInterval is how often the daemon polls the signals directory
+// and positions.yaml for changes.
+const defaultReloadInterval = 2 * time.Second
+
+// RuleDiff describes how a ruleset changed, by rule name.
+type RuleDiff struct {
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	Changed []string `json:"changed,omitempty"`
+}
+
+// Empty reports whether nothing changed.
+func (d RuleDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffRules compares two rulesets by rule ID.
+func DiffRules(prev, next []*Rule) RuleDiff {
+	old := make(map[string]*Rule, len(prev))
+	for _, r := range prev {
+		old[r.RuleID] = r
+	}
+	var d RuleDiff
+	seen := make(map[string]bool, len(next))
+	for _, r := range next {
+		seen[r.RuleID] = true
+		o, ok := old[r.RuleID]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, r.Name)
+		case !sameRule(o, r):
+			d.Changed = append(d.Changed, r.Name)
+		}
+	}
+	for _, r := range prev {
+		if !seen[r.RuleID] {
+			d.Removed = append(d.Removed, r.Name)
+		}
+	}
+	sort.Strings(d.Added)
+	sort.Strings(d.Removed)
+	sort.Strings(d.Changed)
+	return d
+}
+
+func sameRule(a, b *Rule) bool {
+	aj, err1 := json.Marshal(a)
+	bj, err2 := json.Marshal(b)
+	return err1 == nil && err2 == nil && string(aj) == string(bj)
+}
+
+// ReloadRules re-reads the rules directory and swaps the engine's ruleset.
+// A rule whose edited file fails validation keeps running in its previous
+// form; a directory that cannot be read leaves the ruleset untouched.
+// Cooldowns, hourly caps and lifecycle state carry over for rule IDs that
+// remain.
+func ReloadRules(engine *Engine, dcfg DaemonConfig) (RuleDiff, map[string]error, error) {
+	active, skipped, err := LoadActiveRules(dcfg.RulesDir, dcfg.MaxOrderQty)
+	if err != nil {
+		return RuleDiff{}, nil, err
+	}
+
+	prev := engine.Rules()
+	for name := range skipped {
+		for _, r := range prev {
+			if r.Name == name {
+				active = append(active, r)
+			}
+		}
+	}
+
+	diff := DiffRules(prev, active)
+	if !diff.Empty() {
+		engine.SetRules(active)
+	}
+	return diff, skipped, nil
+}
+
+// reloadConfig reloads rules and the position filter, logging what changed.
+func reloadConfig(engine *Engine, dcfg DaemonConfig, reason string) {
+	diff, skipped, err := ReloadRules(engine, dcfg)
+	if err != nil {
+		LogJSON("error", "rule reload failed, keeping current rules", map[string]interface{}{
+			"reason": reason, "error": err.Error(),
+		})
+	} else {
+		for name, err := range skipped {
+			LogJSON("warn", "skipping invalid rule", map[string]interface{}{
+				"rule": name, "error": err.Error(),
+			})
+		}
+		if !diff.Empty() {
+			LogJSON("info", "rules reloaded", map[string]interface{}{
+				"reason":  reason,
+				"added":   diff.Added,
+				"removed": diff.Removed,
+				"changed": diff.Changed,
+				"rules":   len(engine.Rules()),
+			})
+		}
+	}
+
+	f, err := LoadPositionFilter(dcfg.Profile)
+	if err != nil {
+		LogJSON("warn", "position filter reload failed, keeping current filter", map[string]interface{}{
+			"reason": reason, "error": err.Error(),
+		})
+		return
+	}
+	if engine.swapPositionFilter(f) {
+		LogJSON("info", "position filter reloaded", map[string]interface{}{
+			"reason":     reason,
+			"copy_trade": f.Enabled,
+		})
+	}
+}
+
+// swapPositionFilter installs f if it differs from the current filter.
+func (e *Engine) swapPositionFilter(f *PositionFilter) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.posFilter != nil {
+		a, _ := json.Marshal(e.posFilter)
+		b, _ := json.Marshal(f)
+		if string(a) == string(b) {
+			return false
+		}
+	}
+	e.posFilter = f
+	return true
+}
+
+// watchConfig reloads rules and the position filter when the signals
+// directory or positions.yaml changes, or on SIGHUP.
+func watchConfig(ctx context.Context, engine *Engine, dcfg DaemonConfig) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	interval := dcfg.ReloadInterval
+	if interval == 0 {
+		interval = defaultReloadInterval
+	}
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	last := configFingerprint(dcfg)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			last = configFingerprint(dcfg)
+			reloadConfig(engine, dcfg, "sighup")
+		case <-tick:
+			if fp := configFingerprint(dcfg); fp != last {
+				last = fp
+				reloadConfig(engine, dcfg, "file change")
+			}
+		}
+	}
+}
+
+// configFingerprint summarizes the name, size and mtime of every rule file
+// and positions.yaml, so polling can detect edits without reading them.
+func configFingerprint(dcfg DaemonConfig) string {
+	var b strings.Builder
+	if entries, err := os.ReadDir(dcfg.RulesDir); err == nil {
+		for _, e := range entries {
+			if info, err := e.Info(); err == nil && !e.IsDir() {
+				fmt.Fprintf(&b, "%s:%d:%d;", e.Name(), info.Size(), info.ModTime().UnixNano())
+			}
+		}
+	}
+	if path, err := PositionFilterPath(dcfg.Profile); err == nil {
+		if info, err := os.Stat(path); err == nil {
+			fmt.Fprintf(&b, "positions:%d:%d", info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	return b.String()
+}
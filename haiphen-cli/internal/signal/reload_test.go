@@ -0,0 +1,106 @@
+package signal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func reloadRule(name string, threshold float64) *Rule {
+	return &Rule{
+		Version:  1,
+		Name:     name,
+		Status:   "active",
+		Entry:    &ConditionGroup{AllOf: []ConditionOrGroup{{KPI: "X", Operator: ">", Value: threshold}}},
+		Order:    OrderParams{Side: "buy", Type: "market", Qty: 1, TIF: "day"},
+		Cooldown: 600,
+	}
+}
+
+func TestDiffRules(t *testing.T) {
+	a, b, c := reloadRule("a", 1), reloadRule("b", 1), reloadRule("c", 1)
+	for _, r := range []*Rule{a, b, c} {
+		r.RuleID = DeterministicID("", r.Name)
+	}
+	b2 := *b
+	b2.Cooldown = 120
+	d := reloadRule("d", 1)
+	d.RuleID = DeterministicID("", "d")
+
+	got := DiffRules([]*Rule{a, b, c}, []*Rule{a, &b2, d})
+	want := RuleDiff{Added: []string{"d"}, Removed: []string{"c"}, Changed: []string{"b"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("diff = %+v, want %+v", got, want)
+	}
+	if !DiffRules([]*Rule{a}, []*Rule{a}).Empty() {
+		t.Error("identical rulesets should have an empty diff")
+	}
+}
+
+func TestReloadRules(t *testing.T) {
+	dir := t.TempDir()
+	if err := SaveRule(dir, reloadRule("alpha", 5)); err != nil {
+		t.Fatal(err)
+	}
+	dcfg := DaemonConfig{RulesDir: dir}
+
+	b := &mockBroker{}
+	engine := NewEngine(b, DefaultEngineConfig(), make(chan Event, 16))
+	now := time.Date(2026, 2, 10, 15, 0, 0, 0, time.UTC)
+	engine.SetClock(func() time.Time { return now })
+	if _, _, err := ReloadRules(engine, dcfg); err != nil {
+		t.Fatal(err)
+	}
+	engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"X": 10}})
+	if len(b.orders) != 1 {
+		t.Fatalf("expected one order, got %d", len(b.orders))
+	}
+
+	// Editing the rule keeps its cooldown: no second order within 600s.
+	if err := SaveRule(dir, reloadRule("alpha", 3)); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveRule(dir, reloadRule("beta", 50)); err != nil {
+		t.Fatal(err)
+	}
+	diff, _, err := ReloadRules(engine, dcfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(diff, RuleDiff{Added: []string{"beta"}, Changed: []string{"alpha"}}) {
+		t.Fatalf("diff = %+v", diff)
+	}
+	now = now.Add(time.Minute)
+	engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"X": 10}})
+	if len(b.orders) != 1 {
+		t.Fatalf("cooldown lost across reload: %d orders", len(b.orders))
+	}
+
+	// An invalid edit keeps the previous version running.
+	bad := reloadRule("alpha", 3)
+	bad.Order.Side = "hold"
+	if err := SaveRule(dir, bad); err != nil {
+		t.Fatal(err)
+	}
+	diff, skipped, err := ReloadRules(engine, dcfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skipped["alpha"] == nil || !diff.Empty() || len(engine.Rules()) != 2 {
+		t.Fatalf("invalid edit: diff=%+v skipped=%v rules=%d", diff, skipped, len(engine.Rules()))
+	}
+
+	// An unparseable file leaves the ruleset untouched.
+	if err := os.WriteFile(filepath.Join(dir, "broken.yaml"), []byte("entry: ["), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ReloadRules(engine, dcfg); err == nil {
+		t.Fatal("expected parse error")
+	}
+	if len(engine.Rules()) != 2 {
+		t.Fatalf("rules = %d after failed reload", len(engine.Rules()))
+	}
+}
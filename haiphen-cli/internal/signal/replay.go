@@ -0,0 +1,223 @@
+package signal
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/haiphen/haiphen-cli/internal/broker"
+)
+
+// ReplayConfig controls an offline replay of a recorded feed journal.
+type ReplayConfig struct {
+	Rules          []*Rule
+	PositionFilter *PositionFilter // nil disables copy-trade, as in a fresh daemon
+	Engine         EngineConfig
+	// Equity is the account equity and buying power the replay broker
+	// reports; rules sized from the account need it to place orders.
+	Equity float64
+}
+
+// ReplayResult is the exact sequence of engine events produced by a replay.
+type ReplayResult struct {
+	Recording       string    `json:"recording"`
+	Equity          float64   `json:"equity"`
+	Messages        int       `json:"messages"`
+	Snapshots       int       `json:"snapshots"`
+	PositionBatches int       `json:"position_batches"`
+	ParseFailures   int       `json:"parse_failures"`
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	Events          []Event   `json:"events"`
+	// Digest is a SHA-256 over Events; identical inputs always yield the same digest.
+	Digest string `json:"digest"`
+}
+
+// Replay feeds a recorded feed journal through a fresh Engine, driving its
+// clock from each message's receive time. Orders go to an in-memory broker
+// that accepts everything, so safety checks, cooldowns and order decisions
+// are reproduced without touching a brokerage.
+func Replay(ctx context.Context, path string, cfg ReplayConfig) (*ReplayResult, error) {
+	events := make(chan Event, 256)
+	// A dry-run engine never orders, but still sizes rules against the
+	// replay account.
+	rb := &replayBroker{equity: cfg.Equity}
+	engine := NewEngine(rb, cfg.Engine, events)
+	engine.SetRules(cfg.Rules)
+	if cfg.PositionFilter != nil {
+		engine.SetPositionFilter(cfg.PositionFilter)
+	}
+
+	var clock time.Time
+	engine.SetClock(func() time.Time { return clock })
+
+	res := &ReplayResult{Recording: path, Equity: cfg.Equity}
+	err := ReadRecording(path, func(m RecordedMessage) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		res.Messages++
+
+		t, err := time.Parse(time.RFC3339Nano, m.RecvAt)
+		if err != nil {
+			return fmt.Errorf("message %d: bad recv_at %q", res.Messages, m.RecvAt)
+		}
+		clock = t
+		rb.now = t
+		if res.Start.IsZero() {
+			res.Start = t
+		}
+		res.End = t
+
+		var envelope struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(m.Msg, &envelope); err != nil {
+			res.ParseFailures++
+			return nil
+		}
+
+		switch envelope.Type {
+		case "snapshot":
+			snap, err := ParseSnapshot(m.Msg)
+			if err != nil {
+				res.ParseFailures++
+				return nil
+			}
+			res.Snapshots++
+			engine.Evaluate(ctx, snap)
+		case "position_events":
+			evs, err := ParsePositionEvents(m.Msg)
+			if err != nil {
+				res.ParseFailures++
+				return nil
+			}
+			res.PositionBatches++
+			engine.ProcessPositionEvents(ctx, evs)
+		}
+
+		res.Events = append(res.Events, drainEvents(events)...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data, _ := json.Marshal(res.Events)
+	res.Digest = fmt.Sprintf("%x", sha256.Sum256(data))
+	return res, nil
+}
+
+// drainEvents collects everything currently buffered on ch without blocking.
+func drainEvents(ch <-chan Event) []Event {
+	var out []Event
+	for {
+		select {
+		case ev := <-ch:
+			out = append(out, ev)
+		default:
+			return out
+		}
+	}
+}
+
+// replayBroker fills every order immediately with a sequential ID, holds the
+// positions those fills build, and reports fixed equity, so replays exercise
+// the engine's order path deterministically.
+type replayBroker struct {
+	now       time.Time
+	equity    float64
+	orders    []broker.Order
+	positions map[string]float64 // symbol → signed qty; negative is short
+}
+
+func (b *replayBroker) Name() string                  { return "replay" }
+func (b *replayBroker) Connect(context.Context) error { return nil }
+func (b *replayBroker) Close() error                  { return nil }
+
+func (b *replayBroker) GetAccount(context.Context) (*broker.Account, error) {
+	return &broker.Account{
+		AccountID:      "replay",
+		Currency:       "USD",
+		Cash:           b.equity,
+		BuyingPower:    b.equity,
+		Equity:         b.equity,
+		LastEquity:     b.equity,
+		PortfolioValue: b.equity,
+		IsPaper:        true,
+	}, nil
+}
+
+func (b *replayBroker) GetPositions(context.Context) ([]broker.Position, error) {
+	var out []broker.Position
+	for sym, qty := range b.positions {
+		side := "long"
+		if qty < 0 {
+			side = "short"
+		}
+		out = append(out, broker.Position{Symbol: sym, Qty: math.Abs(qty), Side: side})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Symbol < out[j].Symbol })
+	return out, nil
+}
+
+func (b *replayBroker) CreateOrder(_ context.Context, req broker.OrderRequest) (*broker.Order, error) {
+	o := broker.Order{
+		OrderID:    fmt.Sprintf("replay-%d", len(b.orders)+1),
+		Symbol:     req.Symbol,
+		Qty:        req.Qty,
+		Side:       req.Side,
+		Type:       req.Type,
+		LimitPrice: req.LimitPrice,
+		StopPrice:  req.StopPrice,
+		TIF:        req.TIF,
+		OrderClass: req.OrderClass,
+		Status:     "filled",
+		FilledQty:  req.Qty,
+		CreatedAt:  b.now,
+	}
+	b.orders = append(b.orders, o)
+
+	qty := req.Qty
+	if req.Side == "sell" {
+		qty = -qty
+	}
+	if b.positions == nil {
+		b.positions = make(map[string]float64)
+	}
+	b.positions[req.Symbol] += qty
+	if math.Abs(b.positions[req.Symbol]) < qtyEpsilon {
+		delete(b.positions, req.Symbol)
+	}
+	return &o, nil
+}
+
+func (b *replayBroker) CancelOrder(context.Context, string) error    { return nil }
+func (b *replayBroker) CancelAllOrders(context.Context) (int, error) { return 0, nil }
+
+func (b *replayBroker) GetOrders(context.Context, string, int) ([]broker.Order, error) {
+	return b.orders, nil
+}
+
+func (b *replayBroker) GetOrderByID(_ context.Context, orderID string) (*broker.Order, error) {
+	for i := range b.orders {
+		if b.orders[i].OrderID == orderID {
+			o := b.orders[i]
+			return &o, nil
+		}
+	}
+	return nil, fmt.Errorf("order %s not found", orderID)
+}
+
+func (b *replayBroker) ProbeConstraints(context.Context) (*broker.AccountConstraints, error) {
+	return &broker.AccountConstraints{}, nil
+}
+
+func (b *replayBroker) StreamUpdates(ctx context.Context, _ chan<- broker.StreamEvent) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
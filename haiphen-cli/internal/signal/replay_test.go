@@ -0,0 +1,165 @@
+package signal
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// writeJournal records msgs one minute apart starting at start.
+func writeJournal(t *testing.T, start time.Time, msgs ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	rec := NewRecorder(dir, 0, 0)
+	now := start
+	rec.now = func() time.Time { return now }
+	for _, m := range msgs {
+		if err := rec.Write([]byte(m)); err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(time.Minute)
+	}
+	rec.Close()
+	recs, _ := listRecordingsIn(dir)
+	if len(recs) != 1 {
+		t.Fatalf("expected 1 journal, got %d", len(recs))
+	}
+	return recs[0].Path
+}
+
+func replayRules() []*Rule {
+	return []*Rule{{
+		RuleID:   "r1",
+		Name:     "replay-rule",
+		Status:   "active",
+		Symbols:  []string{"SPY"},
+		Entry:    &ConditionGroup{AllOf: []ConditionOrGroup{{KPI: "X", Operator: ">", Value: 5}}},
+		Order:    OrderParams{Side: "buy", Type: "market", Qty: 1, TIF: "day"},
+		Cooldown: 120,
+	}}
+}
+
+func TestReplay_Deterministic(t *testing.T) {
+	start := time.Date(2026, 2, 10, 14, 30, 0, 0, time.UTC)
+	snap := `{"type":"snapshot","date":"2026-02-10","rows":[{"kpi":"X","value":"10"}]}`
+	path := writeJournal(t, start,
+		`{"type":"hello"}`,
+		snap, // 14:31 trigger + order
+		snap, // 14:32 cooldown (120s) still active
+		snap, // 14:33 cooldown elapsed → trigger + order
+		`not json`,
+	)
+
+	cfg := ReplayConfig{Rules: replayRules(), Engine: DefaultEngineConfig()}
+	cfg.Engine.DaemonID = "replay"
+
+	res1, err := Replay(context.Background(), path, cfg)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	res2, err := Replay(context.Background(), path, ReplayConfig{Rules: replayRules(), Engine: cfg.Engine})
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+
+	if res1.Messages != 5 || res1.Snapshots != 3 || res1.ParseFailures != 1 {
+		t.Fatalf("counts: messages=%d snapshots=%d failures=%d", res1.Messages, res1.Snapshots, res1.ParseFailures)
+	}
+
+	want := []string{"entry_triggered", "order_placed", "entry_triggered", "order_placed"}
+	if len(res1.Events) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(res1.Events), len(want), res1.Events)
+	}
+	for i, w := range want {
+		if res1.Events[i].EventType != w {
+			t.Errorf("event %d = %s, want %s", i, res1.Events[i].EventType, w)
+		}
+	}
+	if res1.Events[0].CreatedAt != "2026-02-10T14:31:00Z" {
+		t.Errorf("event timestamp = %s, want recorded receive time", res1.Events[0].CreatedAt)
+	}
+	if res1.Events[1].OrderID != "replay-1" {
+		t.Errorf("order id = %s, want replay-1", res1.Events[1].OrderID)
+	}
+
+	if res1.Digest != res2.Digest {
+		t.Fatalf("replays differ: %s vs %s", res1.Digest, res2.Digest)
+	}
+	for i := range res1.Events {
+		if res1.Events[i] != res2.Events[i] {
+			t.Fatalf("event %d differs:\n%+v\n%+v", i, res1.Events[i], res2.Events[i])
+		}
+	}
+}
+
+func TestReplay_DryRun(t *testing.T) {
+	start := time.Date(2026, 2, 10, 14, 30, 0, 0, time.UTC)
+	path := writeJournal(t, start, `{"type":"snapshot","rows":[{"kpi":"X","value":"10"}]}`)
+
+	cfg := ReplayConfig{Rules: replayRules(), Engine: DefaultEngineConfig()}
+	cfg.Engine.DryRun = true
+	res, err := Replay(context.Background(), path, cfg)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if len(res.Events) != 1 || res.Events[0].EventType != "entry_triggered" {
+		t.Fatalf("dry-run replay events: %+v", res.Events)
+	}
+}
+
+func TestReplay_SizedRuleUsesEquity(t *testing.T) {
+	start := time.Date(2026, 2, 10, 14, 30, 0, 0, time.UTC)
+	path := writeJournal(t, start, `{"type":"snapshot","rows":[{"kpi":"X","value":"10"},{"kpi":"P","value":"500"}]}`)
+
+	rules := replayRules()
+	rules[0].Order.Qty = 0
+	rules[0].Order.Sizing = &Sizing{Mode: SizePctEquity, Percent: 5, PriceKPI: "P"}
+	res, err := Replay(context.Background(), path, ReplayConfig{Rules: rules, Engine: DefaultEngineConfig(), Equity: 100000})
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	// 5% of $100,000 at $500 a share.
+	placed := eventsOfType(res.Events, "order_placed")
+	if len(placed) != 1 || placed[0].OrderQty != 10 {
+		t.Fatalf("events = %+v, want an order for 10 shares", res.Events)
+	}
+}
+
+func TestReplay_ExitClosesEntry(t *testing.T) {
+	start := time.Date(2026, 2, 10, 14, 30, 0, 0, time.UTC)
+	path := writeJournal(t, start,
+		`{"type":"snapshot","rows":[{"kpi":"X","value":"10"},{"kpi":"Y","value":"0"}]}`, // entry
+		`{"type":"snapshot","rows":[{"kpi":"X","value":"0"},{"kpi":"Y","value":"0"}]}`,  // entry fill observed
+		`{"type":"snapshot","rows":[{"kpi":"X","value":"0"},{"kpi":"Y","value":"10"}]}`, // exit
+	)
+
+	rules := replayRules()
+	rules[0].Cooldown = 60
+	rules[0].Exit = &ConditionGroup{AllOf: []ConditionOrGroup{{KPI: "Y", Operator: ">", Value: 5}}}
+	res, err := Replay(context.Background(), path, ReplayConfig{Rules: rules, Engine: DefaultEngineConfig()})
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	placed := eventsOfType(res.Events, "order_placed")
+	if len(placed) != 2 || placed[1].OrderSide != "sell" || placed[1].OrderQty != 1 {
+		t.Fatalf("events = %+v, want the entry and a 1-share exit", res.Events)
+	}
+}
+
+func TestReplay_DryRunSizesRules(t *testing.T) {
+	start := time.Date(2026, 2, 10, 14, 30, 0, 0, time.UTC)
+	path := writeJournal(t, start, `{"type":"snapshot","rows":[{"kpi":"X","value":"10"},{"kpi":"P","value":"500"}]}`)
+
+	rules := replayRules()
+	rules[0].Order.Qty = 0
+	rules[0].Order.Sizing = &Sizing{Mode: SizePctEquity, Percent: 5, PriceKPI: "P"}
+	cfg := ReplayConfig{Rules: rules, Engine: DefaultEngineConfig(), Equity: 100000}
+	cfg.Engine.DryRun = true
+	res, err := Replay(context.Background(), path, cfg)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if len(res.Events) != 1 || res.Events[0].EventType != "entry_triggered" || res.Events[0].OrderQty != 10 {
+		t.Fatalf("events = %+v, want a 10-share dry-run entry", res.Events)
+	}
+}
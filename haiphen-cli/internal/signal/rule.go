@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"strings"
 )
 
 // Rule defines a signal rule with entry/exit conditions and order parameters.
@@ -19,6 +20,7 @@ type Rule struct {
 	Order       OrderParams      `yaml:"order"       json:"order"`
 	Cooldown    int              `yaml:"cooldown"    json:"cooldown"`
 	Temporal    *TemporalConfig  `yaml:"temporal,omitempty" json:"temporal,omitempty"`
+	DataQuality *RuleDataQuality `yaml:"data_quality,omitempty" json:"data_quality,omitempty"`
 }
 
 // ConditionGroup is an AND/OR tree of conditions.
@@ -29,10 +31,14 @@ type ConditionGroup struct {
 
 // ConditionOrGroup is either a leaf condition or a nested group.
 type ConditionOrGroup struct {
-	// Leaf fields
-	KPI      string  `yaml:"kpi,omitempty"      json:"kpi,omitempty"`
-	Operator string  `yaml:"operator,omitempty" json:"operator,omitempty"`
-	Value    float64 `yaml:"value,omitempty"    json:"value,omitempty"`
+	// Leaf fields. A leaf compares either a single KPI or an arithmetic
+	// expression over KPIs (see expr.go) against Value, or against
+	// ValueExpr when set (e.g. `kpi: Price, operator: ">", value_expr: "sma(Price, 20)"`).
+	KPI       string  `yaml:"kpi,omitempty"        json:"kpi,omitempty"`
+	Expr      string  `yaml:"expr,omitempty"       json:"expr,omitempty"`
+	Operator  string  `yaml:"operator,omitempty"   json:"operator,omitempty"`
+	Value     float64 `yaml:"value,omitempty"      json:"value,omitempty"`
+	ValueExpr string  `yaml:"value_expr,omitempty" json:"value_expr,omitempty"`
 
 	// Nested groups
 	AllOf []ConditionOrGroup `yaml:"all_of,omitempty" json:"all_of,omitempty"`
@@ -45,16 +51,66 @@ type OrderParams struct {
 	Type string  `yaml:"type" json:"type"`
 	Qty  float64 `yaml:"qty"  json:"qty"`
 	TIF  string  `yaml:"tif"  json:"tif"`
-}
 
-// TemporalConfig is a placeholder for per-contract time windows.
-type TemporalConfig struct {
-	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Sizing, when set, computes the entry quantity at trigger time and
+	// replaces Qty (see sizing.go).
+	Sizing *Sizing `yaml:"sizing,omitempty" json:"sizing,omitempty"`
+
+	// Attached exits (see brackets.go). Both legs submit a bracket order, one
+	// leg an OTO order; offsets are taken from the PriceKPI reference price.
+	TakeProfit *PriceOffset `yaml:"take_profit,omitempty" json:"take_profit,omitempty"`
+	StopLoss   *PriceOffset `yaml:"stop_loss,omitempty"   json:"stop_loss,omitempty"`
+	// PriceKPI is the reference price for take-profit/stop-loss offsets;
+	// may contain {symbol}. Defaults to Sizing.PriceKPI.
+	PriceKPI string `yaml:"price_kpi,omitempty" json:"price_kpi,omitempty"`
 }
 
-// IsLeaf returns true if this node is a leaf condition (has a KPI).
+// IsLeaf returns true if this node is a leaf condition (has a KPI or expression).
 func (c *ConditionOrGroup) IsLeaf() bool {
-	return c.KPI != ""
+	return c.KPI != "" || c.Expr != ""
+}
+
+// ReferencedKPIs returns the distinct KPI names used by the entry and exit
+// conditions, in the order they first appear. {symbol} placeholders are
+// resolved for each of the rule's symbols.
+func (r *Rule) ReferencedKPIs() []string {
+	var kpis []string
+	seen := make(map[string]bool)
+	add := func(k string) {
+		if k != "" && !seen[k] {
+			kpis = append(kpis, k)
+			seen[k] = true
+		}
+	}
+
+	for _, t := range expandRules([]*Rule{r}) {
+		if r.Order.Sizing != nil {
+			add(strings.ReplaceAll(r.Order.Sizing.PriceKPI, SymbolPlaceholder, t.symbol))
+		}
+		if r.Order.PriceKPI != "" {
+			add(strings.ReplaceAll(r.Order.PriceKPI, SymbolPlaceholder, t.symbol))
+		}
+		if r.DataQuality != nil {
+			for _, k := range r.DataQuality.RequiredKPIs {
+				add(strings.ReplaceAll(k, SymbolPlaceholder, t.symbol))
+			}
+		}
+		for _, g := range []*ConditionGroup{t.entry, t.exit} {
+			walkLeaves(g, func(c *ConditionOrGroup) {
+				add(c.KPI)
+				for _, src := range []string{c.Expr, c.ValueExpr} {
+					if src == "" {
+						continue
+					}
+					refs, _ := ExprKPIs(src)
+					for _, k := range refs {
+						add(k)
+					}
+				}
+			})
+		}
+	}
+	return kpis
 }
 
 // DeterministicID generates a rule ID from user + name.
@@ -75,12 +131,13 @@ func (r *Rule) ToAPIPayload() (map[string]interface{}, error) {
 		"name":                 r.Name,
 		"status":               r.Status,
 		"entry_conditions_json": string(entryJSON),
-		"order_side":           r.Order.Side,
-		"order_type":           r.Order.Type,
-		"order_qty":            r.Order.Qty,
-		"order_tif":            r.Order.TIF,
-		"cooldown_seconds":     r.Cooldown,
-		"version":              r.Version,
+		"order_side":            r.Order.Side,
+		"order_type":            r.Order.Type,
+		"order_qty":             r.Order.Qty,
+		"order_tif":             r.Order.TIF,
+		"cooldown_seconds":      r.Cooldown,
+		"version":               r.Version,
+		"version_hash":          r.ContentHash(),
 	}
 
 	if len(r.Symbols) > 0 {
@@ -96,10 +153,29 @@ func (r *Rule) ToAPIPayload() (map[string]interface{}, error) {
 		payload["exit_conditions_json"] = string(exitJSON)
 	}
 
+	if r.Order.Sizing != nil {
+		sj, _ := json.Marshal(r.Order.Sizing)
+		payload["order_sizing_json"] = string(sj)
+	}
+
+	if r.Order.TakeProfit != nil || r.Order.StopLoss != nil {
+		bj, _ := json.Marshal(map[string]interface{}{
+			"take_profit": r.Order.TakeProfit,
+			"stop_loss":   r.Order.StopLoss,
+			"price_kpi":   r.Order.referencePriceKPI(),
+		})
+		payload["order_bracket_json"] = string(bj)
+	}
+
 	if r.Temporal != nil {
 		tj, _ := json.Marshal(r.Temporal)
 		payload["temporal_json"] = string(tj)
 	}
 
+	if r.DataQuality != nil {
+		dj, _ := json.Marshal(r.DataQuality)
+		payload["data_quality_json"] = string(dj)
+	}
+
 	return payload, nil
 }
@@ -1,6 +1,10 @@
 package signal
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
@@ -171,6 +175,31 @@ func TestToAPIPayload(t *testing.T) {
 	}
 }
 
+func TestPushRules_ReportsSkipped(t *testing.T) {
+	var response string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, response)
+	}))
+	defer srv.Close()
+
+	rules := []*Rule{
+		{RuleID: "a1", Name: "fixed", Entry: &ConditionGroup{}, Order: OrderParams{Side: "buy", Qty: 10}},
+		{RuleID: "b2", Name: "sized", Entry: &ConditionGroup{}, Order: OrderParams{Side: "buy", Sizing: &Sizing{Mode: SizeNotional, Notional: 5000}}},
+	}
+
+	response = `{"ok":true,"upserted":1,"skipped":[{"rule_id":"b2","name":"sized","reason":"dynamically sized rules are not stored yet"}]}`
+	n, skipped, err := PushRules(context.Background(), srv.URL, "tok", rules)
+	if err != nil || n != 1 || len(skipped) != 1 || skipped[0].Name != "sized" {
+		t.Fatalf("PushRules = %d, %+v, %v; want 1 stored and sized skipped", n, skipped, err)
+	}
+
+	// An API that drops rules without listing them is an error.
+	response = `{"ok":true,"upserted":1}`
+	if _, _, err := PushRules(context.Background(), srv.URL, "tok", rules); err == nil {
+		t.Fatal("expected an error when the API stores fewer rules than sent")
+	}
+}
+
 func TestLoadRuleFile(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "test.yaml")
@@ -282,3 +311,23 @@ func TestSaveAndDeleteRule(t *testing.T) {
 		t.Fatal("expected file to be deleted")
 	}
 }
+
+func TestReferencedKPIs_IncludesExprKPIs(t *testing.T) {
+	r := &Rule{
+		Entry: &ConditionGroup{AllOf: []ConditionOrGroup{
+			{KPI: "A", Operator: ">", Value: 0},
+			{Expr: `"Unrealized P&L" / A`, Operator: "<", Value: -0.02},
+		}},
+		Exit: &ConditionGroup{AnyOf: []ConditionOrGroup{{Expr: "abs(B - A)", Operator: ">", Value: 1}}},
+	}
+	got := r.ReferencedKPIs()
+	want := []string{"A", "Unrealized P&L", "B"}
+	if len(got) != len(want) {
+		t.Fatalf("ReferencedKPIs = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("ReferencedKPIs = %v, want %v", got, want)
+		}
+	}
+}
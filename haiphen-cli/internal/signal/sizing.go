@@ -0,0 +1,149 @@
+package signal
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/haiphen/haiphen-cli/internal/broker"
+)
+
+// Sizing modes for OrderParams.Sizing.
+const (
+	SizeNotional       = "notional"         // fixed dollar amount
+	SizePctEquity      = "pct_equity"       // percent of account equity
+	SizePctBuyingPower = "pct_buying_power" // percent of buying power
+	SizeRisk           = "risk"             // risk Percent of equity over the stop distance
+)
+
+// Sizing computes an order quantity at trigger time from the account, so one
+// rule trades proportionally across accounts of different sizes. It replaces
+// OrderParams.Qty for entries.
+type Sizing struct {
+	Mode     string  `yaml:"mode"                json:"mode"`
+	Notional float64 `yaml:"notional,omitempty"  json:"notional,omitempty"` // dollars, for notional
+	Percent  float64 `yaml:"percent,omitempty"   json:"percent,omitempty"`  // 0-100, for pct_* and risk
+	// PriceKPI is the KPI used as the share price; may contain {symbol}.
+	PriceKPI string `yaml:"price_kpi" json:"price_kpi"`
+	// Stop distance for risk sizing: absolute per share, or percent of price.
+	StopDistance float64 `yaml:"stop_distance,omitempty" json:"stop_distance,omitempty"`
+	StopPct      float64 `yaml:"stop_pct,omitempty"      json:"stop_pct,omitempty"`
+	// Fractional allows fractional share quantities; otherwise qty rounds down.
+	Fractional bool `yaml:"fractional,omitempty" json:"fractional,omitempty"`
+}
+
+// SizeLabel is a short description of the order size for tables: the fixed
+// quantity, or the sizing mode (e.g. "$5000", "5%eq", "1%risk").
+func (o OrderParams) SizeLabel() string {
+	s := o.Sizing
+	if s == nil {
+		return fmt.Sprintf("%.0f", o.Qty)
+	}
+	switch s.Mode {
+	case SizeNotional:
+		return fmt.Sprintf("$%.0f", s.Notional)
+	case SizePctEquity:
+		return fmt.Sprintf("%g%%eq", s.Percent)
+	case SizePctBuyingPower:
+		return fmt.Sprintf("%g%%bp", s.Percent)
+	case SizeRisk:
+		return fmt.Sprintf("%g%%risk", s.Percent)
+	}
+	return s.Mode
+}
+
+// Validate checks the sizing parameters for the selected mode.
+func (s *Sizing) Validate() error {
+	if s.PriceKPI == "" {
+		return fmt.Errorf("sizing: price_kpi is required")
+	}
+	if !kpiNameRe.MatchString(strings.ReplaceAll(s.PriceKPI, SymbolPlaceholder, "X")) {
+		return fmt.Errorf("sizing: invalid price_kpi %q", s.PriceKPI)
+	}
+
+	switch s.Mode {
+	case SizeNotional:
+		if s.Notional <= 0 {
+			return fmt.Errorf("sizing: notional must be positive")
+		}
+	case SizePctEquity, SizePctBuyingPower:
+		if s.Percent <= 0 || s.Percent > 100 {
+			return fmt.Errorf("sizing: percent must be in (0, 100]")
+		}
+	case SizeRisk:
+		if s.Percent <= 0 || s.Percent > 100 {
+			return fmt.Errorf("sizing: percent (equity at risk) must be in (0, 100]")
+		}
+		if (s.StopDistance > 0) == (s.StopPct > 0) {
+			return fmt.Errorf("sizing: risk mode needs exactly one of stop_distance or stop_pct")
+		}
+		if s.StopDistance < 0 || s.StopPct < 0 || s.StopPct >= 100 {
+			return fmt.Errorf("sizing: invalid stop distance")
+		}
+	default:
+		return fmt.Errorf("sizing: invalid mode %q: must be one of: %s, %s, %s, %s",
+			s.Mode, SizeNotional, SizePctEquity, SizePctBuyingPower, SizeRisk)
+	}
+	return nil
+}
+
+// Qty returns the order quantity for an account at the given share price.
+func (s *Sizing) Qty(acct *broker.Account, price float64) (float64, error) {
+	if price <= 0 {
+		return 0, fmt.Errorf("sizing: price must be positive")
+	}
+
+	var qty float64
+	switch s.Mode {
+	case SizeNotional:
+		qty = s.Notional / price
+	case SizePctEquity:
+		qty = acct.Equity * s.Percent / 100 / price
+	case SizePctBuyingPower:
+		qty = acct.BuyingPower * s.Percent / 100 / price
+	case SizeRisk:
+		stop := s.StopDistance
+		if stop == 0 {
+			stop = price * s.StopPct / 100
+		}
+		qty = acct.Equity * s.Percent / 100 / stop
+	default:
+		return 0, fmt.Errorf("sizing: invalid mode %q", s.Mode)
+	}
+
+	if !s.Fractional {
+		qty = math.Floor(qty)
+	}
+	if qty <= 0 || math.IsNaN(qty) || math.IsInf(qty, 0) {
+		return 0, fmt.Errorf("sizing: %s sizing at $%.2f yields no shares", s.Mode, price)
+	}
+	return qty, nil
+}
+
+// sizeOrder resolves a rule's sizing for symbol against the live account and
+// the snapshot price. Callers hold e.mu.
+func (e *Engine) sizeOrder(ctx context.Context, s *Sizing, symbol string, snap *Snapshot) (float64, error) {
+	if e.broker == nil {
+		return 0, fmt.Errorf("sizing: no broker account to size against")
+	}
+	priceKPI := strings.ReplaceAll(s.PriceKPI, SymbolPlaceholder, symbol)
+	price, ok := snap.KPIs[priceKPI]
+	if !ok {
+		return 0, fmt.Errorf("sizing: price KPI %q missing from snapshot", priceKPI)
+	}
+	acct, err := e.broker.GetAccount(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("sizing: get account: %w", err)
+	}
+	qty, err := s.Qty(acct, price)
+	if err != nil {
+		return 0, err
+	}
+	// Market orders carry no price for ValidateOrderLimits to check, so the
+	// order value limit is enforced here using the sizing price.
+	if max := e.config.Safety.MaxOrderValue; max > 0 && qty*price > max {
+		return 0, fmt.Errorf("sizing: order value $%.2f exceeds max of $%.2f", qty*price, max)
+	}
+	return qty, nil
+}
@@ -0,0 +1,173 @@
+package signal
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/haiphen/haiphen-cli/internal/broker"
+)
+
+func TestSizing_Qty(t *testing.T) {
+	acct := &broker.Account{Equity: 50000, BuyingPower: 20000}
+
+	tests := []struct {
+		name  string
+		s     Sizing
+		price float64
+		want  float64
+	}{
+		{"notional", Sizing{Mode: SizeNotional, Notional: 1000}, 30, 33},
+		{"notional fractional", Sizing{Mode: SizeNotional, Notional: 1000, Fractional: true}, 40, 25},
+		{"pct equity", Sizing{Mode: SizePctEquity, Percent: 10}, 100, 50},
+		{"pct buying power", Sizing{Mode: SizePctBuyingPower, Percent: 50}, 100, 100},
+		{"risk stop distance", Sizing{Mode: SizeRisk, Percent: 1, StopDistance: 2.5}, 100, 200},
+		{"risk stop pct", Sizing{Mode: SizeRisk, Percent: 1, StopPct: 5}, 100, 100},
+	}
+	for _, tt := range tests {
+		got, err := tt.s.Qty(acct, tt.price)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: qty = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := (&Sizing{Mode: SizeNotional, Notional: 10}).Qty(acct, 30); err == nil {
+		t.Error("expected error when sizing rounds to zero shares")
+	}
+}
+
+func TestSizing_Validate(t *testing.T) {
+	bad := []Sizing{
+		{Mode: SizeNotional, PriceKPI: "P"},
+		{Mode: SizePctEquity, Percent: 150, PriceKPI: "P"},
+		{Mode: SizeRisk, Percent: 1, PriceKPI: "P"},
+		{Mode: SizeRisk, Percent: 1, StopDistance: 1, StopPct: 1, PriceKPI: "P"},
+		{Mode: "kelly", PriceKPI: "P"},
+		{Mode: SizeNotional, Notional: 100},
+	}
+	for _, s := range bad {
+		if err := s.Validate(); err == nil {
+			t.Errorf("expected %+v to be invalid", s)
+		}
+	}
+	ok := Sizing{Mode: SizeRisk, Percent: 1, StopPct: 2, PriceKPI: "{symbol}.price"}
+	if err := ok.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// sizingBroker reports a fixed account.
+type sizingBroker struct {
+	mockBroker
+	acct broker.Account
+}
+
+func (b *sizingBroker) GetAccount(context.Context) (*broker.Account, error) {
+	a := b.acct
+	return &a, nil
+}
+
+func TestEngine_SizedOrder(t *testing.T) {
+	b := &sizingBroker{acct: broker.Account{Equity: 100000, IsPaper: true}}
+	engine := NewEngine(b, DefaultEngineConfig(), make(chan Event, 16))
+	engine.SetRules([]*Rule{{
+		RuleID:  "sized",
+		Name:    "sized",
+		Status:  "active",
+		Symbols: []string{"AAPL"},
+		Entry:   &ConditionGroup{AllOf: []ConditionOrGroup{{KPI: "Go", Operator: ">", Value: 0}}},
+		Order: OrderParams{Side: "buy", Type: "market", TIF: "day",
+			Sizing: &Sizing{Mode: SizePctEquity, Percent: 5, PriceKPI: "{symbol}.price"}},
+		Cooldown: 60,
+	}})
+
+	engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"Go": 1, "AAPL.price": 200}})
+	if len(b.orders) != 1 || b.orders[0].Qty != 25 {
+		t.Fatalf("orders = %+v, want 25 shares", b.orders)
+	}
+}
+
+func TestEngine_SizedOrderExceedsMaxValue(t *testing.T) {
+	b := &sizingBroker{acct: broker.Account{Equity: 10000000, IsPaper: true}}
+	events := make(chan Event, 16)
+	engine := NewEngine(b, DefaultEngineConfig(), events)
+	engine.SetRules([]*Rule{{
+		RuleID:   "big",
+		Name:     "big",
+		Status:   "active",
+		Entry:    &ConditionGroup{AllOf: []ConditionOrGroup{{KPI: "Go", Operator: ">", Value: 0}}},
+		Order:    OrderParams{Side: "buy", Type: "market", TIF: "day", Sizing: &Sizing{Mode: SizePctEquity, Percent: 10, PriceKPI: "P"}},
+		Cooldown: 60,
+	}})
+
+	engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"Go": 1, "P": 10}})
+	if len(b.orders) != 0 {
+		t.Fatalf("expected order to be blocked, got %+v", b.orders)
+	}
+	evs := drainEvents(events)
+	if len(evs) != 2 || evs[1].EventType != "order_failed" {
+		t.Fatalf("events = %+v", evs)
+	}
+}
+
+func TestEngine_DryRunSizedRuleExits(t *testing.T) {
+	// A --dry-run daemon has no broker account to size against.
+	cfg := DefaultEngineConfig()
+	cfg.DryRun = true
+	events := make(chan Event, 16)
+	engine := NewEngine(nil, cfg, events)
+	now := time.Date(2026, 2, 10, 14, 30, 0, 0, time.UTC)
+	engine.SetClock(func() time.Time { return now })
+	r := lifecycleRule()
+	r.Order.Qty = 0
+	r.Order.Sizing = &Sizing{Mode: SizePctEquity, Percent: 5, PriceKPI: "{symbol}.price"}
+	engine.SetRules([]*Rule{r})
+
+	engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"In": 1, "SPY.price": 500}})
+	if st := engine.RuleStates()[0]; st.State != StateLong {
+		t.Fatalf("after unsized entry: %+v, want long", st)
+	}
+
+	now = now.Add(2 * time.Minute)
+	engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"In": 1, "Out": 1, "SPY.price": 500}})
+	if st := engine.RuleStates()[0]; st.State != StateFlat {
+		t.Fatalf("after exit: %+v, want flat", st)
+	}
+	want := []string{"entry_triggered", "exit_triggered"}
+	evs := drainEvents(events)
+	if len(evs) != len(want) || evs[0].EventType != want[0] || evs[1].EventType != want[1] {
+		t.Fatalf("events = %+v, want an entry then an exit", evs)
+	}
+}
+
+func TestValidateRule_Sizing(t *testing.T) {
+	r := &Rule{
+		Name:     "test",
+		Entry:    &ConditionGroup{AllOf: []ConditionOrGroup{{KPI: "X", Operator: ">", Value: 5}}},
+		Order:    OrderParams{Side: "buy", Type: "market", TIF: "day", Sizing: &Sizing{Mode: SizeNotional, Notional: 5000, PriceKPI: "X"}},
+		Cooldown: 60,
+	}
+	if err := ValidateRule(r, 1000); err != nil {
+		t.Fatalf("sized rule without qty should be valid: %v", err)
+	}
+
+	r.Order.Sizing.PriceKPI = "{symbol}.price"
+	if err := ValidateRule(r, 1000); err == nil || !strings.Contains(err.Error(), "no symbols") {
+		t.Fatalf("expected missing symbols error, got: %v", err)
+	}
+}
+
+func TestOrderParams_SizeLabel(t *testing.T) {
+	if got := (OrderParams{Qty: 10}).SizeLabel(); got != "10" {
+		t.Errorf("fixed label = %q", got)
+	}
+	if got := (OrderParams{Sizing: &Sizing{Mode: SizePctEquity, Percent: 2.5}}).SizeLabel(); got != "2.5%eq" {
+		t.Errorf("pct equity label = %q", got)
+	}
+}
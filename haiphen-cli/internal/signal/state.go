@@ -0,0 +1,189 @@
+package signal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/haiphen/haiphen-cli/internal/broker"
+)
+
+// EngineState is the engine's safety and position bookkeeping, checkpointed
+// to disk so a restarted daemon keeps its cooldowns, caps and positions.
+type EngineState struct {
+	SavedAt          time.Time                 `json:"saved_at"`
+	Cooldowns        map[string]time.Time      `json:"cooldowns,omitempty"`
+	TriggerTimes     map[string][]time.Time    `json:"trigger_times,omitempty"`
+	SessionOrders    int                       `json:"session_orders"`
+	TrackedPositions map[string]string         `json:"tracked_positions,omitempty"`
+	CopyAllocations  map[string]CopyAllocation `json:"copy_allocations,omitempty"`
+	RuleStates       map[string]*RuleState     `json:"rule_states,omitempty"`
+	Orders           map[string]*OrderRef      `json:"orders,omitempty"`
+	Breaker          BreakerState              `json:"breaker"`
+	Paused           bool                      `json:"paused,omitempty"`
+	PausedRules      map[string]bool           `json:"paused_rules,omitempty"`
+	DryRun           bool                      `json:"dry_run,omitempty"` // switched on over the control socket
+}
+
+// StatePath returns the engine state file path for a profile.
+func StatePath(profile string) (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "haiphen", fmt.Sprintf("signal.%s.state.json", profile)), nil
+}
+
+// SetStatePath enables checkpointing: from now on the engine writes its state
+// to path after every operation that changed it. An empty path disables it.
+func (e *Engine) SetStatePath(path string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.statePath = path
+	e.savedState = nil
+	e.checkpoint()
+}
+
+// RestoreState loads state saved by a previous run and returns what was
+// kept: expired cooldowns and trigger times older than an hour are dropped,
+// and the session order count only carries over within the same trading
+// day. A missing file returns nil and no error.
+func (e *Engine) RestoreState(path string) (*EngineState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var saved EngineState
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("parse engine state: %w", err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := e.now()
+	st := &EngineState{
+		SavedAt:          saved.SavedAt,
+		Cooldowns:        make(map[string]time.Time),
+		TriggerTimes:     make(map[string][]time.Time),
+		TrackedPositions: saved.TrackedPositions,
+		CopyAllocations:  saved.CopyAllocations,
+		RuleStates:       make(map[string]*RuleState),
+		Orders:           make(map[string]*OrderRef),
+		Breaker:          saved.Breaker,
+		Paused:           saved.Paused,
+		PausedRules:      saved.PausedRules,
+		DryRun:           saved.DryRun,
+	}
+
+	for key, until := range saved.Cooldowns {
+		if until.After(now) {
+			st.Cooldowns[key] = until
+			e.cooldowns[key] = until
+		}
+	}
+	cutoff := now.Add(-time.Hour)
+	for key, times := range saved.TriggerTimes {
+		var recent []time.Time
+		for _, t := range times {
+			if t.After(cutoff) {
+				recent = append(recent, t)
+			}
+		}
+		if len(recent) > 0 {
+			st.TriggerTimes[key] = recent
+			e.triggerCount[key] = recent
+		}
+	}
+	if sameTradingDay(saved.SavedAt, now) {
+		st.SessionOrders = saved.SessionOrders
+		e.sessionOrders = saved.SessionOrders
+	}
+	for id, orderID := range saved.TrackedPositions {
+		e.trackedPositions[id] = orderID
+	}
+	for id, a := range saved.CopyAllocations {
+		e.copyAllocs[id] = a
+	}
+	for key, rs := range saved.RuleStates {
+		if rs != nil {
+			st.RuleStates[key] = rs
+			e.ruleStates[key] = rs
+		}
+	}
+	for id, ref := range saved.Orders {
+		if ref != nil && now.Sub(ref.PlacedAt) <= orderRefTTL {
+			st.Orders[id] = ref
+			e.orders[id] = ref
+		}
+	}
+	// A tripped breaker, pauses and a dry-run switch are only cleared
+	// explicitly.
+	e.breaker = saved.Breaker
+	if e.breaker.Open {
+		e.metrics.set(metricBreakerOpen, 1)
+	}
+	e.paused = saved.Paused
+	for id := range saved.PausedRules {
+		e.pausedRules[id] = true
+	}
+	if saved.DryRun {
+		e.config.DryRun, e.dryRunSwitch = true, true
+	}
+	return st, nil
+}
+
+// checkpoint writes the state file if the state changed since the last
+// write. Callers hold e.mu.
+func (e *Engine) checkpoint() {
+	if e.statePath == "" {
+		return
+	}
+	st := EngineState{
+		Cooldowns:        e.cooldowns,
+		TriggerTimes:     e.triggerCount,
+		SessionOrders:    e.sessionOrders,
+		TrackedPositions: e.trackedPositions,
+		CopyAllocations:  e.copyAllocs,
+		RuleStates:       e.ruleStates,
+		Orders:           e.orders,
+		Breaker:          e.breaker,
+		Paused:           e.paused,
+		PausedRules:      e.pausedRules,
+		DryRun:           e.dryRunSwitch,
+	}
+	data, err := json.Marshal(st)
+	if err != nil {
+		log.Printf("[engine] state checkpoint failed: %v", err)
+		return
+	}
+	if bytes.Equal(data, e.savedState) {
+		return
+	}
+
+	// SavedAt is stamped after the comparison so an unchanged state is not
+	// rewritten just because time passed.
+	st.SavedAt = e.now().UTC()
+	out, _ := json.MarshalIndent(st, "", "  ")
+	tmp := e.statePath + ".tmp"
+	if err := os.WriteFile(tmp, out, 0o600); err != nil {
+		log.Printf("[engine] state checkpoint failed: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, e.statePath); err != nil {
+		log.Printf("[engine] state checkpoint failed: %v", err)
+		return
+	}
+	e.savedState = data
+}
+
+// sameTradingDay reports whether a and b fall on the same exchange date.
+func sameTradingDay(a, b time.Time) bool {
+	return broker.TradingDate(a) == broker.TradingDate(b)
+}
@@ -0,0 +1,128 @@
+package signal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEngineState_RestoreAcrossRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	now := time.Date(2026, 2, 10, 15, 0, 0, 0, time.UTC)
+	snap := &Snapshot{UpdatedAt: now.Format(time.RFC3339), KPIs: map[string]float64{"Go": 1}}
+
+	b := &mockBroker{}
+	first := NewEngine(b, DefaultEngineConfig(), make(chan Event, 16))
+	first.SetClock(func() time.Time { return now })
+	first.SetRules([]*Rule{qualityRule()})
+	first.SetStatePath(path)
+	first.Evaluate(context.Background(), snap)
+	if len(b.orders) != 1 {
+		t.Fatalf("orders = %d, want 1", len(b.orders))
+	}
+
+	// A restarted daemon inside the cooldown must not trigger again.
+	later := now.Add(30 * time.Second)
+	second := NewEngine(b, DefaultEngineConfig(), make(chan Event, 16))
+	second.SetClock(func() time.Time { return later })
+	st, err := second.RestoreState(path)
+	if err != nil || st == nil {
+		t.Fatalf("RestoreState: %v, %v", st, err)
+	}
+	if st.SessionOrders != 1 || len(st.Cooldowns) != 1 || len(st.TriggerTimes) != 1 {
+		t.Fatalf("restored = %+v", st)
+	}
+	second.SetRules([]*Rule{qualityRule()})
+	second.Evaluate(context.Background(), &Snapshot{UpdatedAt: later.Format(time.RFC3339), KPIs: map[string]float64{"Go": 1}})
+	if len(b.orders) != 1 {
+		t.Fatalf("restored engine ignored cooldown, orders = %d", len(b.orders))
+	}
+}
+
+func TestEngineState_ExpiresOnRestore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	now := time.Date(2026, 2, 10, 15, 0, 0, 0, time.UTC)
+
+	first := NewEngine(&mockBroker{}, DefaultEngineConfig(), make(chan Event, 16))
+	first.SetClock(func() time.Time { return now })
+	first.SetRules([]*Rule{qualityRule()})
+	first.SetStatePath(path)
+	first.Evaluate(context.Background(), &Snapshot{UpdatedAt: now.Format(time.RFC3339), KPIs: map[string]float64{"Go": 1}})
+
+	// Next trading day: cooldown, hourly triggers and session count are stale.
+	second := NewEngine(nil, DefaultEngineConfig(), nil)
+	second.SetClock(func() time.Time { return now.Add(24 * time.Hour) })
+	st, err := second.RestoreState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.SessionOrders != 0 || len(st.Cooldowns) != 0 || len(st.TriggerTimes) != 0 {
+		t.Fatalf("stale state restored: %+v", st)
+	}
+}
+
+func TestEngineState_MissingAndCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	engine := NewEngine(nil, DefaultEngineConfig(), nil)
+	if st, err := engine.RestoreState(filepath.Join(dir, "none.json")); st != nil || err != nil {
+		t.Fatalf("missing file: %v, %v", st, err)
+	}
+
+	bad := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(bad, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := engine.RestoreState(bad); err == nil {
+		t.Fatal("expected parse error")
+	}
+}
+
+func TestEngineState_TrackedPositionsCheckpointed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	first := NewEngine(nil, DefaultEngineConfig(), nil)
+	first.SetStatePath(path)
+	first.mu.Lock()
+	first.trackedPositions["pos-1"] = "ord-1"
+	first.checkpoint()
+	first.mu.Unlock()
+
+	second := NewEngine(nil, DefaultEngineConfig(), nil)
+	if _, err := second.RestoreState(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := second.TrackedPositions()["pos-1"]; got != "ord-1" {
+		t.Fatalf("tracked position = %q", got)
+	}
+}
+
+func TestEngineState_DryRunSwitchSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	b := &mockBroker{}
+	first := NewEngine(b, DefaultEngineConfig(), make(chan Event, 16))
+	first.SetStatePath(path)
+	if err := first.SetDryRun(true); err != nil {
+		t.Fatal(err)
+	}
+
+	second := NewEngine(b, DefaultEngineConfig(), make(chan Event, 16))
+	if _, err := second.RestoreState(path); err != nil {
+		t.Fatal(err)
+	}
+	second.SetRules([]*Rule{qualityRule()})
+	second.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"Go": 1}})
+	if len(b.orders) != 0 {
+		t.Fatalf("restored engine left dry-run, orders = %+v", b.orders)
+	}
+
+	// Switching it off is persisted too.
+	second.SetStatePath(path)
+	if err := second.SetDryRun(false); err != nil {
+		t.Fatal(err)
+	}
+	third := NewEngine(b, DefaultEngineConfig(), make(chan Event, 16))
+	if st, err := third.RestoreState(path); err != nil || st.DryRun {
+		t.Fatalf("restored = %+v, %v; want dry-run off", st, err)
+	}
+}
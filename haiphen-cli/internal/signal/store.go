@@ -51,6 +51,31 @@ func LoadRulesFromDir(dir string) ([]*Rule, error) {
 	return rules, nil
 }
 
+// LoadActiveRules loads every rule in dir, assigns missing IDs and validates
+// it, returning the active ones. Invalid rules are reported in skipped
+// (keyed by rule name) instead of failing the whole load.
+func LoadActiveRules(dir string, maxOrderQty int) (active []*Rule, skipped map[string]error, err error) {
+	rules, err := LoadRulesFromDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	skipped = make(map[string]error)
+	for _, r := range rules {
+		if r.RuleID == "" {
+			r.RuleID = DeterministicID("", r.Name)
+		}
+		if err := ValidateRule(r, maxOrderQty); err != nil {
+			skipped[r.Name] = err
+			continue
+		}
+		if r.Status == "active" {
+			active = append(active, r)
+		}
+	}
+	return active, skipped, nil
+}
+
 // LoadRuleFile reads and parses a single YAML rule file.
 func LoadRuleFile(path string) (*Rule, error) {
 	data, err := os.ReadFile(path)
@@ -83,8 +108,23 @@ func LoadRuleFile(path string) (*Rule, error) {
 	return &r, nil
 }
 
-// SaveRule writes a rule as a YAML file. Filename is derived from rule name.
+// SaveRule writes a rule as a YAML file, recording the local user as the
+// author of any new version. Filename is derived from rule name.
 func SaveRule(dir string, r *Rule) error {
+	return SaveRuleAs(dir, r, "")
+}
+
+// SaveRuleAs writes a rule as a YAML file and records it in the rule's
+// version history (see history.go), bumping r.Version when the content
+// changed. An empty author records the local user.
+func SaveRuleAs(dir string, r *Rule, author string) error {
+	if author == "" {
+		author = currentAuthor()
+	}
+	if err := recordRevision(dir, r, author); err != nil {
+		return fmt.Errorf("record version: %w", err)
+	}
+
 	data, err := yaml.Marshal(r)
 	if err != nil {
 		return fmt.Errorf("marshal: %w", err)
@@ -4,17 +4,29 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"sort"
 
 	"github.com/haiphen/haiphen-cli/internal/util"
 )
 
-// PushRules bulk-upserts local rules to D1 via the API.
-func PushRules(ctx context.Context, apiOrigin, token string, rules []*Rule) (int, error) {
+// SkippedRule is a rule the API declined to store during a sync.
+type SkippedRule struct {
+	RuleID string `json:"rule_id"`
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// PushRules bulk-upserts local rules to D1 via the API. Each payload carries
+// the rule's version and content hash, so D1 records which revision is live.
+// It returns the number of rules stored and the rules the API skipped; a
+// count the API cannot account for is an error.
+func PushRules(ctx context.Context, apiOrigin, token string, rules []*Rule) (int, []SkippedRule, error) {
 	var payloads []map[string]interface{}
 	for _, r := range rules {
 		p, err := r.ToAPIPayload()
 		if err != nil {
-			return 0, fmt.Errorf("rule %q: %w", r.Name, err)
+			return 0, nil, fmt.Errorf("rule %q: %w", r.Name, err)
 		}
 		payloads = append(payloads, p)
 	}
@@ -25,17 +37,21 @@ func PushRules(ctx context.Context, apiOrigin, token string, rules []*Rule) (int
 
 	data, err := util.ServicePost(ctx, apiOrigin, "/v1/signal/rules/sync", token, body)
 	if err != nil {
-		return 0, err
+		return 0, nil, err
 	}
 
 	var result struct {
-		OK       bool `json:"ok"`
-		Upserted int  `json:"upserted"`
+		OK       bool          `json:"ok"`
+		Upserted int           `json:"upserted"`
+		Skipped  []SkippedRule `json:"skipped"`
 	}
 	if err := json.Unmarshal(data, &result); err != nil {
-		return 0, fmt.Errorf("parse response: %w", err)
+		return 0, nil, fmt.Errorf("parse response: %w", err)
 	}
-	return result.Upserted, nil
+	if result.Upserted+len(result.Skipped) != len(payloads) {
+		return result.Upserted, result.Skipped, fmt.Errorf("API stored %d of %d rules and did not say why", result.Upserted, len(payloads))
+	}
+	return result.Upserted, result.Skipped, nil
 }
 
 // PullEvents fetches signal events from the API since a given timestamp.
@@ -58,3 +74,150 @@ func PullEvents(ctx context.Context, apiOrigin, token, since string) ([]Event, e
 	}
 	return result.Items, nil
 }
+
+// positionEventsPageSize is the most events /v1/position-events returns.
+const positionEventsPageSize = 200
+
+// FetchActivePositions fetches the upstream positions that are currently
+// active, for copy-trade reconciliation. The endpoint returns one page of
+// the most recently synced events; complete is false when the page is full
+// and older active positions may be missing from it.
+func FetchActivePositions(ctx context.Context, apiOrigin, token string) (events []PositionEvent, complete bool, err error) {
+	path := fmt.Sprintf("/v1/position-events?status=active&limit=%d", positionEventsPageSize)
+	data, err := util.ServiceGet(ctx, apiOrigin, path, token)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var result struct {
+		Events []PositionEvent `json:"events"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, false, fmt.Errorf("parse position events: %w", err)
+	}
+	return result.Events, len(result.Events) < positionEventsPageSize, nil
+}
+
+// tradeDatesPageSize is the most dates /v1/trades/dates returns.
+const tradeDatesPageSize = 100
+
+// FetchSnapshotHistory rebuilds intraday snapshots for every trading date in
+// [from, to] from the per-KPI series endpoint. Values carry forward within a
+// day so each snapshot holds the latest reading of every KPI seen so far.
+// Dates without series points fall back to that day's closing snapshot.
+// The API lists only the latest tradeDatesPageSize dates; a from earlier
+// than the oldest of those is an error rather than a shorter history.
+func FetchSnapshotHistory(ctx context.Context, apiOrigin, token string, kpis []string, from, to string) ([]*Snapshot, error) {
+	path := fmt.Sprintf("/v1/trades/dates?limit=%d", tradeDatesPageSize)
+	data, err := util.ServiceGet(ctx, apiOrigin, path, "")
+	if err != nil {
+		return nil, fmt.Errorf("fetch dates: %w", err)
+	}
+	var dates struct {
+		Items []struct {
+			Date string `json:"date"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &dates); err != nil {
+		return nil, fmt.Errorf("parse dates: %w", err)
+	}
+	if len(dates.Items) >= tradeDatesPageSize && from != "" {
+		oldest := dates.Items[0].Date
+		for _, d := range dates.Items {
+			if d.Date < oldest {
+				oldest = d.Date
+			}
+		}
+		if from < oldest {
+			return nil, fmt.Errorf("history before %s is not available: the API lists the latest %d trading dates; use a from date of %s or later", oldest, tradeDatesPageSize, oldest)
+		}
+	}
+
+	var days []string
+	for _, d := range dates.Items {
+		if (from == "" || d.Date >= from) && (to == "" || d.Date <= to) {
+			days = append(days, d.Date)
+		}
+	}
+	sort.Strings(days)
+
+	var snaps []*Snapshot
+	for _, day := range days {
+		daySnaps, err := fetchDaySeries(ctx, apiOrigin, token, kpis, day)
+		if err != nil {
+			return nil, err
+		}
+		if len(daySnaps) == 0 {
+			snap, err := fetchDaySnapshot(ctx, apiOrigin, day)
+			if err != nil {
+				return nil, err
+			}
+			daySnaps = []*Snapshot{snap}
+		}
+		snaps = append(snaps, daySnaps...)
+	}
+	return snaps, nil
+}
+
+func fetchDaySeries(ctx context.Context, apiOrigin, token string, kpis []string, day string) ([]*Snapshot, error) {
+	byTime := make(map[string]map[string]float64)
+	for _, kpi := range kpis {
+		path := fmt.Sprintf("/v1/metrics/series?kpi=%s&date=%s&limit=2000", url.QueryEscape(kpi), day)
+		data, err := util.ServiceGet(ctx, apiOrigin, path, token)
+		if err != nil {
+			return nil, fmt.Errorf("fetch series %q for %s: %w", kpi, day, err)
+		}
+		var series struct {
+			Points []struct {
+				T string  `json:"t"`
+				V float64 `json:"v"`
+			} `json:"points"`
+		}
+		if err := json.Unmarshal(data, &series); err != nil {
+			return nil, fmt.Errorf("parse series %q for %s: %w", kpi, day, err)
+		}
+		for _, p := range series.Points {
+			if byTime[p.T] == nil {
+				byTime[p.T] = make(map[string]float64)
+			}
+			byTime[p.T][kpi] = p.V
+		}
+	}
+
+	times := make([]string, 0, len(byTime))
+	for t := range byTime {
+		times = append(times, t)
+	}
+	sort.Strings(times)
+
+	var snaps []*Snapshot
+	last := make(map[string]float64)
+	for _, t := range times {
+		for k, v := range byTime[t] {
+			last[k] = v
+		}
+		kv := make(map[string]float64, len(last))
+		for k, v := range last {
+			kv[k] = v
+		}
+		snaps = append(snaps, &Snapshot{Date: day, UpdatedAt: t, KPIs: kv, Source: "series"})
+	}
+	return snaps, nil
+}
+
+func fetchDaySnapshot(ctx context.Context, apiOrigin, day string) (*Snapshot, error) {
+	data, err := util.ServiceGet(ctx, apiOrigin, "/v1/trades/latest?date="+day, "")
+	if err != nil {
+		return nil, fmt.Errorf("fetch snapshot for %s: %w", day, err)
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, fmt.Errorf("parse snapshot for %s: %w", day, err)
+	}
+	obj["type"] = "snapshot"
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSnapshot(raw)
+}
@@ -0,0 +1,262 @@
+package signal
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata" // named timezones on hosts without a zoneinfo database
+
+	"github.com/haiphen/haiphen-cli/internal/broker"
+)
+
+// Market sessions, in exchange (New York) time.
+const (
+	SessionPreMarket  = "premarket"  // 04:00-09:30
+	SessionRegular    = "regular"    // 09:30-16:00
+	SessionAfterHours = "afterhours" // 16:00-20:00
+)
+
+var sessionHours = map[string][2]int{ // minutes since midnight, exchange time
+	SessionPreMarket:  {4 * 60, 9*60 + 30},
+	SessionRegular:    {9*60 + 30, 16 * 60},
+	SessionAfterHours: {16 * 60, 20 * 60},
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// TemporalConfig restricts when a rule trades. Outside its window a rule is
+// not evaluated at all; entries are additionally blocked in the last
+// NoEntryBeforeClose minutes of the regular session and after FlattenAt, when
+// any position the rule holds is closed.
+type TemporalConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Timezone (IANA name) for Windows, Days, BlackoutDates and FlattenAt.
+	// Defaults to America/New_York. Sessions are always exchange time.
+	Timezone string `yaml:"timezone,omitempty" json:"timezone,omitempty"`
+	// Sessions limits trading to premarket, regular and/or afterhours.
+	Sessions []string `yaml:"sessions,omitempty" json:"sessions,omitempty"`
+	// Windows are "HH:MM-HH:MM" ranges; a range ending before it starts wraps
+	// past midnight.
+	Windows []string `yaml:"windows,omitempty" json:"windows,omitempty"`
+	// Days are three-letter weekday names (mon, tue, ...).
+	Days []string `yaml:"days,omitempty" json:"days,omitempty"`
+	// BlackoutDates are YYYY-MM-DD dates with no trading.
+	BlackoutDates []string `yaml:"blackout_dates,omitempty" json:"blackout_dates,omitempty"`
+	// NoEntryBeforeClose blocks new entries this many minutes before the
+	// regular session close.
+	NoEntryBeforeClose int `yaml:"no_entry_before_close,omitempty" json:"no_entry_before_close,omitempty"`
+	// FlattenAt ("HH:MM") closes the rule's open position and blocks entries
+	// for the rest of the day. Only rules with exit conditions hold positions.
+	FlattenAt string `yaml:"flatten_at,omitempty" json:"flatten_at,omitempty"`
+}
+
+// tradingWindow is a rule's temporal status at one instant.
+type tradingWindow struct {
+	open    bool // conditions may be evaluated
+	entries bool // new entries may be placed
+	flatten bool // held positions must be closed
+}
+
+// window reports the rule's temporal status at now. A nil or disabled
+// config is always open.
+func (tc *TemporalConfig) window(now time.Time) tradingWindow {
+	if tc == nil || !tc.Enabled {
+		return tradingWindow{open: true, entries: true}
+	}
+	loc, err := loadLocation(tc.Timezone)
+	if err != nil {
+		// Validation rejects unknown zones; fail closed if one slips through.
+		return tradingWindow{}
+	}
+	exch, err := loadLocation(broker.ExchangeTimezone)
+	if err != nil {
+		return tradingWindow{}
+	}
+	local, market := now.In(loc), now.In(exch)
+
+	var w tradingWindow
+	if fa, ok := parseClock(tc.FlattenAt); ok && minuteOfDay(local) >= fa {
+		w.flatten = true
+		return w
+	}
+	if !tc.dayAllowed(local) {
+		return w
+	}
+	if len(tc.Sessions) > 0 && !inAnySession(tc.Sessions, minuteOfDay(market)) {
+		return w
+	}
+	if len(tc.Windows) > 0 && !inAnyWindow(tc.Windows, minuteOfDay(local)) {
+		return w
+	}
+
+	w.open, w.entries = true, true
+	if tc.NoEntryBeforeClose > 0 {
+		closeAt := sessionHours[SessionRegular][1]
+		m := minuteOfDay(market)
+		if m < closeAt && m >= closeAt-tc.NoEntryBeforeClose {
+			w.entries = false
+		}
+	}
+	return w
+}
+
+func (tc *TemporalConfig) dayAllowed(local time.Time) bool {
+	date := local.Format("2006-01-02")
+	for _, d := range tc.BlackoutDates {
+		if d == date {
+			return false
+		}
+	}
+	if len(tc.Days) == 0 {
+		return true
+	}
+	for _, d := range tc.Days {
+		if weekdays[strings.ToLower(d)] == local.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+func inAnySession(sessions []string, m int) bool {
+	for _, s := range sessions {
+		if h, ok := sessionHours[strings.ToLower(s)]; ok && m >= h[0] && m < h[1] {
+			return true
+		}
+	}
+	return false
+}
+
+func inAnyWindow(windows []string, m int) bool {
+	for _, w := range windows {
+		start, end, err := parseTimeRange(w)
+		if err != nil {
+			continue
+		}
+		if start <= end && m >= start && m < end {
+			return true
+		}
+		if start > end && (m >= start || m < end) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the temporal configuration.
+func (tc *TemporalConfig) Validate() error {
+	if _, err := loadLocation(tc.Timezone); err != nil {
+		return fmt.Errorf("temporal: unknown timezone %q", tc.Timezone)
+	}
+	for _, s := range tc.Sessions {
+		if _, ok := sessionHours[strings.ToLower(s)]; !ok {
+			return fmt.Errorf("temporal: invalid session %q: must be one of: %s, %s, %s",
+				s, SessionPreMarket, SessionRegular, SessionAfterHours)
+		}
+	}
+	for _, w := range tc.Windows {
+		if _, _, err := parseTimeRange(w); err != nil {
+			return err
+		}
+	}
+	for _, d := range tc.Days {
+		if _, ok := weekdays[strings.ToLower(d)]; !ok {
+			return fmt.Errorf("temporal: invalid day %q: use mon, tue, wed, thu, fri, sat or sun", d)
+		}
+	}
+	for _, d := range tc.BlackoutDates {
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			return fmt.Errorf("temporal: invalid blackout date %q: use YYYY-MM-DD", d)
+		}
+	}
+	regular := sessionHours[SessionRegular]
+	if tc.NoEntryBeforeClose < 0 || tc.NoEntryBeforeClose > regular[1]-regular[0] {
+		return fmt.Errorf("temporal: no_entry_before_close must be between 0 and %d minutes", regular[1]-regular[0])
+	}
+	if tc.FlattenAt != "" {
+		if _, ok := parseClock(tc.FlattenAt); !ok {
+			return fmt.Errorf("temporal: invalid flatten_at %q: use HH:MM", tc.FlattenAt)
+		}
+	}
+	return nil
+}
+
+// Summary is a compact description of the window for tables, e.g.
+// "regular 09:45-15:30 mon-fri flat@15:55". Disabled configs return "-".
+func (tc *TemporalConfig) Summary() string {
+	if tc == nil || !tc.Enabled {
+		return "-"
+	}
+	var parts []string
+	if len(tc.Sessions) > 0 {
+		parts = append(parts, strings.Join(tc.Sessions, "+"))
+	}
+	if len(tc.Windows) > 0 {
+		parts = append(parts, strings.Join(tc.Windows, ","))
+	}
+	if len(tc.Days) > 0 {
+		parts = append(parts, strings.Join(tc.Days, ","))
+	}
+	if tc.NoEntryBeforeClose > 0 {
+		parts = append(parts, fmt.Sprintf("noentry-%dm", tc.NoEntryBeforeClose))
+	}
+	if tc.FlattenAt != "" {
+		parts = append(parts, "flat@"+tc.FlattenAt)
+	}
+	if len(tc.BlackoutDates) > 0 {
+		parts = append(parts, fmt.Sprintf("%d blackout", len(tc.BlackoutDates)))
+	}
+	if len(parts) == 0 {
+		return "any"
+	}
+	if tc.Timezone != "" && tc.Timezone != broker.ExchangeTimezone {
+		parts = append(parts, tc.Timezone)
+	}
+	return strings.Join(parts, " ")
+}
+
+// parseTimeRange parses "HH:MM-HH:MM" into minutes since midnight.
+func parseTimeRange(w string) (start, end int, err error) {
+	from, to, found := strings.Cut(w, "-")
+	s, ok1 := parseClock(strings.TrimSpace(from))
+	e, ok2 := parseClock(strings.TrimSpace(to))
+	if !found || !ok1 || !ok2 || s == e {
+		return 0, 0, fmt.Errorf("temporal: invalid window %q: use HH:MM-HH:MM", w)
+	}
+	return s, e, nil
+}
+
+// parseClock parses "HH:MM" into minutes since midnight.
+func parseClock(s string) (int, bool) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+func minuteOfDay(t time.Time) int {
+	return t.Hour()*60 + t.Minute()
+}
+
+var locationCache sync.Map
+
+// loadLocation resolves an IANA timezone name, defaulting to exchange time.
+func loadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		name = broker.ExchangeTimezone
+	}
+	if loc, ok := locationCache.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locationCache.Store(name, loc)
+	return loc, nil
+}
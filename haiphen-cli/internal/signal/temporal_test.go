@@ -0,0 +1,150 @@
+package signal
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTemporalConfig_Window(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("timezone data unavailable")
+	}
+	at := func(day, hour, min int) time.Time { return time.Date(2026, 2, day, hour, min, 0, 0, ny) } // Feb 10 2026 is a Tuesday
+
+	tc := &TemporalConfig{
+		Enabled:            true,
+		Sessions:           []string{SessionRegular},
+		Windows:            []string{"09:45-15:50"},
+		Days:               []string{"mon", "tue", "wed", "thu", "fri"},
+		BlackoutDates:      []string{"2026-02-11"},
+		NoEntryBeforeClose: 15,
+		FlattenAt:          "15:55",
+	}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want tradingWindow
+	}{
+		{"before window", at(10, 9, 35), tradingWindow{}},
+		{"in window", at(10, 10, 0), tradingWindow{open: true, entries: true}},
+		{"near close", at(10, 15, 46), tradingWindow{open: true}},
+		{"flatten", at(10, 15, 56), tradingWindow{flatten: true}},
+		{"blackout", at(11, 10, 0), tradingWindow{}},
+		{"weekend", at(14, 10, 0), tradingWindow{}},
+		{"premarket", at(10, 8, 0), tradingWindow{}},
+	}
+	for _, tt := range tests {
+		if got := tc.window(tt.now); got != tt.want {
+			t.Errorf("%s: window = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+
+	// Windows are read in the configured timezone; sessions stay exchange time.
+	la := &TemporalConfig{Enabled: true, Timezone: "America/Los_Angeles", Windows: []string{"06:30-07:00"}}
+	if w := la.window(at(10, 9, 45)); !w.open {
+		t.Errorf("06:45 Los Angeles should be inside the window: %+v", w)
+	}
+	if w := (&TemporalConfig{Enabled: true, Windows: []string{"22:00-02:00"}}).window(at(10, 1, 0)); !w.open {
+		t.Error("overnight window should wrap past midnight")
+	}
+	if w := (&TemporalConfig{Windows: []string{"10:00-11:00"}}).window(at(10, 9, 0)); !w.open || !w.entries {
+		t.Error("disabled config should always be open")
+	}
+}
+
+func TestTemporalConfig_Validate(t *testing.T) {
+	bad := []TemporalConfig{
+		{Timezone: "Mars/Olympus"},
+		{Sessions: []string{"overnight"}},
+		{Windows: []string{"9:30"}},
+		{Windows: []string{"10:00-10:00"}},
+		{Days: []string{"monday"}},
+		{BlackoutDates: []string{"02/11/2026"}},
+		{NoEntryBeforeClose: 500},
+		{FlattenAt: "25:00"},
+	}
+	for _, tc := range bad {
+		if err := tc.Validate(); err == nil {
+			t.Errorf("expected %+v to be invalid", tc)
+		}
+	}
+	ok := TemporalConfig{Enabled: true, Timezone: "Europe/London", Sessions: []string{"regular"}, Windows: []string{"14:45-20:50"}, FlattenAt: "20:55"}
+	if err := ok.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestEngine_TemporalFlatten(t *testing.T) {
+	b := newLifecycleBroker()
+	events := make(chan Event, 64)
+	engine := NewEngine(b, DefaultEngineConfig(), events)
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("timezone data unavailable")
+	}
+	now := time.Date(2026, 2, 10, 15, 40, 0, 0, ny)
+	engine.SetClock(func() time.Time { return now })
+	r := lifecycleRule()
+	r.Temporal = &TemporalConfig{Enabled: true, Windows: []string{"09:30-16:00"}, FlattenAt: "15:50"}
+	engine.SetRules([]*Rule{r})
+
+	engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"In": 1}})
+	b.status["o1"] = "filled"
+	now = now.Add(2 * time.Minute)
+	engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"In": 1}})
+	if st := engine.RuleStates()[0]; st.State != StateLong {
+		t.Fatalf("expected long before flatten, got %+v", st)
+	}
+
+	// Past flatten_at the position is closed even without an exit signal,
+	// and no new entry follows.
+	now = now.Add(10 * time.Minute)
+	engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"In": 1}})
+	if len(b.orders) != 2 || b.orders[1].Side != "sell" || b.orders[1].Qty != 5 {
+		t.Fatalf("orders = %+v", b.orders)
+	}
+	b.status["o2"] = "filled"
+	now = now.Add(2 * time.Minute)
+	engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"In": 1}})
+	if st := engine.RuleStates()[0]; st.State != StateFlat || len(b.orders) != 2 {
+		t.Fatalf("after flatten: %+v (orders=%d)", st, len(b.orders))
+	}
+
+	flattened := false
+	for _, ev := range drainEvents(events) {
+		if ev.EventType == "flatten_triggered" {
+			flattened = true
+		}
+	}
+	if !flattened {
+		t.Error("expected a flatten_triggered event")
+	}
+}
+
+func TestEngine_TemporalFlattenDuringCooldown(t *testing.T) {
+	b := newLifecycleBroker()
+	engine := NewEngine(b, DefaultEngineConfig(), make(chan Event, 64))
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("timezone data unavailable")
+	}
+	now := time.Date(2026, 2, 10, 15, 48, 0, 0, ny)
+	engine.SetClock(func() time.Time { return now })
+	r := lifecycleRule()
+	r.Cooldown = 600
+	r.Temporal = &TemporalConfig{Enabled: true, Windows: []string{"09:30-16:00"}, FlattenAt: "15:50"}
+	engine.SetRules([]*Rule{r})
+
+	engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"In": 1}})
+	b.status["o1"] = "filled"
+
+	// Flatten time arrives 3 minutes into the 10-minute cooldown.
+	now = now.Add(3 * time.Minute)
+	engine.Evaluate(context.Background(), &Snapshot{KPIs: map[string]float64{"In": 1}})
+	if len(b.orders) != 2 || b.orders[1].Side != "sell" {
+		t.Fatalf("orders = %+v, want the entry and a flattening sell", b.orders)
+	}
+}
@@ -8,6 +8,9 @@ import (
 
 var (
 	kpiNameRe     = regexp.MustCompile(`^[\w\s.:/%()+-]{1,100}$`)
+	// Quoted names inside expressions may also contain '&' and ',' (e.g. "Unrealized P&L"),
+	// the set /v1/metrics/series accepts for backtest history.
+	exprKPINameRe = regexp.MustCompile(`^[\w\s.:/%()+,&-]{1,100}$`)
 	validOperators = map[string]bool{
 		">": true, "<": true, ">=": true, "<=": true,
 		"==": true, "!=": true,
@@ -40,13 +43,25 @@ func ValidateRule(r *Rule, maxOrderQty int) error {
 	if r.Entry == nil {
 		return fmt.Errorf("entry conditions are required")
 	}
-	if err := validateConditionGroup(r.Entry, "entry"); err != nil {
-		return err
+	seenSymbols := make(map[string]bool, len(r.Symbols))
+	for _, sym := range r.Symbols {
+		if seenSymbols[sym] {
+			return fmt.Errorf("duplicate symbol %q", sym)
+		}
+		seenSymbols[sym] = true
 	}
-	if r.Exit != nil {
-		if err := validateConditionGroup(r.Exit, "exit"); err != nil {
-			return err
+	if r.UsesSymbolPlaceholder() {
+		// Templated KPI names are checked as resolved for every symbol.
+		if len(r.Symbols) == 0 {
+			return fmt.Errorf("conditions use %s but the rule lists no symbols", SymbolPlaceholder)
+		}
+		for _, sym := range r.Symbols {
+			if err := validateConditions(r.Entry.ForSymbol(sym), r.Exit.ForSymbol(sym), " ("+sym+")"); err != nil {
+				return err
+			}
 		}
+	} else if err := validateConditions(r.Entry, r.Exit, ""); err != nil {
+		return err
 	}
 
 	// Order params
@@ -71,11 +86,41 @@ func ValidateRule(r *Rule, maxOrderQty int) error {
 		return fmt.Errorf("invalid time-in-force %q: must be one of: day, gtc, ioc, fok", r.Order.TIF)
 	}
 
-	if r.Order.Qty <= 0 {
-		return fmt.Errorf("order qty must be positive")
+	if r.Order.Sizing != nil {
+		if err := r.Order.Sizing.Validate(); err != nil {
+			return err
+		}
+		if strings.Contains(r.Order.Sizing.PriceKPI, SymbolPlaceholder) && len(r.Symbols) == 0 {
+			return fmt.Errorf("sizing price_kpi uses %s but the rule lists no symbols", SymbolPlaceholder)
+		}
+	} else {
+		if r.Order.Qty <= 0 {
+			return fmt.Errorf("order qty must be positive")
+		}
+		if maxOrderQty > 0 && int(r.Order.Qty) > maxOrderQty {
+			return fmt.Errorf("order qty %d exceeds max of %d", int(r.Order.Qty), maxOrderQty)
+		}
 	}
-	if maxOrderQty > 0 && int(r.Order.Qty) > maxOrderQty {
-		return fmt.Errorf("order qty %d exceeds max of %d", int(r.Order.Qty), maxOrderQty)
+
+	if err := r.Order.validateExits(); err != nil {
+		return err
+	}
+	if strings.Contains(r.Order.PriceKPI, SymbolPlaceholder) && len(r.Symbols) == 0 {
+		return fmt.Errorf("order price_kpi uses %s but the rule lists no symbols", SymbolPlaceholder)
+	}
+
+	if r.Temporal != nil {
+		if err := r.Temporal.Validate(); err != nil {
+			return err
+		}
+	}
+	if r.DataQuality != nil {
+		if err := r.DataQuality.Validate(); err != nil {
+			return err
+		}
+		if r.DataQuality.usesSymbolPlaceholder() && len(r.Symbols) == 0 {
+			return fmt.Errorf("data_quality uses %s but the rule lists no symbols", SymbolPlaceholder)
+		}
 	}
 
 	// Cooldown
@@ -91,6 +136,18 @@ func ValidateRule(r *Rule, maxOrderQty int) error {
 	return nil
 }
 
+func validateConditions(entry, exit *ConditionGroup, suffix string) error {
+	if err := validateConditionGroup(entry, "entry"+suffix); err != nil {
+		return err
+	}
+	if exit != nil {
+		if err := validateConditionGroup(exit, "exit"+suffix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func validateConditionGroup(g *ConditionGroup, label string) error {
 	if len(g.AllOf) == 0 && len(g.AnyOf) == 0 {
 		return fmt.Errorf("%s: condition group must have all_of or any_of", label)
@@ -112,22 +169,35 @@ func validateConditionGroup(g *ConditionGroup, label string) error {
 }
 
 func validateConditionOrGroup(c *ConditionOrGroup, label string) error {
-	hasLeaf := c.KPI != ""
+	hasLeaf := c.IsLeaf()
 	hasNested := len(c.AllOf) > 0 || len(c.AnyOf) > 0
 
 	if !hasLeaf && !hasNested {
-		return fmt.Errorf("%s: condition must be a leaf (kpi+operator+value) or a nested group (all_of/any_of)", label)
+		return fmt.Errorf("%s: condition must be a leaf (kpi or expr + operator + value) or a nested group (all_of/any_of)", label)
 	}
 
 	if hasLeaf {
-		if !kpiNameRe.MatchString(c.KPI) {
+		if c.KPI != "" && c.Expr != "" {
+			return fmt.Errorf("%s: condition cannot have both kpi and expr", label)
+		}
+		if c.KPI != "" && !kpiNameRe.MatchString(c.KPI) {
 			return fmt.Errorf("%s: invalid KPI name %q (must match [\\w\\s.:/%%()+\\-]{1,100})", label, c.KPI)
 		}
+		if err := validateExpr(c.Expr, "expr", label); err != nil {
+			return err
+		}
+		if err := validateExpr(c.ValueExpr, "value_expr", label); err != nil {
+			return err
+		}
 		if !validOperators[c.Operator] {
 			return fmt.Errorf("%s: invalid operator %q", label, c.Operator)
 		}
 	}
 
+	if !hasLeaf && c.ValueExpr != "" {
+		return fmt.Errorf("%s: value_expr is only allowed on a leaf condition", label)
+	}
+
 	if hasNested {
 		if len(c.AllOf) > 0 && len(c.AnyOf) > 0 {
 			return fmt.Errorf("%s: nested group cannot have both all_of and any_of", label)
@@ -145,3 +215,21 @@ func validateConditionOrGroup(c *ConditionOrGroup, label string) error {
 
 	return nil
 }
+
+// validateExpr checks that an optional expression parses and references only
+// well-formed KPI names.
+func validateExpr(src, field, label string) error {
+	if src == "" {
+		return nil
+	}
+	refs, err := ExprKPIs(src)
+	if err != nil {
+		return fmt.Errorf("%s: invalid %s %q: %v", label, field, src, err)
+	}
+	for _, k := range refs {
+		if !exprKPINameRe.MatchString(k) {
+			return fmt.Errorf("%s: invalid KPI name %q in %s", label, k, field)
+		}
+	}
+	return nil
+}
@@ -147,6 +147,34 @@ func TestValidateRule_InvalidKPIName(t *testing.T) {
 	}
 }
 
+func TestValidateRule_Expr(t *testing.T) {
+	r := &Rule{
+		Name:     "test",
+		Entry:    &ConditionGroup{AllOf: []ConditionOrGroup{{Expr: `"Unrealized P&L" / "Portfolio Value"`, Operator: "<", Value: -0.02}}},
+		Order:    OrderParams{Side: "buy", Type: "market", Qty: 10, TIF: "day"},
+		Cooldown: 60,
+	}
+	if err := ValidateRule(r, 1000); err != nil {
+		t.Fatalf("expected valid expr rule, got: %v", err)
+	}
+
+	r.Entry.AllOf[0].Expr = "A +"
+	if err := ValidateRule(r, 1000); err == nil || !strings.Contains(err.Error(), "invalid expr") {
+		t.Fatalf("expected expr error, got: %v", err)
+	}
+
+	r.Entry.AllOf[0].Expr = `"<script>" * 2`
+	if err := ValidateRule(r, 1000); err == nil || !strings.Contains(err.Error(), "KPI name") {
+		t.Fatalf("expected KPI name error, got: %v", err)
+	}
+
+	r.Entry.AllOf[0].Expr = "A - B"
+	r.Entry.AllOf[0].KPI = "A"
+	if err := ValidateRule(r, 1000); err == nil || !strings.Contains(err.Error(), "both kpi and expr") {
+		t.Fatalf("expected kpi/expr conflict error, got: %v", err)
+	}
+}
+
 func TestValidateRule_EmptyConditionGroup(t *testing.T) {
 	r := &Rule{
 		Name:     "test",